@@ -0,0 +1,35 @@
+package parsecache
+
+import "io"
+
+// MapParser returns a `Parser` which parses a file with `parser`, and then transforms the parsed
+// content with `transform`, so the transformed content is what's cached, and `transform` runs once
+// each time the file is parsed. If `transform` fails, the load fails, and, as with any failed load,
+// the previously cached content is returned alongside the error.
+func MapParser[A, B any](parser Parser[A], transform func(A) (B, error)) Parser[B] {
+	return func(f io.Reader) (B, error) {
+		parsed, err := parser(f)
+		if err != nil {
+			var zero B
+			return zero, err
+		}
+		return transform(parsed)
+	}
+}
+
+// ValidateParser returns a `Parser` which parses a file with `parser`, and then checks the parsed
+// content with `validate`. If `validate` fails, the load fails, so invalid content is never cached,
+// and the previously cached content is returned alongside the error.
+func ValidateParser[T any](parser Parser[T], validate func(T) error) Parser[T] {
+	return func(f io.Reader) (T, error) {
+		parsed, err := parser(f)
+		if err == nil {
+			err = validate(parsed)
+		}
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+		return parsed, nil
+	}
+}
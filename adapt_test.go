@@ -0,0 +1,92 @@
+package parsecache
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestMapParser(t *testing.T) {
+	maxAge := time.Second / 20
+	mapFS := fstest.MapFS{
+		"a.json": &fstest.MapFile{Data: []byte(`{"Hello": "world", "Number": 2}`)},
+	}
+	errTooBig := errors.New("number too big")
+	transforms := 0
+	cache := NewFsCache(mapFS, MapParser(JsonParser[testFileStructure], func(parsed testFileStructure) (map[string]uint16, error) {
+		transforms++
+		if parsed.Number > 10 {
+			return nil, errTooBig
+		}
+		return map[string]uint16{parsed.Hello: parsed.Number}, nil
+	}), maxAge)
+
+	for i := 0; i < 3; i++ {
+		index, err := cache.GetFile("a.json")
+		if err != nil {
+			panic(err)
+		}
+		if index["world"] != 2 {
+			t.Error("a.json not transformed correctly")
+		}
+	}
+	time.Sleep(maxAge)
+	_, err := cache.GetFile("a.json")
+	if err != nil {
+		panic(err)
+	}
+	if transforms != 1 {
+		t.Errorf("transformed %d times without a reparse, expected once", transforms)
+	}
+
+	mapFS["a.json"] = &fstest.MapFile{Data: []byte(`{"Hello": "world", "Number": 20}`), ModTime: time.Now()}
+	time.Sleep(maxAge)
+	index, err := cache.GetFile("a.json")
+	if !errors.Is(err, errTooBig) {
+		t.Errorf("transform error not returned: %v", err)
+	}
+	if index["world"] != 2 {
+		t.Error("previous content not returned for the transform error")
+	}
+	if transforms != 2 {
+		t.Errorf("transformed %d times after one reparse, expected twice", transforms)
+	}
+}
+
+func TestValidateParser(t *testing.T) {
+	maxAge := time.Second / 20
+	mapFS := fstest.MapFS{
+		"a.json": &fstest.MapFile{Data: []byte(`{"Hello": "world"}`)},
+	}
+	errEmpty := errors.New("empty greeting")
+	cache := NewConcurrentFsCache(mapFS, ValidateParser(JsonParser[testFileStructure], func(parsed testFileStructure) error {
+		if parsed.Hello == "" {
+			return errEmpty
+		}
+		return nil
+	}), maxAge)
+
+	a, err := cache.GetFile("a.json")
+	if err != nil {
+		panic(err)
+	}
+	if a.Hello != "world" {
+		t.Error("a.json not parsed correctly")
+	}
+
+	mapFS["a.json"] = &fstest.MapFile{Data: []byte(`{"Number": 1}`), ModTime: time.Now()}
+	time.Sleep(maxAge)
+	a, err = cache.GetFile("a.json")
+	if !errors.Is(err, errEmpty) {
+		t.Errorf("validation error not returned: %v", err)
+	}
+	if a.Hello != "world" {
+		t.Error("previous content not returned for the invalid file")
+	}
+
+	_, err = cache.GetFile("missing.json")
+	if errors.Is(err, errEmpty) {
+		t.Error("missing file validated")
+	}
+}
@@ -0,0 +1,56 @@
+package parsecache
+
+import "time"
+
+// AdaptiveTTL configures per-file maximum ages, which adapt to how often each file changes.
+type AdaptiveTTL struct {
+	// MinTTL is the shortest maximum age of a file, it must be positive.
+	MinTTL time.Duration
+	// MaxTTL is the longest maximum age of a file, it must be at least `MinTTL`.
+	MaxTTL time.Duration
+}
+
+// WithAdaptiveTTL replaces the fixed maximum age of files with one for each file, which adapts to
+// how often the file changes.
+//
+// A file starts with the cache's maximum age, limited to between `MinTTL` and `MaxTTL`. Each time
+// it's revalidated, its maximum age is doubled if the size and modtime show it hasn't changed, or
+// halved if it has, so stable files are stat-ed less often, and frequently changing files are
+// revalidated sooner.
+//
+// Only `GetFile` (and `GetFileCtx`) use the adaptive maximum age, an explicit maximum age given to
+// `GetFileWithMaxAge` is always used as-is. Directories, and files inside archives, aren't
+// affected. The option is ignored if `MinTTL` isn't positive or `MaxTTL` is less than `MinTTL`.
+func WithAdaptiveTTL[T any](ttl AdaptiveTTL) Option[T] {
+	return func(o *options[T]) {
+		o.load.adaptiveTTL = ttl
+	}
+}
+
+// enabled returns true if the adaptive maximum age should be used.
+func (a AdaptiveTTL) enabled() bool {
+	return a.MinTTL > 0 && a.MaxTTL >= a.MinTTL
+}
+
+// clamp limits `ttl` to between `MinTTL` and `MaxTTL`.
+func (a AdaptiveTTL) clamp(ttl time.Duration) time.Duration {
+	if ttl < a.MinTTL {
+		return a.MinTTL
+	}
+	if ttl > a.MaxTTL {
+		return a.MaxTTL
+	}
+	return ttl
+}
+
+// next returns the maximum age of a file after it has been revalidated, given its current maximum
+// age, `ttl`, and whether it had `changed`.
+func (a AdaptiveTTL) next(ttl time.Duration, changed bool) time.Duration {
+	if changed {
+		return a.clamp(ttl / 2)
+	}
+	if ttl > a.MaxTTL/2 {
+		return a.MaxTTL
+	}
+	return a.clamp(ttl * 2)
+}
@@ -0,0 +1,82 @@
+package parsecache
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func adaptiveTTLTests(t *testing.T, cache testInterface, filesystem *countingFS, mapFS fstest.MapFS, ttl func() time.Duration) {
+	get := func() {
+		_, err := cache.GetFile("a.json")
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	// The first TTL is the cache's maxAge.
+	get()
+	if ttl() != 20*time.Millisecond {
+		t.Errorf("initial TTL is %v, expected 20ms", ttl())
+	}
+
+	// Unchanged files get longer TTLs, up to MaxTTL.
+	for _, expected := range []time.Duration{40 * time.Millisecond, 80 * time.Millisecond, 80 * time.Millisecond} {
+		time.Sleep(ttl() + 5*time.Millisecond)
+		get()
+		if ttl() != expected {
+			t.Errorf("TTL of unchanged file is %v, expected %v", ttl(), expected)
+		}
+	}
+	opens := filesystem.Opens()
+	time.Sleep(50 * time.Millisecond)
+	get()
+	if filesystem.Opens() != opens {
+		t.Error("a.json revalidated before its TTL")
+	}
+
+	// Changed files get shorter TTLs, down to MinTTL.
+	for _, expected := range []time.Duration{40 * time.Millisecond, 20 * time.Millisecond, 10 * time.Millisecond, 10 * time.Millisecond} {
+		time.Sleep(ttl() + 5*time.Millisecond)
+		mapFS["a.json"] = &fstest.MapFile{Data: []byte(`{"Hello": "changed"}`), ModTime: time.Now()}
+		get()
+		if ttl() != expected {
+			t.Errorf("TTL of changed file is %v, expected %v", ttl(), expected)
+		}
+	}
+
+	// An explicit maxAge is used as-is, and doesn't change the TTL.
+	opens = filesystem.Opens()
+	_, err := cache.GetFileWithMaxAge("a.json", 0)
+	if err != nil {
+		panic(err)
+	}
+	if filesystem.Opens() != opens+1 || ttl() != 10*time.Millisecond {
+		t.Error("GetFileWithMaxAge didn't use its maxAge")
+	}
+}
+
+func TestAdaptiveTTL(t *testing.T) {
+	adaptive := WithAdaptiveTTL[testFileStructure](AdaptiveTTL{MinTTL: 10 * time.Millisecond, MaxTTL: 80 * time.Millisecond})
+	for _, concurrent := range []bool{false, true} {
+		mapFS := fstest.MapFS{
+			"a.json": &fstest.MapFile{Data: []byte(`{"Hello": "world"}`)},
+		}
+		filesystem := &countingFS{fs: mapFS}
+		if concurrent {
+			cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], 20*time.Millisecond, adaptive)
+			adaptiveTTLTests(t, cache, filesystem, mapFS, func() time.Duration {
+				entry, _ := cache.GetFileEntry("a.json")
+				entry.lock.RLock()
+				defer entry.lock.RUnlock()
+				return entry.cachedFile.ttl
+			})
+		} else {
+			cache := NewFsCache(filesystem, JsonParser[testFileStructure], 20*time.Millisecond, adaptive)
+			adaptiveTTLTests(t, &cache, filesystem, mapFS, func() time.Duration {
+				entry, _ := cache.GetFileEntry("a.json")
+				return entry.ttl
+			})
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package parsecache
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	pathpkg "path"
+	"strings"
+	"sync"
+)
+
+// ArchiveOpener opens the content of an archive as a filesystem. `r` holds the entire archive,
+// which is `size` bytes long.
+type ArchiveOpener func(r io.ReaderAt, size int64) (fs.FS, error)
+
+// archiveFormats is the registry of archive openers, keyed by path suffix.
+var archiveFormats = struct {
+	lock    sync.RWMutex
+	openers map[string]ArchiveOpener
+}{
+	openers: map[string]ArchiveOpener{
+		".zip": openZip,
+	},
+}
+
+// openZip is the `ArchiveOpener` for zip files.
+func openZip(r io.ReaderAt, size int64) (fs.FS, error) {
+	return zip.NewReader(r, size)
+}
+
+// RegisterArchiveFormat registers `opener` for archives with paths ending in `ext`, for example
+// ".tar". Zip files (".zip") are supported by default. If more than one registered suffix matches
+// a path, the longest is used.
+func RegisterArchiveFormat(ext string, opener ArchiveOpener) {
+	archiveFormats.lock.Lock()
+	defer archiveFormats.lock.Unlock()
+	archiveFormats.openers[ext] = opener
+}
+
+// archiveOpenerFor returns the registered `ArchiveOpener` for the path, if there is one.
+func archiveOpenerFor(path string) (opener ArchiveOpener, ok bool) {
+	archiveFormats.lock.RLock()
+	defer archiveFormats.lock.RUnlock()
+	longest := -1
+	for ext, o := range archiveFormats.openers {
+		if len(ext) > longest && strings.HasSuffix(path, ext) {
+			longest = len(ext)
+			opener = o
+			ok = true
+		}
+	}
+	return
+}
+
+// readArchive reads all of `f` into memory and opens it with `opener`.
+func readArchive(f io.Reader, opener ArchiveOpener) (fs.FS, error) {
+	content, err := BytesParser(f)
+	if err != nil {
+		return nil, err
+	}
+	return opener(bytes.NewReader(content), int64(len(content)))
+}
+
+// archive is the parsed content of an archive file, along with the cache of the files inside it.
+//
+// Since the archive's content is immutable, a new `archive` is created whenever the archive file
+// changes, which invalidates all of the inner entries at once.
+type archive[T any] struct {
+	// fs is the content of the archive.
+	fs fs.FS
+
+	// files is the map of cleanedInnerPath -> cachedFile
+	files map[string]*CachedFile[T]
+}
+
+// concurrentArchive is a concurrency safe version of `archive`.
+type concurrentArchive[T any] struct {
+	// fs is the content of the archive.
+	fs fs.FS
+
+	// files is the map of cleanedInnerPath -> cachedFile
+	files     map[string]*ConcurrentCachedFile[T]
+	filesLock sync.RWMutex
+}
+
+// archiveParser returns a `Parser` which opens an archive with `opener`.
+func archiveParser[T any](opener ArchiveOpener) Parser[*archive[T]] {
+	return func(f io.Reader) (*archive[T], error) {
+		archiveFs, err := readArchive(f, opener)
+		if err != nil {
+			return nil, err
+		}
+		return &archive[T]{
+			fs:    archiveFs,
+			files: make(map[string]*CachedFile[T], 4),
+		}, nil
+	}
+}
+
+// concurrentArchiveParser returns a `Parser` which opens an archive with `opener`.
+func concurrentArchiveParser[T any](opener ArchiveOpener) Parser[*concurrentArchive[T]] {
+	return func(f io.Reader) (*concurrentArchive[T], error) {
+		archiveFs, err := readArchive(f, opener)
+		if err != nil {
+			return nil, err
+		}
+		return &concurrentArchive[T]{
+			fs:    archiveFs,
+			files: make(map[string]*ConcurrentCachedFile[T], 4),
+		}, nil
+	}
+}
+
+// archiveConfig returns the `loadConfig` for the entry of an archive itself, which is `config`
+// without compression, since the opened archive can't be encoded, and without the change detector
+// and on-parse hook, which are for the cache's files, not archives.
+func archiveConfig(config loadConfig) loadConfig {
+	config.compress = false
+	config.changeDetector = nil
+	config.onParse = nil
+	return config
+}
+
+// errNoArchiveFormat returns the error for an archive path with no registered `ArchiveOpener`.
+func errNoArchiveFormat(path string) error {
+	return fmt.Errorf("parsecache: no archive format registered for %q", path)
+}
+
+// getFile returns the parsed content of the file at `path` (which must be cleaned) inside the
+// archive, which is at the cleaned `archivePath`.
+//
+// The content of an archive never changes, so once an inner file has been successfully parsed it
+// isn't loaded again.
+func (a *archive[T]) getFile(archivePath, path string, parser fileParser[T], config loadConfig) (T, error) {
+	config.adaptiveTTL = AdaptiveTTL{}
+	cached, ok := a.files[path]
+	if !ok {
+		cached = &CachedFile[T]{}
+		a.files[path] = cached
+	}
+	src := source{
+		path: pathpkg.Join(archivePath, path),
+		open: opener(a.fs, path),
+	}
+	content, err := cached.get(context.Background(), src, parser, forever, config)
+	if err != nil {
+		delete(a.files, path)
+	}
+	return content, err
+}
+
+// getFile returns the parsed content of the file at `path` (which must be cleaned) inside the
+// archive, which is at the cleaned `archivePath`.
+//
+// The content of an archive never changes, so once an inner file has been successfully parsed it
+// isn't loaded again.
+func (a *concurrentArchive[T]) getFile(archivePath, path string, parser fileParser[T], config loadConfig) (T, error) {
+	config.adaptiveTTL = AdaptiveTTL{}
+	a.filesLock.RLock()
+	cached, ok := a.files[path]
+	a.filesLock.RUnlock()
+
+	if !ok {
+		cached = &ConcurrentCachedFile[T]{}
+	}
+
+	src := source{
+		path: pathpkg.Join(archivePath, path),
+		open: opener(a.fs, path),
+	}
+	content, err := cached.get(context.Background(), src, parser, forever, config)
+
+	if !ok && err == nil {
+		a.filesLock.Lock()
+		a.files[path] = cached
+		a.filesLock.Unlock()
+	}
+
+	return content, err
+}
+
+// checkArchivePaths returns an error if `archivePath` or `innerPath` isn't valid and
+// `WithStrictPaths` is used.
+func (o *options[T]) checkArchivePaths(archivePath, innerPath string) error {
+	if err := o.checkPath(archivePath); err != nil {
+		return err
+	}
+	return o.checkPath(innerPath)
+}
+
+// GetArchiveFile returns the parsed content of the file at `innerPath` inside the archive at
+// `archivePath`, which may be cached.
+//
+// The archive must have a format registered with `RegisterArchiveFormat` (zip is supported by
+// default). The opened archive is itself cached like any other file, and is revalidated by its size
+// and modtime. When the archive changes, all of the cached files inside it are invalidated together.
+func (cache *FsCache[T]) GetArchiveFile(archivePath, innerPath string) (T, error) {
+	if err := cache.options.checkArchivePaths(archivePath, innerPath); err != nil {
+		var zero T
+		return zero, err
+	}
+	path := cache.normalize(archivePath)
+	if err := checkNormalized(archivePath, path); err != nil {
+		var zero T
+		return zero, err
+	}
+	archiveOpener, ok := archiveOpenerFor(path)
+	if !ok {
+		var zero T
+		return zero, errNoArchiveFormat(path)
+	}
+
+	key := cache.options.key(path)
+	cached, ok := cache.archives[key]
+	if !ok {
+		cached = &CachedFile[*archive[T]]{}
+		cache.archives[key] = cached
+	}
+	arch, err := cached.get(context.Background(), newSource(cache.fs, cache.options.openPath(path)), archiveParser[T](archiveOpener).fileParser(), cache.MaxAge, archiveConfig(cache.options.load))
+	if err != nil {
+		delete(cache.archives, key)
+		var zero T
+		return zero, err
+	}
+
+	inner := cleanPath(innerPath)
+	return arch.getFile(path, inner, cache.parserFor(inner), cache.options.load)
+}
+
+// GetArchiveFile returns the parsed content of the file at `innerPath` inside the archive at
+// `archivePath`, which may be cached.
+//
+// The archive must have a format registered with `RegisterArchiveFormat` (zip is supported by
+// default). The opened archive is itself cached like any other file, and is revalidated by its size
+// and modtime. When the archive changes, all of the cached files inside it are invalidated together.
+func (cache *ConcurrentFsCache[T]) GetArchiveFile(archivePath, innerPath string) (T, error) {
+	if err := cache.options.checkArchivePaths(archivePath, innerPath); err != nil {
+		var zero T
+		return zero, err
+	}
+	path := cache.normalize(archivePath)
+	if err := checkNormalized(archivePath, path); err != nil {
+		var zero T
+		return zero, err
+	}
+	archiveOpener, ok := archiveOpenerFor(path)
+	if !ok {
+		var zero T
+		return zero, errNoArchiveFormat(path)
+	}
+
+	// Read the existing cache entry (if it exists), the maxAge and the filesystem
+	key := cache.options.key(path)
+	cache.archivesLock.RLock()
+	cached, ok := cache.archives[key]
+	cache.archivesLock.RUnlock()
+	settings := cache.loadSettings()
+
+	// Create a new entry if one didn't exist, we'll insert this later, if the load is successful.
+	if !ok {
+		cached = &ConcurrentCachedFile[*concurrentArchive[T]]{}
+	}
+
+	arch, err := cached.get(context.Background(), newSource(settings.fs, cache.options.openPath(path)), concurrentArchiveParser[T](archiveOpener).fileParser(), settings.maxAge, archiveConfig(cache.options.load))
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	// Insert the new entry if required
+	if !ok {
+		cache.archivesLock.Lock()
+		cache.archives[key] = cached
+		cache.archivesLock.Unlock()
+	}
+
+	inner := cleanPath(innerPath)
+	return arch.getFile(path, inner, cache.parserFor(inner, settings), cache.options.load)
+}
@@ -0,0 +1,163 @@
+package parsecache
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testArchiveInterface interface {
+	GetArchiveFile(string, string) (testFileStructure, error)
+}
+
+// writeTestZip writes a zip file to `path` containing `files`, a map of name -> content.
+func writeTestZip(path string, files map[string]string) {
+	f, err := os.Create(path)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		inner, err := w.Create(name)
+		if err != nil {
+			panic(err)
+		}
+		_, err = inner.Write([]byte(content))
+		if err != nil {
+			panic(err)
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		panic(err)
+	}
+}
+
+func archiveTests(t *testing.T, cache testArchiveInterface, filesystem *countingFS, maxAge time.Duration, dir string) {
+	writeTestZip(filepath.Join(dir, "bundle.zip"), map[string]string{
+		"a.json":        `{"Hello": "world!", "Number": 12}`,
+		"nested/b.json": `{"Number": 600}`,
+	})
+
+	a, err := cache.GetArchiveFile("bundle.zip", "a.json")
+	if err != nil {
+		panic(err)
+	}
+	if a.Hello != "world!" || a.Number != 12 {
+		t.Error("a.json not parsed correctly")
+	}
+	b, err := cache.GetArchiveFile("/bundle.zip", "/nested/../nested/./b.json")
+	if err != nil {
+		panic(err)
+	}
+	if b.Number != 600 {
+		t.Error("nested/b.json not parsed correctly")
+	}
+
+	opens := filesystem.Opens()
+	a, err = cache.GetArchiveFile("bundle.zip", "a.json")
+	if err != nil {
+		panic(err)
+	}
+	b, err = cache.GetArchiveFile("bundle.zip", "nested/b.json")
+	if err != nil {
+		panic(err)
+	}
+	if a.Hello != "world!" || b.Number != 600 {
+		t.Error("archive files not cached correctly")
+	}
+	if filesystem.Opens() != opens {
+		t.Error("cached archive files were read from the filesystem")
+	}
+
+	_, err = cache.GetArchiveFile("bundle.zip", "c.json")
+	if err == nil || !os.IsNotExist(err) {
+		t.Error("GetArchiveFile doesn't return correct error for missing inner file")
+	}
+	_, err = cache.GetArchiveFile("missing.zip", "a.json")
+	if err == nil || !os.IsNotExist(err) {
+		t.Error("GetArchiveFile doesn't return correct error for missing archive")
+	}
+	_, err = cache.GetArchiveFile("bundle.rar", "a.json")
+	if err == nil {
+		t.Error("GetArchiveFile doesn't return an error for an unregistered format")
+	}
+
+	// Replace the archive, all of the inner files should be invalidated together.
+	writeTestZip(filepath.Join(dir, "bundle.zip"), map[string]string{
+		"a.json": `{"Hello": "replaced", "Number": 13}`,
+		"c.json": `{"Number": 700}`,
+	})
+	a, err = cache.GetArchiveFile("bundle.zip", "a.json")
+	if err != nil {
+		panic(err)
+	}
+	if a.Hello != "world!" {
+		t.Error("a.json not cached correctly")
+	}
+	time.Sleep(maxAge + time.Second/10)
+	a, err = cache.GetArchiveFile("bundle.zip", "a.json")
+	if err != nil {
+		panic(err)
+	}
+	if a.Hello != "replaced" || a.Number != 13 {
+		t.Error("a.json not invalidated correctly")
+	}
+	c, err := cache.GetArchiveFile("bundle.zip", "c.json")
+	if err != nil {
+		panic(err)
+	}
+	if c.Number != 700 {
+		t.Error("c.json not parsed correctly")
+	}
+	_, err = cache.GetArchiveFile("bundle.zip", "nested/b.json")
+	if err == nil || !os.IsNotExist(err) {
+		t.Error("nested/b.json not invalidated correctly")
+	}
+}
+
+func TestArchive(t *testing.T) {
+	maxAge := time.Second / 2
+	dir, err := os.MkdirTemp("", "parsecache-test-archive-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	filesystem := &countingFS{fs: os.DirFS(dir)}
+	cache := NewFsCache[testFileStructure](filesystem, JsonParser[testFileStructure], maxAge)
+	archiveTests(t, &cache, filesystem, maxAge, dir)
+}
+
+func TestArchiveConcurrent(t *testing.T) {
+	maxAge := time.Second / 2
+	dir, err := os.MkdirTemp("", "parsecache-test-archive-concurrent-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	filesystem := &countingFS{fs: os.DirFS(dir)}
+	cache := NewConcurrentFsCache[testFileStructure](filesystem, JsonParser[testFileStructure], maxAge)
+	archiveTests(t, cache, filesystem, maxAge, dir)
+}
+
+func TestArchiveCompression(t *testing.T) {
+	maxAge := time.Second / 2
+	for _, concurrent := range []bool{false, true} {
+		dir, err := os.MkdirTemp("", "parsecache-test-archive-compression-*")
+		if err != nil {
+			panic(err)
+		}
+		defer os.RemoveAll(dir)
+		filesystem := &countingFS{fs: os.DirFS(dir)}
+		opts := []Option[testFileStructure]{WithCompression[testFileStructure]()}
+		if concurrent {
+			archiveTests(t, NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], maxAge, opts...), filesystem, maxAge, dir)
+		} else {
+			cache := NewFsCache(filesystem, JsonParser[testFileStructure], maxAge, opts...)
+			archiveTests(t, &cache, filesystem, maxAge, dir)
+		}
+	}
+}
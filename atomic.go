@@ -0,0 +1,43 @@
+package parsecache
+
+import (
+	"context"
+	"io"
+	"io/fs"
+)
+
+// ScratchParser parses content into `scratch`, a new zero value of type `T` for each parse, rather
+// than returning it, like `Parser`. It must only write to `scratch`, and values it allocates, and
+// not to any value it shares between parses.
+type ScratchParser[T any] func(r io.Reader, scratch *T) error
+
+// fileParser returns a `fileParser` which calls `parser` with a new scratch value, which is only
+// returned once `parser` succeeds.
+func (parser ScratchParser[T]) fileParser() fileParser[T] {
+	return func(_ context.Context, f fs.File, _ fs.FileInfo) (T, error) {
+		scratch := new(T)
+		if err := parser(f, scratch); err != nil {
+			var zero T
+			return zero, err
+		}
+		return *scratch, nil
+	}
+}
+
+// GetFileAtomic returns the parsed content of a file, which may be cached, like `GetFile`, but if
+// the file is parsed, it's parsed by `parser` into a new scratch value, instead of by the cache's
+// parser. The scratch value only replaces the cached content once `parser` returns without an
+// error, and is never shared with the cached content, so the content returned can't be one which a
+// parse has modified partway through, even if `T` is a pointer, slice or map.
+func (cache *FsCache[T]) GetFileAtomic(file string, parser ScratchParser[T]) (T, error) {
+	return cache.getFile(file, cache.MaxAge, cache.options.load, parser.fileParser())
+}
+
+// GetFileAtomic returns the parsed content of a file, which may be cached, like `GetFile`, but if
+// the file is parsed, it's parsed by `parser` into a new scratch value, instead of by the cache's
+// parser. The scratch value only replaces the cached content once `parser` returns without an
+// error, and is never shared with the cached content, so the content returned can't be one which a
+// parse has modified partway through, even if `T` is a pointer, slice or map.
+func (cache *ConcurrentFsCache[T]) GetFileAtomic(file string, parser ScratchParser[T]) (T, error) {
+	return cache.getFile(context.Background(), file, 0, false, nil, parser.fileParser())
+}
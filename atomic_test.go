@@ -0,0 +1,61 @@
+package parsecache
+
+import (
+	"encoding/json"
+	"io"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestGetFileAtomic(t *testing.T) {
+	maxAge := time.Second / 20
+	// shared is the value a mutating parser decodes into each time, which would be modified by a
+	// parse which fails partway through.
+	shared := &testFileStructure{}
+	mutating := func(r io.Reader) (*testFileStructure, error) {
+		return shared, json.NewDecoder(r).Decode(shared)
+	}
+	scratch := func(r io.Reader, scratch **testFileStructure) error {
+		return json.NewDecoder(r).Decode(scratch)
+	}
+	for _, concurrent := range []bool{false, true} {
+		mapFS := fstest.MapFS{
+			"a.json": &fstest.MapFile{Data: []byte(`{"Hello": "world!"}`)},
+		}
+		var cache interface {
+			GetFileAtomic(string, ScratchParser[*testFileStructure]) (*testFileStructure, error)
+		}
+		if concurrent {
+			cache = NewConcurrentFsCache(mapFS, mutating, maxAge)
+		} else {
+			c := NewFsCache(mapFS, mutating, maxAge)
+			cache = &c
+		}
+		before, err := cache.GetFileAtomic("a.json", scratch)
+		if err != nil || before.Hello != "world!" {
+			t.Errorf("concurrent=%v: a.json not parsed correctly: %+v, %v", concurrent, before, err)
+		}
+		if before == shared {
+			t.Errorf("concurrent=%v: a.json parsed into the shared value", concurrent)
+		}
+
+		// The new content is decoded partway, into "Hello", before the parse fails.
+		mapFS["a.json"] = &fstest.MapFile{Data: []byte(`{"Hello": "changed", "Number": nope}`), ModTime: time.Now()}
+		time.Sleep(maxAge)
+		after, err := cache.GetFileAtomic("a.json", scratch)
+		if !isParseError(err) {
+			t.Errorf("concurrent=%v: a.json didn't fail to parse: %v", concurrent, err)
+		}
+		if after != before || before.Hello != "world!" {
+			t.Errorf("concurrent=%v: failed parse modified the cached content: %+v", concurrent, after)
+		}
+
+		mapFS["a.json"] = &fstest.MapFile{Data: []byte(`{"Hello": "changed"}`), ModTime: time.Now().Add(time.Second)}
+		time.Sleep(maxAge)
+		after, err = cache.GetFileAtomic("a.json", scratch)
+		if err != nil || after.Hello != "changed" || before.Hello != "world!" {
+			t.Errorf("concurrent=%v: a.json change not seen: %+v, %+v, %v", concurrent, before, after, err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package parsecache
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// The byte order marks detected by `DecodeTextParser`.
+var (
+	bomUtf8    = []byte{0xef, 0xbb, 0xbf}
+	bomUtf16LE = []byte{0xff, 0xfe}
+	bomUtf16BE = []byte{0xfe, 0xff}
+)
+
+// errUtf16OddLength is returned when a UTF-16 file has an odd number of bytes.
+var errUtf16OddLength = errors.New("parsecache: utf-16: odd number of bytes")
+
+// DecodeTextParser returns a `Parser` which detects the encoding of a text file by its byte order
+// mark, and parses it with `inner` as UTF-8, without the byte order mark. UTF-8, UTF-16LE and
+// UTF-16BE byte order marks are detected, and UTF-16 files are converted to UTF-8 as they're read,
+// with invalid surrogates replaced by U+FFFD. Files without a byte order mark are passed to `inner`
+// unchanged, without being copied.
+func DecodeTextParser[T any](inner Parser[T]) Parser[T] {
+	return func(f io.Reader) (T, error) {
+		var head [3]byte
+		n, err := io.ReadFull(f, head[:])
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			var zero T
+			return zero, err
+		}
+		switch {
+		case bytes.HasPrefix(head[:n], bomUtf8):
+			return inner(f)
+		case bytes.HasPrefix(head[:n], bomUtf16LE):
+			return inner(&utf16Reader{r: io.MultiReader(bytes.NewReader(head[2:n]), f), littleEndian: true})
+		case bytes.HasPrefix(head[:n], bomUtf16BE):
+			return inner(&utf16Reader{r: io.MultiReader(bytes.NewReader(head[2:n]), f)})
+		}
+		return inner(io.MultiReader(bytes.NewReader(head[:n]), f))
+	}
+}
+
+// utf16Reader converts UTF-16 read from `r` to UTF-8.
+type utf16Reader struct {
+	r            io.Reader
+	littleEndian bool
+	// in is the UTF-16 which has been read, but not converted.
+	in [4096]byte
+	// inLen is the length of the content of `in`.
+	inLen int
+	// out is the converted content which is ready to be read.
+	out []byte
+	err error
+}
+
+func (r *utf16Reader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 && r.err == nil {
+		r.fill()
+	}
+	if len(r.out) > 0 {
+		n := copy(p, r.out)
+		r.out = r.out[n:]
+		return n, nil
+	}
+	return 0, r.err
+}
+
+// fill reads and converts the next chunk of `r.r`, setting `r.err` once it fails.
+func (r *utf16Reader) fill() {
+	n, err := r.r.Read(r.in[r.inLen:])
+	r.inLen += n
+	units := r.in[:r.inLen]
+
+	r.out = r.out[:0]
+	for len(units) >= 2 {
+		u1 := r.unit(units)
+		if !utf16.IsSurrogate(rune(u1)) {
+			r.out = utf8.AppendRune(r.out, rune(u1))
+			units = units[2:]
+			continue
+		}
+		if len(units) < 4 && err == nil {
+			// Wait for the rest of the surrogate pair.
+			break
+		}
+		decoded := utf8.RuneError
+		consumed := 2
+		if len(units) >= 4 {
+			if pair := utf16.DecodeRune(rune(u1), rune(r.unit(units[2:]))); pair != utf8.RuneError {
+				decoded = pair
+				consumed = 4
+			}
+		}
+		r.out = utf8.AppendRune(r.out, decoded)
+		units = units[consumed:]
+	}
+	r.inLen = copy(r.in[:], units)
+
+	if err != nil {
+		if err == io.EOF && r.inLen != 0 {
+			err = errUtf16OddLength
+		}
+		r.err = err
+	}
+}
+
+// unit returns the UTF-16 code unit at the start of `b`.
+func (r *utf16Reader) unit(b []byte) uint16 {
+	if r.littleEndian {
+		return uint16(b[0]) | uint16(b[1])<<8
+	}
+	return uint16(b[0])<<8 | uint16(b[1])
+}
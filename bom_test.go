@@ -0,0 +1,70 @@
+package parsecache
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+)
+
+// encodeUtf16 encodes `s` as UTF-16 with a byte order mark.
+func encodeUtf16(s string, littleEndian bool) []byte {
+	units := append([]uint16{0xfeff}, utf16.Encode([]rune(s))...)
+	encoded := make([]byte, 0, len(units)*2)
+	for _, u := range units {
+		if littleEndian {
+			encoded = append(encoded, byte(u), byte(u>>8))
+		} else {
+			encoded = append(encoded, byte(u>>8), byte(u))
+		}
+	}
+	return encoded
+}
+
+func TestDecodeTextParser(t *testing.T) {
+	content := `{"Hello": "wörld 🌍!", "Number": 12}`
+	fixtures := map[string][]byte{
+		"no BOM":   []byte(content),
+		"UTF-8":    append([]byte("\uFEFF"), content...),
+		"UTF-16LE": encodeUtf16(content, true),
+		"UTF-16BE": encodeUtf16(content, false),
+	}
+	parser := DecodeTextParser(JsonParser[testFileStructure])
+	for name, fixture := range fixtures {
+		for _, r := range []io.Reader{strings.NewReader(string(fixture)), iotest.OneByteReader(strings.NewReader(string(fixture)))} {
+			parsed, err := parser(r)
+			if err != nil {
+				t.Errorf("%s failed to parse: %v", name, err)
+			}
+			if parsed.Hello != "wörld 🌍!" || parsed.Number != 12 {
+				t.Errorf("%s not parsed correctly: %+v", name, parsed)
+			}
+		}
+	}
+
+	text := DecodeTextParser(StringParser)
+	for _, fixture := range []string{"", "a", "ab"} {
+		decoded, err := text(strings.NewReader(fixture))
+		if err != nil || decoded != fixture {
+			t.Errorf("short file %q not passed through: %q, %v", fixture, decoded, err)
+		}
+	}
+	decoded, err := text(strings.NewReader(string(encodeUtf16("", true))))
+	if err != nil || decoded != "" {
+		t.Errorf("empty UTF-16 file not decoded correctly: %q, %v", decoded, err)
+	}
+
+	// An unpaired surrogate is replaced.
+	lone := append(encodeUtf16("a", true), 0x00, 0xd8, 'b', 0x00)
+	decoded, err = text(strings.NewReader(string(lone)))
+	if err != nil || decoded != "a�b" {
+		t.Errorf("unpaired surrogate not replaced: %q, %v", decoded, err)
+	}
+
+	_, err = text(strings.NewReader(string(append(encodeUtf16("a", false), 'b'))))
+	if !errors.Is(err, errUtf16OddLength) {
+		t.Errorf("UTF-16 file with an odd length didn't fail: %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package parsecache
+
+import (
+	"errors"
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// CircuitBreaker configures the circuit breaker set by `WithCircuitBreaker`.
+type CircuitBreaker struct {
+	// MaxFailures is the number of consecutive parse failures of a file after which the circuit
+	// opens.
+	MaxFailures int
+	// ResetAfter is how long the circuit stays open before another load is attempted.
+	ResetAfter time.Duration
+}
+
+// WithCircuitBreaker stops files which repeatedly fail to parse from being loaded over and over.
+//
+// After `breaker.MaxFailures` consecutive parse failures of a file, the circuit for it opens, and
+// getting the file returns the last parse error immediately, without touching the filesystem (along
+// with the previously cached content, if there is any). After `breaker.ResetAfter`, the circuit is
+// half-open: one more load is attempted, which closes the circuit if it succeeds, or opens it again
+// if it fails. Errors other than parse errors, such as the file not existing, don't count as
+// failures.
+func WithCircuitBreaker[T any](breaker CircuitBreaker) Option[T] {
+	return func(o *options[T]) {
+		o.circuitBreaker = breaker
+	}
+}
+
+// circuitState is the circuit breaker state of a single file.
+type circuitState struct {
+	// failures is the number of consecutive parse failures.
+	failures int
+	// lastErr is the last parse error.
+	lastErr error
+	// openedAt is the time the circuit last opened.
+	openedAt time.Time
+}
+
+// circuitBreakers tracks the circuit breaker state of the files in a cache. A nil `circuitBreakers`
+// never opens.
+type circuitBreakers struct {
+	config CircuitBreaker
+
+	lock   sync.Mutex
+	states map[string]*circuitState
+}
+
+// newCircuitBreakers returns the `circuitBreakers` for `config`, or nil if it's disabled.
+func newCircuitBreakers(config CircuitBreaker) *circuitBreakers {
+	if config.MaxFailures <= 0 {
+		return nil
+	}
+	return &circuitBreakers{
+		config: config,
+		states: make(map[string]*circuitState),
+	}
+}
+
+// check returns the last parse error of the file at the cleaned `path` if its circuit is open.
+func (b *circuitBreakers) check(path string) error {
+	if b == nil {
+		return nil
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	state, ok := b.states[path]
+	if !ok || state.failures < b.config.MaxFailures {
+		return nil
+	}
+	if time.Since(state.openedAt) < b.config.ResetAfter {
+		return state.lastErr
+	}
+	return nil
+}
+
+// record updates the state of the file at the cleaned `path` with the result of a load.
+func (b *circuitBreakers) record(path string, err error) {
+	if b == nil {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if !isParseError(err) {
+		if err == nil {
+			delete(b.states, path)
+		}
+		return
+	}
+	state, ok := b.states[path]
+	if !ok {
+		state = &circuitState{}
+		b.states[path] = state
+	}
+	state.failures++
+	state.lastErr = err
+	if state.failures >= b.config.MaxFailures {
+		state.openedAt = time.Now()
+	}
+}
+
+// clear resets the state of every file.
+func (b *circuitBreakers) clear() {
+	if b == nil {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.states = make(map[string]*circuitState)
+}
+
+// isParseError returns true if `err` is from parsing a file, rather than from the filesystem.
+func isParseError(err error) bool {
+	var panicErr *ParserPanicError
+	if errors.As(err, &panicErr) {
+		return true
+	}
+	var pathErr *fs.PathError
+	return errors.As(err, &pathErr) && pathErr.Op == "parsecache.parse"
+}
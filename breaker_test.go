@@ -0,0 +1,83 @@
+package parsecache
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func circuitBreakerTests(t *testing.T, cache testInterface, filesystem *countingFS, mapFS fstest.MapFS, resetAfter time.Duration) {
+	for i := 0; i < 2; i++ {
+		_, err := cache.GetFile("bad.json")
+		if !isParseError(err) {
+			t.Fatalf("bad.json didn't fail to parse: %v", err)
+		}
+	}
+	if filesystem.Opens() != 2 {
+		t.Error("bad.json not opened for each failure")
+	}
+
+	// The circuit is now open.
+	_, err := cache.GetFile("bad.json")
+	if !isParseError(err) {
+		t.Error("open circuit doesn't return the last parse error")
+	}
+	if filesystem.Opens() != 2 {
+		t.Error("open circuit touched the filesystem")
+	}
+
+	// Missing files don't count as failures.
+	for i := 0; i < 3; i++ {
+		cache.GetFile("missing.json")
+	}
+	if filesystem.Opens() != 5 {
+		t.Error("missing files shouldn't open the circuit")
+	}
+
+	// After ResetAfter, one attempt is made, which opens the circuit again.
+	time.Sleep(resetAfter)
+	cache.GetFile("bad.json")
+	cache.GetFile("bad.json")
+	if filesystem.Opens() != 6 {
+		t.Error("half-open circuit didn't make exactly one attempt")
+	}
+
+	// Once the file is fixed, the circuit closes.
+	mapFS["bad.json"] = &fstest.MapFile{Data: []byte(`{"Hello": "fixed"}`), ModTime: time.Now()}
+	time.Sleep(resetAfter)
+	fixed, err := cache.GetFile("bad.json")
+	if err != nil {
+		panic(err)
+	}
+	if fixed.Hello != "fixed" {
+		t.Error("bad.json not parsed correctly once fixed")
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	resetAfter := time.Second / 10
+	breaker := WithCircuitBreaker[testFileStructure](CircuitBreaker{MaxFailures: 2, ResetAfter: resetAfter})
+	for _, concurrent := range []bool{false, true} {
+		mapFS := fstest.MapFS{
+			"bad.json": &fstest.MapFile{Data: []byte(`{"Hello": nope}`)},
+		}
+		filesystem := &countingFS{fs: mapFS}
+		if concurrent {
+			cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], 0, breaker)
+			circuitBreakerTests(t, cache, filesystem, mapFS, resetAfter)
+		} else {
+			cache := NewFsCache(filesystem, JsonParser[testFileStructure], 0, breaker)
+			circuitBreakerTests(t, &cache, filesystem, mapFS, resetAfter)
+		}
+	}
+}
+
+func TestIsParseError(t *testing.T) {
+	if isParseError(errors.New("other")) || isParseError(nil) {
+		t.Error("other errors treated as parse errors")
+	}
+	if !isParseError(&ParserPanicError{}) {
+		t.Error("parser panics not treated as parse errors")
+	}
+}
@@ -0,0 +1,184 @@
+package parsecache
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	pathpkg "path"
+	"time"
+)
+
+// NewCachedFS returns a read-only `fs.FS` view of `cache`, whose files are read from the content
+// cached by `cache`, and whose directories list the cached directory entries, so the cache can be
+// used by anything which reads an `fs.FS`, such as `fs.Glob`, `http.FS` and template loading, and
+// files are revalidated, like any other gets of the cache, when they're opened.
+//
+// `cache` should parse files with `BytesParser`, so the content of each file is its raw bytes. The
+// `fs.FileInfo` of a file or directory is that of its cache entry, with the size, modtime and mode
+// it had when it was last loaded. An opened file keeps the content it had when it was opened, and
+// later opens see any changes to the underlying file once the entry reaches its maximum age.
+func NewCachedFS(cache *ConcurrentFsCache[[]byte]) fs.FS {
+	return cachedFS[[]byte]{cache, func(content []byte) ([]byte, error) { return content, nil }}
+}
+
+// FS returns a read-only `fs.FS` view of the cache, like `NewCachedFS`, but for a cache of any type,
+// whose files are read from their parsed content, serialized again: a `[]byte` or `string` is used
+// as it is, an `encoding.TextMarshaler` is encoded with `MarshalText`, and anything else is encoded
+// as JSON. The `fs.FileInfo` of a file is that of its cache entry, so its size is that of the file
+// it was parsed from, not of the serialized content. A file whose content fails to be serialized
+// fails to be opened.
+func (cache *ConcurrentFsCache[T]) FS() fs.FS {
+	return cachedFS[T]{cache, serialize[T]}
+}
+
+// serialize encodes `content` for `ConcurrentFsCache.FS`.
+func serialize[T any](content T) ([]byte, error) {
+	switch c := any(content).(type) {
+	case []byte:
+		return c, nil
+	case string:
+		return []byte(c), nil
+	case encoding.TextMarshaler:
+		return c.MarshalText()
+	}
+	return json.Marshal(content)
+}
+
+// cachedFS is the `fs.FS` returned by `NewCachedFS` and `ConcurrentFsCache.FS`.
+type cachedFS[T any] struct {
+	cache *ConcurrentFsCache[T]
+	// encode returns the content of a file from its parsed content.
+	encode func(T) ([]byte, error)
+}
+
+func (c cachedFS[T]) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	// Directories are opened as files first, unless they're already cached, and a file which has
+	// become a directory, or the other way around, is opened as the other.
+	if _, ok := c.cache.GetDirEntry(name); ok {
+		dir, err := c.openDir(name)
+		if !errors.Is(err, ErrNotADirectory) {
+			return dir, err
+		}
+	}
+	file, err := c.openFile(name)
+	if errors.Is(err, ErrIsADirectory) {
+		return c.openDir(name)
+	}
+	return file, err
+}
+
+// openFile opens the file `name`.
+func (c cachedFS[T]) openFile(name string) (fs.File, error) {
+	parsed, err := c.cache.GetFile(name)
+	if err != nil {
+		return nil, openErr(name, err)
+	}
+	content, err := c.encode(parsed)
+	if err != nil {
+		return nil, openErr(name, err)
+	}
+	entry, ok := c.cache.GetFileEntry(name)
+	if !ok {
+		// The entry was cleared or evicted, so its info is that of the content.
+		return &cachedFile{Reader: bytes.NewReader(content), info: cachedInfo{name: pathpkg.Base(name), size: int64(len(content))}}, nil
+	}
+	entry.lock.RLock()
+	info := cachedInfo{pathpkg.Base(name), entry.cachedFile.lastSize, entry.cachedFile.lastModTime, entry.cachedFile.lastMode}
+	entry.lock.RUnlock()
+	return &cachedFile{Reader: bytes.NewReader(content), info: info}, nil
+}
+
+// openDir opens the directory `name`.
+func (c cachedFS[T]) openDir(name string) (fs.File, error) {
+	entries, err := c.cache.GetDirShared(name)
+	if err != nil {
+		return nil, openErr(name, err)
+	}
+	info := cachedInfo{name: pathpkg.Base(name), mode: fs.ModeDir}
+	if entry, ok := c.cache.GetDirEntry(name); ok {
+		entry.lock.RLock()
+		info.size, info.modTime, info.mode = entry.cachedDir.lastSize, entry.cachedDir.lastModTime, entry.cachedDir.lastMode
+		entry.lock.RUnlock()
+	}
+	// The entries are copied, so they can't be modified through the opened directory.
+	return &cachedDir{name: name, info: info, entries: append([]fs.DirEntry(nil), entries...)}, nil
+}
+
+// openErr returns the error from opening `name`, which failed with `err`.
+func openErr(name string, err error) error {
+	return &fs.PathError{Op: "open", Path: name, Err: unwrapPathErr(err)}
+}
+
+// cachedInfo is the `fs.FileInfo` of a file or directory opened from a `NewCachedFS`, or of a
+// directory merged from the layers of a `NewOverlayFsCache`.
+type cachedInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	mode    fs.FileMode
+}
+
+func (i cachedInfo) Name() string       { return i.name }
+func (i cachedInfo) Size() int64        { return i.size }
+func (i cachedInfo) Mode() fs.FileMode  { return i.mode }
+func (i cachedInfo) ModTime() time.Time { return i.modTime }
+func (i cachedInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i cachedInfo) Sys() any           { return nil }
+
+// cachedFile is a file opened from a `NewCachedFS`.
+type cachedFile struct {
+	*bytes.Reader
+	info cachedInfo
+}
+
+func (f *cachedFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *cachedFile) Close() error {
+	return nil
+}
+
+// cachedDir is a directory opened from a `NewCachedFS`.
+type cachedDir struct {
+	name string
+	info cachedInfo
+	// entries are the entries which haven't been read yet.
+	entries []fs.DirEntry
+}
+
+func (d *cachedDir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *cachedDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: ErrIsADirectory}
+}
+
+func (d *cachedDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(d.entries) {
+		n = len(d.entries)
+	}
+	entries := d.entries[:n:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
+
+func (d *cachedDir) Close() error {
+	return nil
+}
@@ -0,0 +1,101 @@
+package parsecache
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestCachedFS(t *testing.T) {
+	modTime := time.Now().Add(-time.Hour)
+	filesystem := fstest.MapFS{
+		"a.txt":         &fstest.MapFile{Data: []byte("a"), ModTime: modTime},
+		"dir/b.txt":     &fstest.MapFile{Data: []byte("bb"), ModTime: modTime, Mode: 0o600},
+		"dir/sub/c.txt": &fstest.MapFile{Data: []byte("ccc"), ModTime: modTime},
+		"empty":         &fstest.MapFile{Mode: fs.ModeDir | 0o755, ModTime: modTime},
+	}
+	cache := NewConcurrentFsCache(filesystem, BytesParser, time.Minute)
+	fsys := NewCachedFS(cache)
+	if err := fstest.TestFS(fsys, "a.txt", "dir/b.txt", "dir/sub/c.txt", "empty"); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := fsys.Open("missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing.txt didn't fail with fs.ErrNotExist: %v", err)
+	}
+	if _, err := fsys.Open("/a.txt"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("/a.txt didn't fail with fs.ErrInvalid: %v", err)
+	}
+	if _, ok := cache.GetFileEntry("dir/sub/c.txt"); !ok {
+		t.Error("dir/sub/c.txt not cached")
+	}
+}
+
+func TestCachedFSChanges(t *testing.T) {
+	modTime := time.Now().Add(-time.Hour)
+	filesystem := fstest.MapFS{
+		"a.txt": &fstest.MapFile{Data: []byte("before"), ModTime: modTime},
+	}
+	cache := NewConcurrentFsCache(filesystem, BytesParser, 0)
+	fsys := NewCachedFS(cache)
+
+	opened, err := fsys.Open("a.txt")
+	if err != nil {
+		panic(err)
+	}
+	defer opened.Close()
+	filesystem["a.txt"] = &fstest.MapFile{Data: []byte("changed!"), ModTime: modTime.Add(time.Minute)}
+
+	content, err := fs.ReadFile(fsys, "a.txt")
+	if err != nil || string(content) != "changed!" {
+		t.Errorf("change not seen by a later open: %q, %v", content, err)
+	}
+	info, err := fs.Stat(fsys, "a.txt")
+	if err != nil || info.Size() != 8 || !info.ModTime().Equal(modTime.Add(time.Minute)) {
+		t.Errorf("incorrect info after the change: %+v, %v", info, err)
+	}
+
+	// The file opened before the change keeps its content.
+	buf := make([]byte, 16)
+	n, _ := opened.Read(buf)
+	if string(buf[:n]) != "before" {
+		t.Errorf("opened file's content changed: %q", buf[:n])
+	}
+
+	// A file replaced by a directory is opened as one.
+	delete(filesystem, "a.txt")
+	filesystem["a.txt/b.txt"] = &fstest.MapFile{Data: []byte("b"), ModTime: modTime}
+	entries, err := fs.ReadDir(fsys, "a.txt")
+	if err != nil || len(entries) != 1 || entries[0].Name() != "b.txt" {
+		t.Errorf("directory replacing a file not read: %d entries, %v", len(entries), err)
+	}
+}
+
+func TestCacheFS(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"a.json":     &fstest.MapFile{Data: []byte(`{"Hello": "a", "Number": 1}`)},
+		"dir/b.json": &fstest.MapFile{Data: []byte(`{"Hello": "b"}`)},
+	}
+	cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+	fsys := cache.FS()
+	content, err := fs.ReadFile(fsys, "a.json")
+	if err != nil || string(content) != `{"Hello":"a","Number":1,"Float":0}` {
+		t.Errorf("a.json not serialized as JSON: %q, %v", content, err)
+	}
+	entries, err := fs.ReadDir(fsys, "dir")
+	if err != nil || len(entries) != 1 || entries[0].Name() != "b.json" {
+		t.Errorf("dir not listed: %v, %v", entries, err)
+	}
+
+	raw := NewConcurrentFsCache(filesystem, func(r io.Reader) (string, error) {
+		content, err := io.ReadAll(r)
+		return string(content), err
+	}, time.Minute)
+	content, err = fs.ReadFile(raw.FS(), "dir/b.json")
+	if err != nil || string(content) != `{"Hello": "b"}` {
+		t.Errorf("string content not used as it is: %q, %v", content, err)
+	}
+}
@@ -0,0 +1,16 @@
+package parsecache
+
+// WithCaseInsensitiveKeys keys the cache's entries by the lower case of their cleaned paths, so
+// paths which differ only by case share an entry, but, unlike `CaseInsensitiveNormalizer`, the path
+// opened is still the cleaned path as it was given. An entry is loaded from the path of the first
+// get to load it successfully, and served to every casing of the path after that, until it's
+// cleared.
+//
+// It applies to every way of getting or clearing an entry, such as `GetFileEntry`, `SetFileEntry`,
+// `UntypedGet` and `SubCache.Clear`, and `Entries` returns the lower cased keys. The circuit breaker
+// and the filter of missing files still use the cleaned paths, since they're about the paths opened.
+func WithCaseInsensitiveKeys[T any]() Option[T] {
+	return func(o *options[T]) {
+		o.caseInsensitiveKeys = true
+	}
+}
@@ -0,0 +1,89 @@
+package parsecache
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestCaseInsensitiveKeys(t *testing.T) {
+	for _, concurrent := range []bool{false, true} {
+		filesystem := &countingFS{fs: fstest.MapFS{
+			"Pages/About.json": &fstest.MapFile{Data: []byte(`{"Hello": "about"}`)},
+		}}
+		var cache testInterface
+		var getEntry func(string) bool
+		opts := []Option[testFileStructure]{WithCaseInsensitiveKeys[testFileStructure]()}
+		if concurrent {
+			c := NewConcurrentFsCache[testFileStructure](filesystem, JsonParser[testFileStructure], time.Minute, opts...)
+			cache = c
+			getEntry = func(path string) bool {
+				_, ok := c.GetFileEntry(path)
+				return ok
+			}
+		} else {
+			c := NewFsCache[testFileStructure](filesystem, JsonParser[testFileStructure], time.Minute, opts...)
+			cache = &c
+			getEntry = func(path string) bool {
+				_, ok := c.GetFileEntry(path)
+				return ok
+			}
+		}
+
+		// The path opened keeps its case, so a casing which doesn't exist fails and isn't cached.
+		if _, err := cache.GetFile("pages/about.json"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("concurrent=%v: pages/about.json opened with its case changed: %v", concurrent, err)
+		}
+		if getEntry("Pages/About.json") {
+			t.Errorf("concurrent=%v: failed load cached", concurrent)
+		}
+
+		a, err := cache.GetFile("Pages/About.json")
+		if err != nil || a.Hello != "about" {
+			t.Errorf("concurrent=%v: Pages/About.json not parsed: %+v, %v", concurrent, a, err)
+		}
+		opens := filesystem.Opens()
+		for _, path := range []string{"pages/about.json", "/PAGES/ABOUT.JSON", "Pages/about.json"} {
+			a, err := cache.GetFile(path)
+			if err != nil || a.Hello != "about" {
+				t.Errorf("concurrent=%v: %s not served from the shared entry: %+v, %v", concurrent, path, a, err)
+			}
+			if !getEntry(path) {
+				t.Errorf("concurrent=%v: no entry for %s", concurrent, path)
+			}
+		}
+		if filesystem.Opens() != opens {
+			t.Errorf("concurrent=%v: other casings of the path were opened", concurrent)
+		}
+	}
+}
+
+func TestCaseInsensitiveKeysEntries(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"Pages/About.json": &fstest.MapFile{Data: []byte(`{"Hello": "about"}`)},
+	}
+	cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute, WithCaseInsensitiveKeys[testFileStructure]())
+	if _, err := cache.GetFile("Pages/About.json"); err != nil {
+		panic(err)
+	}
+	if _, err := cache.GetDir("Pages"); err != nil {
+		panic(err)
+	}
+	if _, ok := cache.GetDirEntry("PAGES"); !ok {
+		t.Error("no directory entry for PAGES")
+	}
+	if entry, ok := cache.UntypedGet("pages/ABOUT.json"); !ok || entry.Path != "/pages/ABOUT.json" {
+		t.Errorf("incorrect untyped entry: %+v, %v", entry, ok)
+	}
+	entries := cache.Entries()
+	if len(entries) != 2 || entries[0].Path != "/pages" || entries[1].Path != "/pages/about.json" {
+		t.Errorf("incorrect entries: %+v", entries)
+	}
+
+	cache.Sub("PAGES").Clear()
+	if stats := cache.Stats(); stats.Files != 0 || stats.Dirs != 0 {
+		t.Errorf("entries not cleared by a differently cased prefix: %+v", stats)
+	}
+}
@@ -0,0 +1,27 @@
+package parsecache
+
+// WithChangeDetector compares the content of a file which is parsed again, because its size or
+// modtime (or whatever else revalidates it) changed, with the cached content, using `changed`. If
+// `changed` returns false, the cached content is kept, rather than being replaced by the equal
+// content just parsed, so it's the same value as before, the file isn't counted as refreshed by
+// `GetFileOrRefresh`, and the parse is logged as a no-op. The cache entry is still revalidated, so
+// the file isn't parsed again until it changes again.
+//
+// This is useful for types with a well-defined `Equal` method, to avoid triggering downstream
+// updates when a file is touched or rewritten without its content changing.
+func WithChangeDetector[T any](changed func(old, new T) bool) Option[T] {
+	return func(o *options[T]) {
+		o.load.changeDetector = changed
+	}
+}
+
+// sameContent returns true if `config` has a change detector for `T`, which finds `content` to be
+// unchanged from the content of the entry.
+func (f *CachedFile[T]) sameContent(src source, content T, config loadConfig) bool {
+	changed, ok := config.changeDetector.(func(old, new T) bool)
+	if !ok {
+		return false
+	}
+	old, err := f.value(src)
+	return err == nil && !changed(old, content)
+}
@@ -0,0 +1,61 @@
+package parsecache
+
+import (
+	"io"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestChangeDetector(t *testing.T) {
+	for _, concurrent := range []bool{false, true} {
+		modTime := time.Now().Add(-time.Hour)
+		filesystem := fstest.MapFS{
+			"a.json": &fstest.MapFile{Data: []byte(`{"Hello": "a"}`), ModTime: modTime},
+		}
+		var parses int
+		parser := func(f io.Reader) (*testFileStructure, error) {
+			parses++
+			return JsonParser[*testFileStructure](f)
+		}
+		changed := func(old, new *testFileStructure) bool {
+			return *old != *new
+		}
+		opts := []Option[*testFileStructure]{WithChangeDetector(changed)}
+		var getFile func() (*testFileStructure, bool, error)
+		if concurrent {
+			c := NewConcurrentFsCache(filesystem, parser, 0, opts...)
+			getFile = func() (*testFileStructure, bool, error) { return c.GetFileOrRefresh("a.json") }
+		} else {
+			c := NewFsCache(filesystem, parser, 0, opts...)
+			getFile = func() (*testFileStructure, bool, error) { return c.GetFileOrRefresh("a.json") }
+		}
+
+		first, refreshed, err := getFile()
+		if err != nil || !refreshed || first.Hello != "a" {
+			t.Errorf("concurrent=%v: a.json not loaded: %+v, %v, %v", concurrent, first, refreshed, err)
+		}
+
+		// Rewriting the file with equal content keeps the cached value.
+		filesystem["a.json"] = &fstest.MapFile{Data: []byte(`{ "Hello": "a" }`), ModTime: modTime.Add(time.Minute)}
+		again, refreshed, err := getFile()
+		if err != nil || refreshed || again != first {
+			t.Errorf("concurrent=%v: equal content replaced the cached value: %+v, %v, %v", concurrent, again, refreshed, err)
+		}
+		if parses != 2 {
+			t.Errorf("concurrent=%v: expected 2 parses, got %d", concurrent, parses)
+		}
+
+		// The entry was revalidated, so it isn't parsed again.
+		if _, _, err = getFile(); err != nil || parses != 2 {
+			t.Errorf("concurrent=%v: unchanged file parsed again: %d parses, %v", concurrent, parses, err)
+		}
+
+		// Changed content replaces it.
+		filesystem["a.json"] = &fstest.MapFile{Data: []byte(`{"Hello": "b"}`), ModTime: modTime.Add(2 * time.Minute)}
+		changedContent, refreshed, err := getFile()
+		if err != nil || !refreshed || changedContent == first || changedContent.Hello != "b" {
+			t.Errorf("concurrent=%v: changed content not loaded: %+v, %v, %v", concurrent, changedContent, refreshed, err)
+		}
+	}
+}
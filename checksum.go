@@ -0,0 +1,43 @@
+package parsecache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/crc32"
+	"io"
+)
+
+// Sha256Parser is a `Parser` which computes the SHA-256 hash of a file's content. The file is
+// streamed into the hash, rather than read into memory, though a cache reads it into memory with
+// `ReadFile` where it can, unless it's created with `WithStreamingReads`.
+func Sha256Parser(f io.Reader) ([32]byte, error) {
+	var sum [32]byte
+	hash := sha256.New()
+	_, err := io.Copy(hash, f)
+	if err != nil {
+		return sum, err
+	}
+	hash.Sum(sum[:0])
+	return sum, nil
+}
+
+// Sha256HexParser is a `Parser` which computes the SHA-256 hash of a file's content, like
+// `Sha256Parser`, as a lower case hex string.
+func Sha256HexParser(f io.Reader) (string, error) {
+	sum, err := Sha256Parser(f)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Crc32Parser is a `Parser` which computes the CRC-32 checksum of a file's content, using the IEEE
+// polynomial. The file is streamed into the checksum, like `Sha256Parser`.
+func Crc32Parser(f io.Reader) (uint32, error) {
+	hash := crc32.NewIEEE()
+	_, err := io.Copy(hash, f)
+	if err != nil {
+		return 0, err
+	}
+	return hash.Sum32(), nil
+}
@@ -0,0 +1,65 @@
+package parsecache
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestChecksumParsers(t *testing.T) {
+	mapFS := fstest.MapFS{
+		"a.txt": &fstest.MapFile{Data: []byte("hello world")},
+	}
+	filesystem := &countingFS{fs: mapFS}
+	maxAge := time.Second / 20
+
+	sha := NewFsCache(filesystem, Sha256HexParser, maxAge)
+	for i := 0; i < 3; i++ {
+		sum, err := sha.GetFile("a.txt")
+		if err != nil {
+			panic(err)
+		}
+		if sum != "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9" {
+			t.Errorf("incorrect SHA-256 of a.txt: %s", sum)
+		}
+	}
+	if filesystem.Opens() != 1 {
+		t.Error("a.txt rehashed on a cache hit")
+	}
+
+	raw := NewFsCache(filesystem, Sha256Parser, time.Minute)
+	sum, err := raw.GetFile("a.txt")
+	if err != nil {
+		panic(err)
+	}
+	if sum[0] != 0xb9 || sum[31] != 0xe9 {
+		t.Errorf("incorrect SHA-256 of a.txt: %x", sum)
+	}
+
+	crc := NewConcurrentFsCache(filesystem, Crc32Parser, time.Minute)
+	checksum, err := crc.GetFile("a.txt")
+	if err != nil {
+		panic(err)
+	}
+	if checksum != 0x0d4a1185 {
+		t.Errorf("incorrect CRC-32 of a.txt: %08x", checksum)
+	}
+	_, err = crc.GetFile("a.txt")
+	if err != nil {
+		panic(err)
+	}
+	if filesystem.Opens() != 3 {
+		t.Errorf("a.txt opened %d times, expected 3", filesystem.Opens())
+	}
+
+	// A changed file is rehashed once the entry expires.
+	time.Sleep(maxAge)
+	mapFS["a.txt"] = &fstest.MapFile{Data: []byte("hello, world"), ModTime: time.Now()}
+	changed, err := sha.GetFile("a.txt")
+	if err != nil {
+		panic(err)
+	}
+	if changed != "09ca7e4eaa6e8ae9c7d261167129184883644d07dfba7cbfbc4c8a2e08360d5b" {
+		t.Errorf("changed a.txt not rehashed: %s", changed)
+	}
+}
@@ -0,0 +1,32 @@
+package parsecache
+
+// Clone returns a new cache with the same filesystem, parser, maximum age and options as `cache`,
+// but without any of its entries, circuit breaker state or statistics.
+func (cache *FsCache[T]) Clone() *FsCache[T] {
+	clone := FsCache[T]{
+		fs:      cache.fs,
+		parser:  cache.parser,
+		MaxAge:  cache.MaxAge,
+		options: cache.options,
+	}
+	clone.breakers = newCircuitBreakers(clone.options.circuitBreaker)
+	clone.notExist = newNotExistFilter(clone.options.notExistFilter)
+	clone.Clear()
+	return &clone
+}
+
+// Clone returns a new cache with the same filesystem, parser, maximum age and options as `cache`,
+// but without any of its entries, circuit breaker state or statistics. Signal listeners aren't
+// copied to the new cache.
+func (cache *ConcurrentFsCache[T]) Clone() *ConcurrentFsCache[T] {
+	settings := *cache.loadSettings()
+	clone := ConcurrentFsCache[T]{
+		options: cache.options,
+	}
+	clone.settings.Store(&settings)
+	clone.shards = newShards[T](clone.options.shards)
+	clone.breakers = newCircuitBreakers(clone.options.circuitBreaker)
+	clone.notExist = newNotExistFilter(clone.options.notExistFilter)
+	clone.Clear()
+	return &clone
+}
@@ -0,0 +1,45 @@
+package parsecache
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestClone(t *testing.T) {
+	filesystem := &countingFS{fs: fstest.MapFS{
+		"a.json": &fstest.MapFile{Data: []byte(`{"Number": 1}`)},
+		"b.txt":  &fstest.MapFile{Data: []byte("b")},
+	}}
+	opts := []Option[testFileStructure]{
+		WithUnknownExtensionError[testFileStructure](),
+		WithParserFor(".json", JsonParser[testFileStructure]),
+	}
+	for _, concurrent := range []bool{false, true} {
+		var cache, clone testInterface
+		if concurrent {
+			c := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute, opts...)
+			cache, clone = c, c.Clone()
+		} else {
+			c := NewFsCache(filesystem, JsonParser[testFileStructure], time.Minute, opts...)
+			cache, clone = &c, c.Clone()
+		}
+		opens := filesystem.Opens()
+		if _, err := cache.GetFile("a.json"); err != nil {
+			panic(err)
+		}
+
+		// The clone has none of the entries, but the same configuration.
+		a, err := clone.GetFile("a.json")
+		if err != nil || a.Number != 1 {
+			t.Errorf("concurrent=%v: clone didn't parse a.json: %+v, %v", concurrent, a, err)
+		}
+		if _, err := clone.GetFile("b.txt"); err == nil {
+			t.Errorf("concurrent=%v: clone didn't keep the options", concurrent)
+		}
+		opens = filesystem.Opens()
+		if _, err := clone.GetFile("a.json"); err != nil || filesystem.Opens() != opens {
+			t.Errorf("concurrent=%v: clone didn't cache a.json", concurrent)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package parsecache
+
+import (
+	"io"
+	"sync"
+)
+
+var (
+	_ io.Closer = (*FsCache[any])(nil)
+	_ io.Closer = (*ConcurrentFsCache[any])(nil)
+)
+
+// Close does nothing, since an `FsCache` doesn't run anything in the background. It's provided so
+// that either type of cache can be closed.
+func (cache *FsCache[T]) Close() error {
+	return nil
+}
+
+// Close stops everything the cache runs in the background, such as the listeners started by
+// `ListenForInvalidationSignal`, waits for them to finish, and closes the channel returned by
+// `Events`. The cache can still be used afterwards.
+func (cache *ConcurrentFsCache[T]) Close() error {
+	cache.background.close()
+	cache.events.close()
+	return nil
+}
+
+// backgroundTasks tracks the goroutines a cache runs in the background.
+type backgroundTasks struct {
+	lock sync.Mutex
+	// stops are the functions which stop each of the running tasks.
+	stops []func()
+	wg    sync.WaitGroup
+}
+
+// start runs `task` in a new goroutine, and returns a function which stops it, by calling `onStop`
+// and closing the channel passed to `task`, which must then return. The returned function may be
+// called more than once.
+func (b *backgroundTasks) start(task func(done <-chan struct{}), onStop func()) func() {
+	done := make(chan struct{})
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			onStop()
+			close(done)
+		})
+	}
+	b.lock.Lock()
+	b.stops = append(b.stops, stop)
+	b.lock.Unlock()
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		task(done)
+	}()
+	return stop
+}
+
+// close stops every task, and waits for them to return.
+func (b *backgroundTasks) close() {
+	b.lock.Lock()
+	stops := b.stops
+	b.stops = nil
+	b.lock.Unlock()
+	for _, stop := range stops {
+		stop()
+	}
+	b.wg.Wait()
+}
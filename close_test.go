@@ -0,0 +1,32 @@
+package parsecache
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestClose(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"a.json": &fstest.MapFile{Data: []byte(`{"Hello": "world"}`)},
+	}
+	cache := NewFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+	if err := cache.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	concurrent := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+	if err := concurrent.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+
+	// The caches can still be used, and closed again.
+	for _, cache := range []testInterface{&cache, concurrent} {
+		a, err := cache.GetFile("a.json")
+		if err != nil || a.Hello != "world" {
+			t.Errorf("a.json not loaded after Close: %v", err)
+		}
+	}
+	if err := concurrent.Close(); err != nil {
+		t.Errorf("second Close failed: %v", err)
+	}
+}
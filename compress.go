@@ -0,0 +1,42 @@
+package parsecache
+
+import (
+	"bytes"
+	"compress/gzip"
+)
+
+// WithCompression keeps the parsed content of files compressed in memory, trading CPU for memory.
+// After a file is parsed, its content is encoded with encoding/gob and gzip-compressed, and it's
+// decompressed and decoded again every time it's read from the cache.
+//
+// `T` must be encodable with gob, typically by having exported fields or implementing
+// `gob.GobEncoder` and `gob.GobDecoder`. If the content can't be encoded, the load fails with the
+// error, and the previously cached content is returned alongside it.
+func WithCompression[T any]() Option[T] {
+	return func(o *options[T]) {
+		o.load.compress = true
+	}
+}
+
+// compress returns the gzip-compressed gob encoding of `content`.
+func compress[T any](content T) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if err := EncodeGob(w, content); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompress decodes content encoded by `compress`.
+func decompress[T any](compressed []byte) (T, error) {
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return GobParser[T](r)
+}
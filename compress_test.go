@@ -0,0 +1,73 @@
+package parsecache
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestWithCompression(t *testing.T) {
+	maxAge := time.Second / 20
+	for _, concurrent := range []bool{false, true} {
+		mapFS := fstest.MapFS{
+			"a.json": &fstest.MapFile{Data: []byte(`{"Hello": "world!", "Number": 12}`)},
+		}
+		filesystem := &countingFS{fs: mapFS}
+		var cache testInterface
+		var entry func() *CachedFile[testFileStructure]
+		if concurrent {
+			c := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], maxAge, WithCompression[testFileStructure]())
+			cache = c
+			entry = func() *CachedFile[testFileStructure] {
+				e, _ := c.GetFileEntry("a.json")
+				return &e.cachedFile
+			}
+		} else {
+			c := NewFsCache(filesystem, JsonParser[testFileStructure], maxAge, WithCompression[testFileStructure]())
+			cache = &c
+			entry = func() *CachedFile[testFileStructure] {
+				e, _ := c.GetFileEntry("a.json")
+				return e
+			}
+		}
+
+		for i := 0; i < 2; i++ {
+			a, err := cache.GetFile("a.json")
+			if err != nil {
+				panic(err)
+			}
+			if a.Hello != "world!" || a.Number != 12 {
+				t.Errorf("a.json not parsed correctly %d", i)
+			}
+		}
+		if filesystem.Opens() != 1 {
+			t.Error("a.json not cached")
+		}
+		if e := entry(); e.compressed == nil || e.content.Hello != "" {
+			t.Error("a.json not stored compressed")
+		}
+		if a, _, _ := entry().Cached(); a.Hello != "world!" {
+			t.Error("Cached doesn't decompress the content")
+		}
+
+		// A failed load still returns the decompressed content.
+		mapFS["a.json"] = &fstest.MapFile{Data: []byte(`{"Hello": nope}`), ModTime: time.Now()}
+		time.Sleep(maxAge)
+		a, err := cache.GetFile("a.json")
+		if !isParseError(err) || a.Hello != "world!" {
+			t.Errorf("failed load didn't return the previous content: %v", err)
+		}
+	}
+}
+
+func TestWithCompressionUnencodable(t *testing.T) {
+	type unexported struct{ hello string }
+	mapFS := fstest.MapFS{
+		"a.json": &fstest.MapFile{Data: []byte(`{}`)},
+	}
+	cache := NewFsCache(mapFS, JsonParser[unexported], time.Minute, WithCompression[unexported]())
+	_, err := cache.GetFile("a.json")
+	if err == nil {
+		t.Error("unencodable content didn't fail")
+	}
+}
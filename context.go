@@ -0,0 +1,26 @@
+package parsecache
+
+import (
+	"context"
+	"io/fs"
+)
+
+// GetFileCtx returns the parsed content of a file, which may be cached, giving up on loading it
+// once `ctx` is done, in which case it fails with `ctx.Err()`. `ctx` is also passed to the parser.
+//
+// As with any other failed load, the content which was last loaded successfully (or the zero value,
+// if there isn't any) is returned alongside the error. A fresh cache entry is returned without
+// checking `ctx`. An abandoned load carries on in its own goroutine until the file is closed, but
+// its result is discarded. Waiting for another caller's load of the same file isn't limited by
+// `ctx`, use `WithLoadTimeout` to limit how long those loads take.
+func (cache *ConcurrentFsCache[T]) GetFileCtx(ctx context.Context, file string) (T, error) {
+	return cache.getFile(ctx, file, 0, false, nil, nil)
+}
+
+// GetDirCtx gets the entries of a directory, which may be cached, giving up on loading them once
+// `ctx` is done, in which case it fails with `ctx.Err()`, like `GetFileCtx`. The entries are copied
+// into a new slice, which the caller may modify.
+func (cache *ConcurrentFsCache[T]) GetDirCtx(ctx context.Context, dir string) ([]fs.DirEntry, error) {
+	entries, err := cache.getDir(ctx, dir, 0, false, nil)
+	return copyEntries(entries), err
+}
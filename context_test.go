@@ -0,0 +1,170 @@
+package parsecache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gatedFS wraps a filesystem, blocking calls to Open while `gate` is locked.
+type gatedFS struct {
+	fs   fs.FS
+	gate sync.RWMutex
+}
+
+func (g *gatedFS) Open(name string) (fs.File, error) {
+	g.gate.RLock()
+	defer g.gate.RUnlock()
+	return g.fs.Open(name)
+}
+
+func TestGetFileCtx(t *testing.T) {
+	maxAge := time.Second / 10
+	dir, err := os.MkdirTemp("", "parsecache-test-ctx-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"Hello": "world!"}`), 0660)
+	filesystem := &gatedFS{fs: os.DirFS(dir)}
+	cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], maxAge)
+
+	a, err := cache.GetFileCtx(context.Background(), "a.json")
+	if err != nil {
+		panic(err)
+	}
+	if a.Hello != "world!" {
+		t.Error("a.json not loaded correctly")
+	}
+
+	// A fresh entry is returned even if the context is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	a, err = cache.GetFileCtx(ctx, "a.json")
+	if err != nil || a.Hello != "world!" {
+		t.Errorf("fresh a.json not returned with a done context: %+v, %v", a, err)
+	}
+
+	// Block the filesystem, so the next load can't complete before the deadline.
+	os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"Hello": "changed"}`), 0660)
+	time.Sleep(maxAge + time.Second/10)
+	filesystem.gate.Lock()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second/10)
+	a, err = cache.GetFileCtx(ctx, "a.json")
+	cancel()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("GetFileCtx doesn't return the context error")
+	}
+	if a.Hello != "world!" {
+		t.Error("GetFileCtx doesn't return the stale value")
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second/10)
+	b, err := cache.GetFileCtx(ctx, "b.json")
+	cancel()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("GetFileCtx doesn't return the context error without a cached value")
+	}
+	if b.Hello != "" {
+		t.Error("GetFileCtx returns a stale value without a cached value")
+	}
+
+	// The context is already done, so no load should be attempted.
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	a, err = cache.GetFileCtx(ctx, "a.json")
+	if !errors.Is(err, context.Canceled) || a.Hello != "world!" {
+		t.Error("GetFileCtx doesn't handle an already cancelled context")
+	}
+
+	// Once the filesystem is unblocked, the file is loaded again.
+	filesystem.gate.Unlock()
+	a, err = cache.GetFileCtx(context.Background(), "a.json")
+	if err != nil || a.Hello != "changed" {
+		t.Errorf("a.json not loaded again: %+v, %v", a, err)
+	}
+}
+
+func TestGetDirCtx(t *testing.T) {
+	maxAge := time.Second / 10
+	dir, err := os.MkdirTemp("", "parsecache-test-dir-ctx-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{}`), 0660)
+	filesystem := &gatedFS{fs: os.DirFS(dir)}
+	cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], maxAge)
+
+	entries, err := cache.GetDirCtx(context.Background(), "/")
+	if err != nil {
+		panic(err)
+	}
+	if len(entries) != 1 {
+		t.Error("/ not loaded correctly")
+	}
+
+	os.WriteFile(filepath.Join(dir, "b.json"), []byte(`{}`), 0660)
+	time.Sleep(maxAge + time.Second/10)
+	filesystem.gate.Lock()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second/10)
+	entries, err = cache.GetDirCtx(ctx, "/")
+	cancel()
+	if !errors.Is(err, context.DeadlineExceeded) || len(entries) != 1 {
+		t.Error("GetDirCtx doesn't return the stale entries")
+	}
+	filesystem.gate.Unlock()
+
+	entries, err = cache.GetDirCtx(context.Background(), "/")
+	if err != nil {
+		panic(err)
+	}
+	if len(entries) != 2 {
+		t.Error("directory not loaded again")
+	}
+}
+
+func TestParserCtx(t *testing.T) {
+	dir, err := os.MkdirTemp("", "parsecache-test-parser-ctx-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{}`), 0660)
+
+	// This parser only completes if its context isn't done.
+	parser := func(ctx context.Context, f io.Reader) (string, error) {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(time.Second / 5):
+			return "parsed", nil
+		}
+	}
+	cache := NewConcurrentFsCacheCtx(os.DirFS(dir), parser, time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second/20)
+	_, err = cache.GetFileCtx(ctx, "a.json")
+	cancel()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("GetFileCtx doesn't return the context error")
+	}
+	time.Sleep(time.Second / 20)
+	if _, ok := cache.GetFileEntry("a.json"); ok {
+		t.Error("cancelled parse populated the cache")
+	}
+
+	a, err := cache.GetFile("a.json")
+	if err != nil {
+		panic(err)
+	}
+	if a != "parsed" {
+		t.Error("a.json not parsed correctly")
+	}
+}
@@ -0,0 +1,86 @@
+// Package debug provides an `http.Handler` which describes the content of a parsecache cache, for
+// use as an internal diagnostics endpoint.
+package debug
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/JOT85/parsecache"
+)
+
+// DebugCache is implemented by `*parsecache.FsCache` and `*parsecache.ConcurrentFsCache`, for any
+// type of content.
+type DebugCache interface {
+	Entries() []parsecache.EntryInfo
+}
+
+// statsCache is implemented by caches which can report their `parsecache.Stats`.
+type statsCache interface {
+	Stats() parsecache.Stats
+}
+
+// response is the JSON served by the handler.
+type response struct {
+	Stats   *parsecache.Stats `json:"stats,omitempty"`
+	Entries []entry           `json:"entries"`
+}
+
+// entry is the JSON description of a cached file or directory.
+type entry struct {
+	Path       string    `json:"path"`
+	Dir        bool      `json:"dir"`
+	Age        string    `json:"age"`
+	AgeSeconds float64   `json:"ageSeconds"`
+	CachedAt   time.Time `json:"cachedAt"`
+	Size       int64     `json:"size"`
+	ModTime    time.Time `json:"modTime"`
+	Hits       uint64    `json:"hits"`
+}
+
+// NewDebugHandler returns an `http.Handler` which responds with a JSON description of every file
+// and directory in `cache`: its path, age, size, modtime and the number of times it was returned
+// from memory. If the cache has a `Stats` method, such as `*parsecache.ConcurrentFsCache`, the
+// stats are also included.
+//
+// The response describes the paths in the cache, so the handler should only be served internally,
+// for example at "/_cache/debug". An `*parsecache.FsCache` isn't safe for concurrent use, so it
+// must not be used by anything else while the handler is serving requests.
+func NewDebugHandler(cache DebugCache) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var resp response
+		if stats, ok := cache.(statsCache); ok {
+			s := stats.Stats()
+			resp.Stats = &s
+		}
+		now := time.Now()
+		infos := cache.Entries()
+		resp.Entries = make([]entry, len(infos))
+		for i, info := range infos {
+			age := now.Sub(info.CachedAt)
+			resp.Entries[i] = entry{
+				Path:       info.Path,
+				Dir:        info.Dir,
+				Age:        age.String(),
+				AgeSeconds: age.Seconds(),
+				CachedAt:   info.CachedAt,
+				Size:       info.Size,
+				ModTime:    info.ModTime,
+				Hits:       info.Hits,
+			}
+		}
+
+		// The response is encoded before any of it is written, so an error can still be reported.
+		var body bytes.Buffer
+		err := json.NewEncoder(&body).Encode(resp)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write(body.Bytes())
+	})
+}
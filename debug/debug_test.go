@@ -0,0 +1,88 @@
+package debug
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/JOT85/parsecache"
+)
+
+type testFileStructure struct {
+	Hello string
+}
+
+func TestDebugHandler(t *testing.T) {
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	filesystem := fstest.MapFS{
+		"a.json":     &fstest.MapFile{Data: []byte(`{"Hello": "a"}`), ModTime: modTime},
+		"dir/b.json": &fstest.MapFile{Data: []byte(`{"Hello": "b"}`), ModTime: modTime},
+	}
+	cache := parsecache.NewConcurrentFsCache(filesystem, parsecache.JsonParser[testFileStructure], time.Minute)
+	for _, file := range []string{"dir/b.json", "a.json"} {
+		_, err := cache.GetFile(file)
+		if err != nil {
+			panic(err)
+		}
+	}
+	_, err := cache.GetDir("dir")
+	if err != nil {
+		panic(err)
+	}
+	// a.json is returned from memory twice.
+	for i := 0; i < 2; i++ {
+		_, err = cache.GetFile("a.json")
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	NewDebugHandler(cache).ServeHTTP(recorder, httptest.NewRequest("GET", "/_cache/debug", nil))
+	if recorder.Header().Get("Content-Type") != "application/json" {
+		t.Error("incorrect Content-Type")
+	}
+	var resp response
+	err = json.Unmarshal(recorder.Body.Bytes(), &resp)
+	if err != nil {
+		t.Fatalf("invalid JSON response: %v", err)
+	}
+	if resp.Stats == nil || resp.Stats.Files != 2 || resp.Stats.Dirs != 1 {
+		t.Errorf("incorrect stats: %+v", resp.Stats)
+	}
+	if len(resp.Entries) != 3 {
+		t.Fatalf("incorrect entries: %+v", resp.Entries)
+	}
+	for i, expected := range []struct {
+		path string
+		dir  bool
+	}{{"/a.json", false}, {"/dir", true}, {"/dir/b.json", false}} {
+		e := resp.Entries[i]
+		if e.Path != expected.path || e.Dir != expected.dir {
+			t.Errorf("entry %d is %s, expected %s", i, e.Path, expected.path)
+		}
+		if e.AgeSeconds < 0 || e.AgeSeconds > 10 || e.Age == "" {
+			t.Errorf("%s has an incorrect age: %s", e.Path, e.Age)
+		}
+	}
+	if a := resp.Entries[0]; a.Size != 14 || !a.ModTime.Equal(modTime) {
+		t.Errorf("/a.json has an incorrect size or modtime: %+v", a)
+	}
+	for i, hits := range []uint64{2, 0, 0} {
+		if e := resp.Entries[i]; e.Hits != hits {
+			t.Errorf("%s has %d hits, expected %d", e.Path, e.Hits, hits)
+		}
+	}
+
+	// An FsCache works too.
+	resp = response{}
+	fsCache := parsecache.NewFsCache(filesystem, parsecache.JsonParser[testFileStructure], time.Minute)
+	recorder = httptest.NewRecorder()
+	NewDebugHandler(&fsCache).ServeHTTP(recorder, httptest.NewRequest("GET", "/_cache/debug", nil))
+	err = json.Unmarshal(recorder.Body.Bytes(), &resp)
+	if err != nil || len(resp.Entries) != 0 {
+		t.Errorf("incorrect response for an empty cache: %s", recorder.Body.String())
+	}
+}
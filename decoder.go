@@ -0,0 +1,34 @@
+package parsecache
+
+import "io"
+
+// Decoder is a decoder with the standard `Decode` method, such as `*json.Decoder`, `*gob.Decoder`
+// or `*xml.Decoder`.
+type Decoder interface {
+	Decode(v any) error
+}
+
+// DecoderParser returns a `Parser` which decodes a single value from a file with the `Decoder`
+// returned by `newDecoder`, such as:
+//
+//	DecoderParser[T](func(r io.Reader) Decoder { return gob.NewDecoder(r) })
+func DecoderParser[T any](newDecoder func(io.Reader) Decoder) Parser[T] {
+	return func(f io.Reader) (T, error) {
+		var parsed T
+		err := newDecoder(f).Decode(&parsed)
+		return parsed, err
+	}
+}
+
+// FromBytesParser returns a `Parser` which reads the entire content of a file, like `BytesParser`,
+// and parses it with `parse`, for formats whose parsers take a byte slice.
+func FromBytesParser[T any](parse func([]byte) (T, error)) Parser[T] {
+	return func(f io.Reader) (T, error) {
+		content, err := BytesParser(f)
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+		return parse(content)
+	}
+}
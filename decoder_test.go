@@ -0,0 +1,64 @@
+package parsecache
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestDecoderParser(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"a.json":   &fstest.MapFile{Data: []byte(`{"Hello": "world"}`)},
+		"bad.json": &fstest.MapFile{Data: []byte(`{"Hello": nope}`)},
+	}
+	cache := NewFsCache(filesystem, DecoderParser[testFileStructure](func(r io.Reader) Decoder {
+		return json.NewDecoder(r)
+	}), time.Minute)
+
+	a, err := cache.GetFile("a.json")
+	if err != nil {
+		panic(err)
+	}
+	if a.Hello != "world" {
+		t.Error("a.json not decoded correctly")
+	}
+
+	_, err = cache.GetFile("bad.json")
+	var pathErr *fs.PathError
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &pathErr) || pathErr.Path != "/bad.json" || !errors.As(err, &syntaxErr) {
+		t.Errorf("bad.json didn't fail with a wrapped SyntaxError: %v", err)
+	}
+}
+
+func TestFromBytesParser(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"a.xml":   &fstest.MapFile{Data: []byte(`<testFileStructure><Hello>world</Hello></testFileStructure>`)},
+		"bad.xml": &fstest.MapFile{Data: []byte(`<testFileStructure>`)},
+	}
+	cache := NewConcurrentFsCache(filesystem, FromBytesParser(func(content []byte) (testFileStructure, error) {
+		var parsed testFileStructure
+		err := xml.Unmarshal(content, &parsed)
+		return parsed, err
+	}), time.Minute)
+
+	a, err := cache.GetFile("a.xml")
+	if err != nil {
+		panic(err)
+	}
+	if a.Hello != "world" {
+		t.Error("a.xml not parsed correctly")
+	}
+
+	_, err = cache.GetFile("bad.xml")
+	var pathErr *fs.PathError
+	var syntaxErr *xml.SyntaxError
+	if !errors.As(err, &pathErr) || pathErr.Path != "/bad.xml" || !errors.As(err, &syntaxErr) {
+		t.Errorf("bad.xml didn't fail with a wrapped SyntaxError: %v", err)
+	}
+}
@@ -0,0 +1,326 @@
+package parsecache
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"sort"
+	"time"
+)
+
+// entriesMap returns a map of the names of `entries` to the entries.
+func entriesMap(entries []fs.DirEntry) map[string]fs.DirEntry {
+	m := make(map[string]fs.DirEntry, len(entries))
+	for _, entry := range entries {
+		m[entry.Name()] = entry
+	}
+	return m
+}
+
+// GetDirEntriesMap gets the entries of a directory, which may be cached, as a map of the names of
+// the entries to the entries. As with `GetDir`, if there's an error, the map holds the last
+// successfully loaded entries, if any.
+func (cache *FsCache[T]) GetDirEntriesMap(dir string) (map[string]fs.DirEntry, error) {
+	entries, err := cache.GetDirShared(dir)
+	return entriesMap(entries), err
+}
+
+// GetDirEntriesMap gets the entries of a directory, which may be cached, as a map of the names of
+// the entries to the entries. As with `GetDir`, if there's an error, the map holds the last
+// successfully loaded entries, if any.
+func (cache *ConcurrentFsCache[T]) GetDirEntriesMap(dir string) (map[string]fs.DirEntry, error) {
+	entries, err := cache.GetDirShared(dir)
+	return entriesMap(entries), err
+}
+
+// GetDirCount returns the number of entries of a directory, which may be cached, such as to check
+// whether it's empty. The entries are read with `GetDirShared`, so they aren't copied. As with
+// `GetDir`, if there's an error, the count is of the last successfully loaded entries, if any.
+func (cache *FsCache[T]) GetDirCount(dir string) (int, error) {
+	entries, err := cache.GetDirShared(dir)
+	return len(entries), err
+}
+
+// GetDirCount returns the number of entries of a directory, which may be cached, such as to check
+// whether it's empty. The entries are read with `GetDirShared`, so they aren't copied. As with
+// `GetDir`, if there's an error, the count is of the last successfully loaded entries, if any.
+func (cache *ConcurrentFsCache[T]) GetDirCount(dir string) (int, error) {
+	entries, err := cache.GetDirShared(dir)
+	return len(entries), err
+}
+
+// filterEntries returns a new slice of the entries for which `keep` returns true.
+func filterEntries(entries []fs.DirEntry, keep func(fs.DirEntry) bool) []fs.DirEntry {
+	var kept []fs.DirEntry
+	for _, entry := range entries {
+		if keep(entry) {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// GetDirFiltered gets the entries of a directory, which may be cached, for which `keep` returns
+// true, in the order returned by `GetDir`, such as only the ".json" files. The entries are copied
+// into a new slice, so the cached entries aren't modified. As with `GetDir`, if there's an error,
+// the entries are filtered from the last successfully loaded entries, if any.
+func (cache *FsCache[T]) GetDirFiltered(dir string, keep func(fs.DirEntry) bool) ([]fs.DirEntry, error) {
+	entries, err := cache.GetDirShared(dir)
+	return filterEntries(entries, keep), err
+}
+
+// GetDirFiltered gets the entries of a directory, which may be cached, for which `keep` returns
+// true, in the order returned by `GetDir`, such as only the ".json" files. The entries are copied
+// into a new slice, so the cached entries aren't modified. As with `GetDir`, if there's an error,
+// the entries are filtered from the last successfully loaded entries, if any.
+func (cache *ConcurrentFsCache[T]) GetDirFiltered(dir string, keep func(fs.DirEntry) bool) ([]fs.DirEntry, error) {
+	entries, err := cache.GetDirShared(dir)
+	return filterEntries(entries, keep), err
+}
+
+// sortedNames returns the sorted names of the entries, which are cached until the entries change.
+// The returned slice mustn't be modified.
+func (f *CachedDir) sortedNames() []string {
+	if f.names == nil {
+		f.names = entryNamesSorted(f.entries)
+	}
+	return f.names
+}
+
+// entryNamesSorted returns the sorted names of `entries`.
+func entryNamesSorted(entries []fs.DirEntry) []string {
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetDirNames gets the sorted names of the entries of a directory, which may be cached. The names
+// are cached along with the directory, until its entries change, and each call returns a new copy
+// of them, which the caller may modify.
+func (cache *FsCache[T]) GetDirNames(dir string) ([]string, error) {
+	return cache.GetDirNamesWithMaxAge(dir, cache.MaxAge)
+}
+
+// GetDirNamesWithMaxAge is `GetDirNames`, with the specified maximum age.
+func (cache *FsCache[T]) GetDirNamesWithMaxAge(dir string, maxAge time.Duration) ([]string, error) {
+	_, err := cache.getDir(dir, maxAge, nil)
+	if err != nil {
+		return nil, err
+	}
+	names := cache.dirs[cache.options.key(cache.normalize(dir))].sortedNames()
+	return append([]string(nil), names...), nil
+}
+
+// GetDirNames gets the sorted names of the entries of a directory, which may be cached. The names
+// are cached along with the directory, until its entries change, and each call returns a new copy
+// of them, which the caller may modify.
+func (cache *ConcurrentFsCache[T]) GetDirNames(dir string) ([]string, error) {
+	return cache.getDirNames(dir, 0, false)
+}
+
+// GetDirNamesWithMaxAge is `GetDirNames`, with the specified maximum age.
+func (cache *ConcurrentFsCache[T]) GetDirNamesWithMaxAge(dir string, maxAge time.Duration) ([]string, error) {
+	return cache.getDirNames(dir, maxAge, true)
+}
+
+// getDirNames is `GetDirNames`, with a maximum age like `getDir`.
+func (cache *ConcurrentFsCache[T]) getDirNames(dir string, maxAge time.Duration, useMaxAge bool) ([]string, error) {
+	entries, err := cache.getDir(context.Background(), dir, maxAge, useMaxAge, nil)
+	if err != nil {
+		return nil, err
+	}
+	cached, ok := cache.GetDirEntry(dir)
+	if !ok {
+		// The cache was cleared after the directory was loaded.
+		return entryNamesSorted(entries), nil
+	}
+	cached.lock.Lock()
+	defer cached.lock.Unlock()
+	return append([]string(nil), cached.cachedDir.sortedNames()...), nil
+}
+
+// DirEntryInfo is an entry of a directory, along with its `fs.FileInfo`, or the error from reading
+// it, returned by `GetDirInfos`.
+type DirEntryInfo struct {
+	Entry fs.DirEntry
+	// Info is the info of the entry, or nil if reading it failed with `Err`.
+	Info fs.FileInfo
+	Err  error
+}
+
+// WithDirInfos reads the `fs.FileInfo` of each entry of a directory when it's loaded or
+// revalidated, rather than when it's first needed by `GetDirInfos` or `GetDirWithStats`.
+func WithDirInfos[T any]() Option[T] {
+	return func(o *options[T]) {
+		o.load.dirInfos = true
+	}
+}
+
+// statEntries returns the info of each of `entries`.
+func statEntries(entries []fs.DirEntry) []DirEntryInfo {
+	infos := make([]DirEntryInfo, len(entries))
+	for i, entry := range entries {
+		info, err := entry.Info()
+		infos[i] = DirEntryInfo{Entry: entry, Info: info, Err: err}
+	}
+	return infos
+}
+
+// dirEntryInfos returns the info of each of the entries, which are cached until the entry is next
+// loaded or revalidated.
+func (f *CachedDir) dirEntryInfos() []DirEntryInfo {
+	if f.entryInfos != nil && f.infosLoadTime.Equal(f.lastLoadTime) {
+		return f.entryInfos
+	}
+	f.entryInfos = statEntries(f.entries)
+	f.infos = nil
+	f.infosLoadTime = f.lastLoadTime
+	return f.entryInfos
+}
+
+// fileInfos returns the `fs.FileInfo` of each of the entries, which are cached until the entry is
+// next loaded or revalidated. Entries which no longer exist are skipped.
+func (f *CachedDir) fileInfos() ([]fs.FileInfo, error) {
+	if f.infos != nil && f.infosLoadTime.Equal(f.lastLoadTime) {
+		return f.infos, nil
+	}
+	infos, err := fileInfos(f.dirEntryInfos())
+	if err != nil {
+		return nil, err
+	}
+	f.infos = infos
+	return infos, nil
+}
+
+// fileInfos returns the `fs.FileInfo` of each of `infos`, skipping entries which no longer exist,
+// or the first other error from reading them.
+func fileInfos(infos []DirEntryInfo) ([]fs.FileInfo, error) {
+	fileInfos := make([]fs.FileInfo, 0, len(infos))
+	for _, info := range infos {
+		if errors.Is(info.Err, fs.ErrNotExist) {
+			continue
+		}
+		if info.Err != nil {
+			return nil, info.Err
+		}
+		fileInfos = append(fileInfos, info.Info)
+	}
+	return fileInfos, nil
+}
+
+// GetDirInfos gets the entries of a directory, which may be cached, along with the `fs.FileInfo` of
+// each of them, or the error from reading it, so one entry which fails to be stat-ed, such as
+// because it was removed, doesn't fail the whole directory.
+//
+// The infos are cached along with the directory, and read again each time the directory is loaded
+// or revalidated, so the files of a directory are stat-ed once per load, however many times they're
+// got. With `WithDirInfos`, they're read as the directory is loaded. The returned slice is a copy,
+// which the caller may modify.
+func (cache *FsCache[T]) GetDirInfos(dir string) ([]DirEntryInfo, error) {
+	_, err := cache.GetDirShared(dir)
+	if err != nil {
+		return nil, err
+	}
+	infos := cache.dirs[cache.options.key(cache.normalize(dir))].dirEntryInfos()
+	return append([]DirEntryInfo(nil), infos...), nil
+}
+
+// GetDirInfos gets the entries of a directory, which may be cached, along with the `fs.FileInfo` of
+// each of them, or the error from reading it, so one entry which fails to be stat-ed, such as
+// because it was removed, doesn't fail the whole directory.
+//
+// The infos are cached along with the directory, and read again each time the directory is loaded
+// or revalidated, so the files of a directory are stat-ed once per load, however many times they're
+// got. With `WithDirInfos`, they're read as the directory is loaded. The returned slice is a copy,
+// which the caller may modify.
+func (cache *ConcurrentFsCache[T]) GetDirInfos(dir string) ([]DirEntryInfo, error) {
+	entries, err := cache.GetDirShared(dir)
+	if err != nil {
+		return nil, err
+	}
+	cached, ok := cache.GetDirEntry(dir)
+	if !ok {
+		// The cache was cleared after the directory was loaded.
+		return statEntries(entries), nil
+	}
+	cached.lock.Lock()
+	defer cached.lock.Unlock()
+	return append([]DirEntryInfo(nil), cached.cachedDir.dirEntryInfos()...), nil
+}
+
+// GetDirWithStats gets the `fs.FileInfo` of each of the entries of a directory, which may be cached.
+//
+// The infos are cached along with the directory, and read again each time the directory is loaded
+// or revalidated, so changes to the files in the directory are seen within the directory's maximum
+// age. Entries which are removed before they can be stat-ed are skipped.
+func (cache *FsCache[T]) GetDirWithStats(dir string) ([]fs.FileInfo, error) {
+	_, err := cache.GetDirShared(dir)
+	if err != nil {
+		return nil, err
+	}
+	return cache.dirs[cache.options.key(cache.normalize(dir))].fileInfos()
+}
+
+// GetDirWithStats gets the `fs.FileInfo` of each of the entries of a directory, which may be cached.
+//
+// The infos are cached along with the directory, and read again each time the directory is loaded
+// or revalidated, so changes to the files in the directory are seen within the directory's maximum
+// age. Entries which are removed before they can be stat-ed are skipped.
+func (cache *ConcurrentFsCache[T]) GetDirWithStats(dir string) ([]fs.FileInfo, error) {
+	entries, err := cache.GetDirShared(dir)
+	if err != nil {
+		return nil, err
+	}
+	cached, ok := cache.GetDirEntry(dir)
+	if !ok {
+		// The cache was cleared after the directory was loaded.
+		return fileInfos(statEntries(entries))
+	}
+	cached.lock.Lock()
+	defer cached.lock.Unlock()
+	return cached.cachedDir.fileInfos()
+}
+
+// newest returns the info with the latest modtime, or nil if there are no infos.
+func newest(infos []fs.FileInfo) fs.FileInfo {
+	var newest fs.FileInfo
+	for _, info := range infos {
+		if newest == nil || info.ModTime().After(newest.ModTime()) {
+			newest = info
+		}
+	}
+	return newest
+}
+
+// GetNewest returns the name and entry of the most recently modified entry in a directory, using
+// `GetDirWithStats`. If more than one entry has the latest modtime, the first is returned. If the
+// directory is empty, the name is empty and the entry is nil.
+func (cache *FsCache[T]) GetNewest(dir string) (string, fs.DirEntry, error) {
+	infos, err := cache.GetDirWithStats(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	info := newest(infos)
+	if info == nil {
+		return "", nil, nil
+	}
+	return info.Name(), fs.FileInfoToDirEntry(info), nil
+}
+
+// GetNewest returns the name and entry of the most recently modified entry in a directory, using
+// `GetDirWithStats`. If more than one entry has the latest modtime, the first is returned. If the
+// directory is empty, the name is empty and the entry is nil.
+func (cache *ConcurrentFsCache[T]) GetNewest(dir string) (string, fs.DirEntry, error) {
+	infos, err := cache.GetDirWithStats(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	info := newest(infos)
+	if info == nil {
+		return "", nil, nil
+	}
+	return info.Name(), fs.FileInfoToDirEntry(info), nil
+}
@@ -0,0 +1,286 @@
+package parsecache
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+type testDirsInterface interface {
+	GetDirEntriesMap(string) (map[string]fs.DirEntry, error)
+	GetDirCount(string) (int, error)
+	GetDirNames(string) ([]string, error)
+	GetDirWithStats(string) ([]fs.FileInfo, error)
+	GetNewest(string) (string, fs.DirEntry, error)
+}
+
+func dirsTests(t *testing.T, cache testDirsInterface) {
+	entries, err := cache.GetDirEntriesMap("/")
+	if err != nil {
+		panic(err)
+	}
+	if len(entries) != 4 || entries["a.json"] == nil || entries["sub"] == nil || !entries["sub"].IsDir() {
+		t.Errorf("incorrect entries map: %v", entries)
+	}
+
+	entries, err = cache.GetDirEntriesMap("missing")
+	if !os.IsNotExist(err) || len(entries) != 0 {
+		t.Errorf("missing directory didn't return an empty map and ErrNotExist: %v", err)
+	}
+
+	for dir, expected := range map[string]int{"/": 4, "logs": 3, "empty": 0} {
+		if count, err := cache.GetDirCount(dir); err != nil || count != expected {
+			t.Errorf("incorrect count of %s: %d, %v", dir, count, err)
+		}
+	}
+	if count, err := cache.GetDirCount("missing"); !os.IsNotExist(err) || count != 0 {
+		t.Errorf("missing directory didn't return 0 and ErrNotExist: %d, %v", count, err)
+	}
+
+	names, err := cache.GetDirNames("/")
+	if err != nil || !equalNames(names, []string{"a.json", "empty", "logs", "sub"}) {
+		t.Errorf("incorrect names: %v, %v", names, err)
+	}
+	// The names are copied, so modifying them doesn't affect the cache.
+	names[0] = "modified"
+	if names, _ = cache.GetDirNames("/"); names[0] != "a.json" {
+		t.Errorf("cached names modified: %v", names)
+	}
+	if names, err = cache.GetDirNames("missing"); !os.IsNotExist(err) || names != nil {
+		t.Errorf("missing directory didn't return no names and ErrNotExist: %v, %v", names, err)
+	}
+
+	infos, err := cache.GetDirWithStats("logs")
+	if err != nil {
+		panic(err)
+	}
+	if len(infos) != 3 || infos[0].Name() != "1.log" || infos[0].Size() != 3 {
+		t.Errorf("incorrect stats: %v", infos)
+	}
+
+	name, entry, err := cache.GetNewest("logs")
+	if err != nil {
+		panic(err)
+	}
+	if name != "2.log" || entry.Name() != "2.log" || entry.IsDir() {
+		t.Errorf("incorrect newest entry %s", name)
+	}
+
+	name, entry, err = cache.GetNewest("empty")
+	if err != nil || name != "" || entry != nil {
+		t.Errorf("empty directory has a newest entry %s: %v", name, err)
+	}
+	_, _, err = cache.GetNewest("missing")
+	if !os.IsNotExist(err) {
+		t.Errorf("missing directory didn't return ErrNotExist: %v", err)
+	}
+}
+
+func TestDirHelpers(t *testing.T) {
+	now := time.Now()
+	filesystem := fstest.MapFS{
+		"a.json":     &fstest.MapFile{},
+		"sub/b.json": &fstest.MapFile{},
+		"logs/1.log": &fstest.MapFile{Data: []byte("one"), ModTime: now.Add(-time.Hour)},
+		"logs/2.log": &fstest.MapFile{Data: []byte("two"), ModTime: now},
+		"logs/3.log": &fstest.MapFile{Data: []byte("three"), ModTime: now.Add(-time.Minute)},
+		"empty":      &fstest.MapFile{Mode: fs.ModeDir},
+	}
+	fsCache := NewFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+	dirsTests(t, &fsCache)
+	dirsTests(t, NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute))
+}
+
+func TestGetDirNamesChanged(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"dir":        &fstest.MapFile{Mode: fs.ModeDir, ModTime: time.Now().Add(-time.Hour)},
+		"dir/b.json": &fstest.MapFile{},
+	}
+	cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+	if names, err := cache.GetDirNamesWithMaxAge("dir", 0); err != nil || !equalNames(names, []string{"b.json"}) {
+		t.Errorf("incorrect names: %v, %v", names, err)
+	}
+	filesystem["dir"] = &fstest.MapFile{Mode: fs.ModeDir, ModTime: time.Now()}
+	filesystem["dir/a.json"] = &fstest.MapFile{}
+	if names, err := cache.GetDirNamesWithMaxAge("dir", 0); err != nil || !equalNames(names, []string{"a.json", "b.json"}) {
+		t.Errorf("names not read again after the directory changed: %v, %v", names, err)
+	}
+}
+
+// reversedFS is a filesystem which reads directories in reverse order.
+type reversedFS struct {
+	fstest.MapFS
+}
+
+func (r reversedFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := r.MapFS.ReadDir(name)
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, err
+}
+
+func TestGetDirFiltered(t *testing.T) {
+	mapFS := fstest.MapFS{
+		"dir":        &fstest.MapFile{Mode: fs.ModeDir, ModTime: time.Now().Add(-time.Hour)},
+		"dir/a.json": &fstest.MapFile{},
+		"dir/b.txt":  &fstest.MapFile{},
+		"dir/c.json": &fstest.MapFile{},
+		"dir/.d":     &fstest.MapFile{},
+	}
+	keep := func(entry fs.DirEntry) bool {
+		return strings.HasSuffix(entry.Name(), ".json") && !strings.HasPrefix(entry.Name(), ".")
+	}
+	for _, concurrent := range []bool{false, true} {
+		var getDir func(string) ([]fs.DirEntry, error)
+		var getDirFiltered func(string, func(fs.DirEntry) bool) ([]fs.DirEntry, error)
+		if concurrent {
+			c := NewConcurrentFsCache(reversedFS{mapFS}, JsonParser[testFileStructure], 0)
+			getDir, getDirFiltered = c.GetDir, c.GetDirFiltered
+		} else {
+			c := NewFsCache(reversedFS{mapFS}, JsonParser[testFileStructure], 0)
+			getDir, getDirFiltered = c.GetDir, c.GetDirFiltered
+		}
+
+		// Entries are sorted by name however the filesystem reads them, including once the
+		// directory changes.
+		expected := []string{".d", "a.json", "b.txt", "c.json"}
+		expectedFiltered := []string{"a.json", "c.json"}
+		for i := 0; i < 2; i++ {
+			entries, err := getDir("dir")
+			if err != nil || !equalNames(entryNames(entries), expected) {
+				t.Errorf("concurrent=%v: entries not sorted: %v, %v", concurrent, entryNames(entries), err)
+			}
+			filtered, err := getDirFiltered("dir", keep)
+			if err != nil || !equalNames(entryNames(filtered), expectedFiltered) {
+				t.Errorf("concurrent=%v: entries not filtered: %v, %v", concurrent, entryNames(filtered), err)
+			}
+			entries, _ = getDir("dir")
+			if !equalNames(entryNames(entries), expected) {
+				t.Errorf("concurrent=%v: cached entries modified by filtering: %v", concurrent, entryNames(entries))
+			}
+			mapFS["dir/0.json"] = &fstest.MapFile{}
+			mapFS["dir"] = &fstest.MapFile{Mode: fs.ModeDir, ModTime: time.Now()}
+			expected = []string{".d", "0.json", "a.json", "b.txt", "c.json"}
+			expectedFiltered = []string{"0.json", "a.json", "c.json"}
+		}
+		delete(mapFS, "dir/0.json")
+		mapFS["dir"] = &fstest.MapFile{Mode: fs.ModeDir, ModTime: time.Now().Add(-time.Hour)}
+	}
+}
+
+func TestGetDirCopy(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"dir/a.json": &fstest.MapFile{},
+		"dir/b.json": &fstest.MapFile{},
+	}
+	for _, concurrent := range []bool{false, true} {
+		var getDir, getDirShared func(string) ([]fs.DirEntry, error)
+		if concurrent {
+			c := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+			getDir, getDirShared = c.GetDir, c.GetDirShared
+		} else {
+			c := NewFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+			getDir, getDirShared = c.GetDir, c.GetDirShared
+		}
+
+		entries, err := getDir("dir")
+		if err != nil {
+			panic(err)
+		}
+		entries[0], entries[1] = entries[1], entries[0]
+		if entries, _ = getDir("dir"); !equalNames(entryNames(entries), []string{"a.json", "b.json"}) {
+			t.Errorf("concurrent=%v: cached entries modified through GetDir: %v", concurrent, entryNames(entries))
+		}
+
+		// GetDirShared returns the cached slice itself.
+		shared, _ := getDirShared("dir")
+		again, _ := getDirShared("dir")
+		if len(shared) != 2 || &shared[0] != &again[0] {
+			t.Errorf("concurrent=%v: GetDirShared didn't return the cached slice", concurrent)
+		}
+	}
+}
+
+// infoCountingFS is a filesystem whose directory entries count the number of times their info is
+// read, and fail to read the info of entries named "broken".
+type infoCountingFS struct {
+	fstest.MapFS
+	infos *int
+}
+
+// infoCountingEntry is a directory entry of an `infoCountingFS`.
+type infoCountingEntry struct {
+	fs.DirEntry
+	infos *int
+}
+
+func (e infoCountingEntry) Info() (fs.FileInfo, error) {
+	*e.infos++
+	if e.Name() == "broken" {
+		return nil, fs.ErrPermission
+	}
+	return e.DirEntry.Info()
+}
+
+func (c infoCountingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := c.MapFS.ReadDir(name)
+	for i, entry := range entries {
+		entries[i] = infoCountingEntry{entry, c.infos}
+	}
+	return entries, err
+}
+
+func TestGetDirInfos(t *testing.T) {
+	for _, concurrent := range []bool{false, true} {
+		for _, eager := range []bool{false, true} {
+			var infos int
+			mapFS := fstest.MapFS{
+				"dir":        &fstest.MapFile{Mode: fs.ModeDir, ModTime: time.Now().Add(-time.Hour)},
+				"dir/a.json": &fstest.MapFile{Data: []byte("a")},
+				"dir/b.json": &fstest.MapFile{Data: []byte("bb")},
+				"dir/broken": &fstest.MapFile{},
+			}
+			filesystem := infoCountingFS{mapFS, &infos}
+			var opts []Option[testFileStructure]
+			if eager {
+				opts = append(opts, WithDirInfos[testFileStructure]())
+			}
+			var getDir func(string) ([]fs.DirEntry, error)
+			var getDirInfos func(string) ([]DirEntryInfo, error)
+			if concurrent {
+				c := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Hour, opts...)
+				getDir, getDirInfos = c.GetDir, c.GetDirInfos
+			} else {
+				c := NewFsCache(filesystem, JsonParser[testFileStructure], time.Hour, opts...)
+				getDir, getDirInfos = c.GetDir, c.GetDirInfos
+			}
+
+			getDir("dir")
+			if eager && infos != 3 || !eager && infos != 0 {
+				t.Errorf("concurrent=%v eager=%v: %d infos read by loading the directory", concurrent, eager, infos)
+			}
+			infos = 0
+			for i := 0; i < 2; i++ {
+				dirInfos, err := getDirInfos("dir")
+				if err != nil || len(dirInfos) != 3 {
+					t.Fatalf("concurrent=%v eager=%v: incorrect infos: %v, %v", concurrent, eager, dirInfos, err)
+				}
+				if dirInfos[1].Entry.Name() != "b.json" || dirInfos[1].Info.Size() != 2 || dirInfos[1].Err != nil {
+					t.Errorf("concurrent=%v eager=%v: incorrect info for b.json: %+v", concurrent, eager, dirInfos[1])
+				}
+				if dirInfos[2].Info != nil || !errors.Is(dirInfos[2].Err, fs.ErrPermission) {
+					t.Errorf("concurrent=%v eager=%v: broken entry doesn't carry its error: %+v", concurrent, eager, dirInfos[2])
+				}
+			}
+			// The infos are read once per load, either as it's loaded or by the first get.
+			if eager && infos != 0 || !eager && infos != 3 {
+				t.Errorf("concurrent=%v eager=%v: %d infos read by GetDirInfos", concurrent, eager, infos)
+			}
+		}
+	}
+}
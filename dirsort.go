@@ -0,0 +1,78 @@
+package parsecache
+
+import (
+	"io/fs"
+	"sort"
+	"time"
+)
+
+// DirSortOrder is the order of the entries of cached directories, see `WithDirSortOrder`.
+type DirSortOrder int
+
+const (
+	// SortByName sorts entries by name, like `fs.ReadDir`.
+	SortByName DirSortOrder = iota
+	// SortByNameDesc sorts entries by name, in reverse.
+	SortByNameDesc
+	// SortByModTime sorts entries by modtime, oldest first.
+	SortByModTime
+	// SortByModTimeDesc sorts entries by modtime, newest first.
+	SortByModTimeDesc
+	// SortBySize sorts entries by size, smallest first.
+	SortBySize
+	// SortBySizeDesc sorts entries by size, largest first.
+	SortBySizeDesc
+)
+
+// WithDirSortOrder sets the order of the entries returned by `GetDir`, which are sorted once each
+// time a directory is read, rather than by each caller. The default is `SortByName`.
+//
+// Sorting by modtime or size stats each entry when the directory is read, and entries with the
+// same modtime or size are sorted by name. Entries which can't be stat-ed, such as because they
+// were removed, sort as if their modtime and size were zero.
+func WithDirSortOrder[T any](order DirSortOrder) Option[T] {
+	return func(o *options[T]) {
+		o.load.dirSort = order
+	}
+}
+
+// sortDirEntries sorts `entries` in `order`.
+func sortDirEntries(entries []fs.DirEntry, order DirSortOrder) {
+	switch order {
+	case SortByName:
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Name() < entries[j].Name()
+		})
+		return
+	case SortByNameDesc:
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Name() > entries[j].Name()
+		})
+		return
+	}
+
+	type sortKey struct {
+		modTime time.Time
+		size    int64
+	}
+	keys := make(map[string]sortKey, len(entries))
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil {
+			keys[entry.Name()] = sortKey{info.ModTime(), info.Size()}
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := keys[entries[i].Name()], keys[entries[j].Name()]
+		switch {
+		case order == SortByModTime && !a.modTime.Equal(b.modTime):
+			return a.modTime.Before(b.modTime)
+		case order == SortByModTimeDesc && !a.modTime.Equal(b.modTime):
+			return a.modTime.After(b.modTime)
+		case order == SortBySize && a.size != b.size:
+			return a.size < b.size
+		case order == SortBySizeDesc && a.size != b.size:
+			return a.size > b.size
+		}
+		return entries[i].Name() < entries[j].Name()
+	})
+}
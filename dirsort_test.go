@@ -0,0 +1,58 @@
+package parsecache
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestDirSortOrder(t *testing.T) {
+	now := time.Now()
+	filesystem := fstest.MapFS{
+		"dir/a.txt": &fstest.MapFile{Data: []byte("aaa"), ModTime: now.Add(-time.Minute)},
+		"dir/b.txt": &fstest.MapFile{Data: []byte("b"), ModTime: now.Add(-time.Hour)},
+		"dir/c.txt": &fstest.MapFile{Data: []byte("cc"), ModTime: now},
+		"dir/d.txt": &fstest.MapFile{Data: []byte("dd"), ModTime: now},
+	}
+	for _, test := range []struct {
+		order DirSortOrder
+		names []string
+	}{
+		{SortByName, []string{"a.txt", "b.txt", "c.txt", "d.txt"}},
+		{SortByNameDesc, []string{"d.txt", "c.txt", "b.txt", "a.txt"}},
+		{SortByModTime, []string{"b.txt", "a.txt", "c.txt", "d.txt"}},
+		{SortByModTimeDesc, []string{"c.txt", "d.txt", "a.txt", "b.txt"}},
+		{SortBySize, []string{"b.txt", "c.txt", "d.txt", "a.txt"}},
+		{SortBySizeDesc, []string{"a.txt", "c.txt", "d.txt", "b.txt"}},
+	} {
+		for _, concurrent := range []bool{false, true} {
+			opt := WithDirSortOrder[testFileStructure](test.order)
+			var cache testInterface
+			if concurrent {
+				cache = NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute, opt)
+			} else {
+				c := NewFsCache(filesystem, JsonParser[testFileStructure], time.Minute, opt)
+				cache = &c
+			}
+			for i := 0; i < 2; i++ {
+				entries, err := cache.GetDir("dir")
+				if err != nil {
+					panic(err)
+				}
+				if !equalNames(entryNames(entries), test.names) {
+					t.Errorf("order %d, concurrent=%v: got %v", test.order, concurrent, entryNames(entries))
+				}
+			}
+		}
+	}
+}
+
+// entryNames returns the names of `entries`.
+func entryNames(entries []fs.DirEntry) []string {
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+	return names
+}
@@ -0,0 +1,29 @@
+package parsecache
+
+import (
+	"embed"
+	"testing"
+)
+
+//go:embed testdata/embed
+var testEmbedFS embed.FS
+
+func TestFsCacheFromEmbed(t *testing.T) {
+	cache := NewFsCacheFromEmbed(testEmbedFS, JsonParser[testFileStructure])
+	for i := 0; i < 2; i++ {
+		a, err := cache.GetFile("testdata/embed/a.json")
+		if err != nil {
+			panic(err)
+		}
+		if a.Hello != "embedded" || a.Number != 3 {
+			t.Error("embedded a.json not parsed correctly")
+		}
+	}
+	entries, err := cache.GetDir("testdata/embed")
+	if err != nil {
+		panic(err)
+	}
+	if len(entries) != 1 {
+		t.Error("embedded directory not read correctly")
+	}
+}
@@ -0,0 +1,45 @@
+package parsecache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// NewCachedFile returns a cache entry for a file of `size` bytes, last modified at `modTime`, whose
+// parsed content, `content`, was cached at `cachedAt`. It can be stored in a cache with
+// `SetFileEntry`, for example, to preload a cache, or in tests.
+func NewCachedFile[T any](content T, size int64, modTime, cachedAt time.Time) CachedFile[T] {
+	return CachedFile[T]{
+		lastLoadTime: cachedAt,
+		lastSize:     size,
+		lastModTime:  modTime,
+		lastToken:    sizeModTime{size, modTime},
+		content:      content,
+	}
+}
+
+// SetFileEntry replaces the entry for the file at `path` with a copy of `entry`. The entry is
+// revalidated, by its size and modtime, once it reaches the maximum age, like any other entry.
+func (cache *FsCache[T]) SetFileEntry(path string, entry CachedFile[T]) {
+	path = cache.options.key(cache.normalize(path))
+	entry.lastUsed = time.Now()
+	cache.files[path] = &entry
+	cache.evictFiles(path)
+}
+
+// SetFileEntry replaces the entry for the file at `path` with a copy of `entry`. The entry is
+// revalidated, by its size and modtime, once it reaches the maximum age, like any other entry.
+func (cache *ConcurrentFsCache[T]) SetFileEntry(path string, entry CachedFile[T]) {
+	path = cache.options.key(cache.normalize(path))
+	cached := &ConcurrentCachedFile[T]{cachedFile: entry}
+	if !entry.lastLoadTime.IsZero() {
+		cached.last.Store(&lastContent[T]{content: entry.content, compressed: entry.compressed})
+	}
+	atomic.StoreInt64(&cached.lastUsed, time.Now().UnixNano())
+
+	shard := cache.shard(path)
+	shard.filesLock.Lock()
+	shard.files[path] = cached
+	shard.filesLock.Unlock()
+	cache.notifyEvicted(cache.evictFiles(path))
+}
@@ -0,0 +1,41 @@
+package parsecache
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestSetFileEntry(t *testing.T) {
+	modTime := time.Now().Add(-time.Hour)
+	filesystem := fstest.MapFS{
+		"a.json": &fstest.MapFile{Data: []byte(`{"Hello": "world"}`), ModTime: modTime},
+	}
+	size := int64(len(filesystem["a.json"].Data))
+	for _, concurrent := range []bool{false, true} {
+		var get func() (testFileStructure, error)
+		var set func(CachedFile[testFileStructure])
+		if concurrent {
+			cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+			get = func() (testFileStructure, error) { return cache.GetFile("a.json") }
+			set = func(entry CachedFile[testFileStructure]) { cache.SetFileEntry("a.json", entry) }
+		} else {
+			cache := NewFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+			get = func() (testFileStructure, error) { return cache.GetFile("a.json") }
+			set = func(entry CachedFile[testFileStructure]) { cache.SetFileEntry("a.json", entry) }
+		}
+
+		set(NewCachedFile(testFileStructure{Hello: "set"}, size, modTime, time.Now()))
+		a, err := get()
+		if err != nil || a.Hello != "set" {
+			t.Errorf("concurrent=%v: entry not served: %+v, %v", concurrent, a, err)
+		}
+
+		// An expired entry for a file that changed is reloaded.
+		set(NewCachedFile(testFileStructure{Hello: "set"}, size+1, modTime, time.Now().Add(-time.Hour)))
+		a, err = get()
+		if err != nil || a.Hello != "world" {
+			t.Errorf("concurrent=%v: changed file not reloaded: %+v, %v", concurrent, a, err)
+		}
+	}
+}
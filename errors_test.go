@@ -0,0 +1,83 @@
+package parsecache
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func loadErrorTests(t *testing.T, cache testInterface) {
+	_, err := cache.GetFile("/missing/../missing.json")
+	if !errors.Is(err, fs.ErrNotExist) || !os.IsNotExist(err) {
+		t.Error("open error doesn't unwrap to fs.ErrNotExist")
+	}
+	if err == nil || !strings.HasPrefix(err.Error(), "parsecache.open /missing.json: ") {
+		t.Errorf("unexpected open error message: %v", err)
+	}
+
+	_, err = cache.GetDir("missing")
+	if !errors.Is(err, fs.ErrNotExist) || !os.IsNotExist(err) {
+		t.Error("directory open error doesn't unwrap to fs.ErrNotExist")
+	}
+	if err == nil || !strings.HasPrefix(err.Error(), "parsecache.open /missing: ") {
+		t.Errorf("unexpected directory open error message: %v", err)
+	}
+
+	_, err = cache.GetFile("bad.json")
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Error("parse error doesn't unwrap to the parser's error")
+	}
+	var pathErr *fs.PathError
+	if !errors.As(err, &pathErr) || pathErr.Op != "parsecache.parse" || pathErr.Path != "/bad.json" {
+		t.Errorf("unexpected parse error: %v", err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"bad.json": &fstest.MapFile{Data: []byte(`{"Hello": nope}`)},
+	}
+	cache := NewFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+	loadErrorTests(t, &cache)
+	concurrent := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+	loadErrorTests(t, concurrent)
+}
+
+func TestFailedParseKeepsContent(t *testing.T) {
+	maxAge := time.Second / 20
+	for _, concurrent := range []bool{false, true} {
+		mapFS := fstest.MapFS{
+			"a.json": &fstest.MapFile{Data: []byte(`{"a": {"Hello": "world!"}}`)},
+		}
+		var cache interface {
+			GetFile(string) (map[string]*testFileStructure, error)
+		}
+		if concurrent {
+			cache = NewConcurrentFsCache(mapFS, JsonParser[map[string]*testFileStructure], maxAge)
+		} else {
+			c := NewFsCache(mapFS, JsonParser[map[string]*testFileStructure], maxAge)
+			cache = &c
+		}
+		before, err := cache.GetFile("a.json")
+		if err != nil {
+			panic(err)
+		}
+
+		// The new content is decoded partway, into the "a" key, before the parse fails.
+		mapFS["a.json"] = &fstest.MapFile{Data: []byte(`{"a": {"Hello": "changed"}, "b": nope}`), ModTime: time.Now()}
+		time.Sleep(maxAge)
+		after, err := cache.GetFile("a.json")
+		if !isParseError(err) {
+			t.Errorf("a.json didn't fail to parse: %v", err)
+		}
+		if before["a"].Hello != "world!" || after["a"].Hello != "world!" || len(after) != 1 {
+			t.Error("failed parse modified the cached content")
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package parsecache
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheEventType is the type of a `CacheEvent`.
+type CacheEventType int
+
+const (
+	// CacheHit is a file which was returned from memory.
+	CacheHit CacheEventType = iota
+	// CacheMiss is a file which wasn't cached, and was loaded.
+	CacheMiss
+	// CacheEvict is a file which was evicted because of `WithMaxEntries`.
+	CacheEvict
+	// CacheRefresh is a cached file which reached its maximum age, and was revalidated, and parsed
+	// again if it had changed.
+	CacheRefresh
+	// CacheError is a file which failed to load.
+	CacheError
+)
+
+func (t CacheEventType) String() string {
+	switch t {
+	case CacheHit:
+		return "hit"
+	case CacheMiss:
+		return "miss"
+	case CacheEvict:
+		return "evict"
+	case CacheRefresh:
+		return "refresh"
+	case CacheError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// CacheEvent is something which happened to a file in a cache, received from `Events`.
+type CacheEvent struct {
+	Type CacheEventType
+	// Path is the cleaned path of the file.
+	Path string
+	// Time is when the event happened.
+	Time time.Time
+}
+
+// eventsBuffer is the size of the buffer of the channel returned by `Events`.
+const eventsBuffer = 256
+
+// Events returns a channel which receives an event for each get of a file from the cache, and each
+// file evicted from it, as they happen. Every call returns the same channel, and the events are only
+// sent once it's first called.
+//
+// The channel is buffered, and events are dropped, rather than delaying the cache, while its buffer
+// is full, so it should be received from promptly. `Close` closes the channel, and events aren't
+// sent after that.
+func (cache *ConcurrentFsCache[T]) Events() <-chan CacheEvent {
+	return cache.events.channel()
+}
+
+// cacheEvents is the channel of events returned by `Events`.
+type cacheEvents struct {
+	// active is non-zero once the channel has been created, and until it's closed. It must be
+	// accessed atomically.
+	active int32
+
+	lock   sync.RWMutex
+	ch     chan CacheEvent
+	closed bool
+}
+
+// channel returns the channel, creating it if it hasn't been already.
+func (e *cacheEvents) channel() <-chan CacheEvent {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.ch == nil {
+		e.ch = make(chan CacheEvent, eventsBuffer)
+		if e.closed {
+			close(e.ch)
+		} else {
+			atomic.StoreInt32(&e.active, 1)
+		}
+	}
+	return e.ch
+}
+
+// enabled returns true if events should be sent.
+func (e *cacheEvents) enabled() bool {
+	return atomic.LoadInt32(&e.active) != 0
+}
+
+// send sends an event of type `t` for `path`, unless the channel hasn't been created, has been
+// closed, or is full.
+func (e *cacheEvents) send(t CacheEventType, path string) {
+	if !e.enabled() {
+		return
+	}
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	if e.closed {
+		return
+	}
+	select {
+	case e.ch <- CacheEvent{Type: t, Path: path, Time: time.Now()}:
+	default:
+	}
+}
+
+// load sends the event for a get of the file at `path`, given the time its entry was cached before
+// and after, which are zero if it wasn't cached, like `logLoad`.
+func (e *cacheEvents) load(path string, before, after time.Time, err error) {
+	switch {
+	case err != nil:
+		e.send(CacheError, path)
+	case !before.IsZero() && after.Equal(before):
+		e.send(CacheHit, path)
+	case before.IsZero():
+		e.send(CacheMiss, path)
+	default:
+		e.send(CacheRefresh, path)
+	}
+}
+
+// close closes the channel, if it's been created, and stops events being sent.
+func (e *cacheEvents) close() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.closed {
+		return
+	}
+	e.closed = true
+	atomic.StoreInt32(&e.active, 0)
+	if e.ch != nil {
+		close(e.ch)
+	}
+}
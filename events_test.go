@@ -0,0 +1,66 @@
+package parsecache
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestEvents(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"a.json":       &fstest.MapFile{Data: []byte(`{"Hello": "a"}`)},
+		"b.json":       &fstest.MapFile{Data: []byte(`{"Hello": "b"}`)},
+		"invalid.json": &fstest.MapFile{Data: []byte(`{"Hello": `)},
+	}
+	cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute, WithMaxEntries[testFileStructure](1))
+
+	// Events aren't sent until the channel is requested.
+	cache.GetFile("a.json")
+	events := cache.Events()
+	if cache.Events() != events {
+		t.Error("Events returned a different channel")
+	}
+
+	cache.GetFile("a.json")
+	cache.GetFile("b.json")
+	cache.GetFile("invalid.json")
+	cache.SetMaxAge(0)
+	cache.GetFile("b.json")
+
+	expected := []CacheEvent{
+		{Type: CacheHit, Path: "/a.json"},
+		{Type: CacheMiss, Path: "/b.json"},
+		{Type: CacheEvict, Path: "/a.json"},
+		{Type: CacheError, Path: "/invalid.json"},
+		{Type: CacheRefresh, Path: "/b.json"},
+	}
+	for _, e := range expected {
+		select {
+		case event := <-events:
+			if event.Type != e.Type || event.Path != e.Path || event.Time.IsZero() {
+				t.Errorf("expected a %v event for %s, got %v for %s", e.Type, e.Path, event.Type, event.Path)
+			}
+		default:
+			t.Errorf("no %v event for %s", e.Type, e.Path)
+		}
+	}
+
+	cache.Close()
+	cache.GetFile("b.json")
+	if event, ok := <-events; ok {
+		t.Errorf("event received after Close: %v for %s", event.Type, event.Path)
+	}
+}
+
+func TestEventsFull(t *testing.T) {
+	cache := NewConcurrentFsCache(fstest.MapFS{"a.json": &fstest.MapFile{Data: []byte(`{}`)}}, JsonParser[testFileStructure], time.Minute)
+	events := cache.Events()
+	// A full channel drops events rather than blocking the cache.
+	for i := 0; i < eventsBuffer+10; i++ {
+		cache.GetFile("a.json")
+	}
+	if len(events) != eventsBuffer {
+		t.Errorf("expected %d buffered events, got %d", eventsBuffer, len(events))
+	}
+	cache.Close()
+}
@@ -0,0 +1,117 @@
+package parsecache
+
+import "sync/atomic"
+
+// WithMaxEntries limits the number of files cached to `maxEntries`. When a new file is cached and the
+// limit is exceeded, the least recently used file is evicted. Directories and files inside archives
+// don't count towards the limit.
+//
+// Finding the least recently used entry scans all of the entries, so this is intended for limits of
+// up to a few thousand files.
+func WithMaxEntries[T any](maxEntries int) Option[T] {
+	return func(o *options[T]) {
+		o.maxEntries = maxEntries
+	}
+}
+
+// WithOnEvict sets a function to be called with each file entry that's evicted because of
+// `WithMaxEntries`. For a `ConcurrentFsCache`, `entry` is a copy of the entry, and `onEvict` is
+// called without any of the cache's locks held.
+func WithOnEvict[T any](onEvict func(path string, entry *CachedFile[T])) Option[T] {
+	return func(o *options[T]) {
+		o.onEvict = onEvict
+	}
+}
+
+// evictFiles removes the least recently used files, other than `keep`, until the number of files is
+// within the limit set by `WithMaxEntries`.
+func (cache *FsCache[T]) evictFiles(keep string) {
+	for cache.options.maxEntries > 0 && len(cache.files) > cache.options.maxEntries {
+		var oldestPath string
+		var oldest *CachedFile[T]
+		for path, entry := range cache.files {
+			if path != keep && (oldest == nil || entry.lastUsed.Before(oldest.lastUsed)) {
+				oldestPath = path
+				oldest = entry
+			}
+		}
+		if oldest == nil {
+			return
+		}
+		delete(cache.files, oldestPath)
+		cache.evictions++
+		if cache.options.logger != nil {
+			cache.options.logger.evicted(oldestPath)
+		}
+		if cache.options.onEvict != nil {
+			cache.options.onEvict(oldestPath, oldest)
+		}
+	}
+}
+
+// evictedFile is a file entry which has been evicted from a `ConcurrentFsCache`.
+type evictedFile[T any] struct {
+	path  string
+	entry *ConcurrentCachedFile[T]
+}
+
+// evictFiles removes the least recently used files, other than `keep`, until the number of files is
+// within the limit set by `WithMaxEntries`, and returns the evicted entries. It locks every shard, so
+// none of the cache's locks may be held.
+func (cache *ConcurrentFsCache[T]) evictFiles(keep string) []evictedFile[T] {
+	if cache.options.maxEntries <= 0 {
+		return nil
+	}
+	files := 0
+	for i := range cache.shards {
+		cache.shards[i].filesLock.Lock()
+		defer cache.shards[i].filesLock.Unlock()
+		files += len(cache.shards[i].files)
+	}
+
+	var evicted []evictedFile[T]
+	for ; files > cache.options.maxEntries; files-- {
+		var oldest evictedFile[T]
+		var oldestShard *cacheShard[T]
+		var oldestUsed int64
+		for i := range cache.shards {
+			for path, entry := range cache.shards[i].files {
+				used := atomic.LoadInt64(&entry.lastUsed)
+				if path != keep && (oldest.entry == nil || used < oldestUsed) {
+					oldest = evictedFile[T]{path, entry}
+					oldestShard = &cache.shards[i]
+					oldestUsed = used
+				}
+			}
+		}
+		if oldest.entry == nil {
+			break
+		}
+		delete(oldestShard.files, oldest.path)
+		atomic.AddUint64(&cache.evictions, 1)
+		evicted = append(evicted, oldest)
+	}
+	return evicted
+}
+
+// notifyEvicted logs each of the evicted entries, sends their events, and calls the function set by `WithOnEvict` with
+// them. It must be called without holding any of the cache's locks.
+func (cache *ConcurrentFsCache[T]) notifyEvicted(evicted []evictedFile[T]) {
+	if cache.options.logger != nil {
+		for _, e := range evicted {
+			cache.options.logger.evicted(e.path)
+		}
+	}
+	for _, e := range evicted {
+		cache.events.send(CacheEvict, e.path)
+	}
+	if cache.options.onEvict == nil {
+		return
+	}
+	for _, e := range evicted {
+		e.entry.lock.RLock()
+		entry := e.entry.cachedFile
+		e.entry.lock.RUnlock()
+		cache.options.onEvict(e.path, &entry)
+	}
+}
@@ -0,0 +1,57 @@
+package parsecache
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"strings"
+)
+
+// ErrUnknownExtension is the error for a file with no parser set by `WithParserFor`, when
+// `WithUnknownExtensionError` is used.
+var ErrUnknownExtension = errors.New("parsecache: no parser for the file's extension")
+
+// WithParserFor sets the parser for files with paths ending in `ext`, for example ".yaml", so a
+// single cache can hold files in different formats. If more than one extension matches a path, the
+// longest is used, so ".json.gz" can have a different parser to ".gz".
+//
+// Files which don't match any extension are parsed by the parser given to the constructor (or set
+// by `WithPathParser` or `WithInfoParser`), unless `WithUnknownExtensionError` is used. Files inside
+// archives are matched by their path inside the archive.
+func WithParserFor[T any](ext string, parser Parser[T]) Option[T] {
+	return func(o *options[T]) {
+		if o.parsersByExt == nil {
+			o.parsersByExt = make(map[string]fileParser[T])
+		}
+		o.parsersByExt[ext] = parser.fileParser()
+	}
+}
+
+// WithUnknownExtensionError makes files which don't match any of the extensions set by
+// `WithParserFor` fail to load with `ErrUnknownExtension`, rather than being parsed by the default
+// parser, which may then be nil.
+func WithUnknownExtensionError[T any]() Option[T] {
+	return func(o *options[T]) {
+		o.unknownExtensionError = true
+	}
+}
+
+// extensionParser returns the parser set by `WithParserFor` for the cleaned `path`, or, if there
+// isn't one, a parser failing with `ErrUnknownExtension` if `WithUnknownExtensionError` is used.
+func (o *options[T]) extensionParser(path string) (parser fileParser[T], ok bool) {
+	longest := -1
+	for ext, p := range o.parsersByExt {
+		if len(ext) > longest && strings.HasSuffix(path, ext) {
+			longest = len(ext)
+			parser = p
+			ok = true
+		}
+	}
+	if !ok && o.unknownExtensionError {
+		return func(context.Context, fs.File, fs.FileInfo) (T, error) {
+			var zero T
+			return zero, ErrUnknownExtension
+		}, true
+	}
+	return
+}
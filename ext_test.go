@@ -0,0 +1,57 @@
+package parsecache
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestWithParserFor(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"a.json":    &fstest.MapFile{Data: []byte(`{"Hello": "json"}`)},
+		"b.xml":     &fstest.MapFile{Data: []byte(`<testFileStructure><Hello>xml</Hello></testFileStructure>`)},
+		"c.json.gz": &fstest.MapFile{Data: gzipped([]byte(`{"Hello": "gzipped json"}`))},
+		"d.txt":     &fstest.MapFile{Data: []byte(`txt`)},
+	}
+	textParser := func(f io.Reader) (testFileStructure, error) {
+		content, err := io.ReadAll(f)
+		return testFileStructure{Hello: string(bytes.TrimSpace(content))}, err
+	}
+	extensions := []Option[testFileStructure]{
+		WithParserFor(".json", JsonParser[testFileStructure]),
+		WithParserFor(".xml", XmlParser[testFileStructure]),
+		WithParserFor(".gz", Parser[testFileStructure](func(io.Reader) (testFileStructure, error) {
+			return testFileStructure{}, errors.New("not a gzipped JSON file")
+		})),
+		WithParserFor(".json.gz", GzipParser(JsonParser[testFileStructure])),
+	}
+
+	cache := NewFsCache(filesystem, textParser, time.Minute, extensions...)
+	for file, expected := range map[string]string{
+		"a.json":    "json",
+		"b.xml":     "xml",
+		"c.json.gz": "gzipped json",
+		"d.txt":     "txt",
+	} {
+		parsed, err := cache.GetFile(file)
+		if err != nil {
+			t.Errorf("%s failed to parse: %v", file, err)
+		}
+		if parsed.Hello != expected {
+			t.Errorf("%s parsed as %q, expected %q", file, parsed.Hello, expected)
+		}
+	}
+
+	strict := NewConcurrentFsCache(filesystem, nil, time.Minute, append(extensions, WithUnknownExtensionError[testFileStructure]())...)
+	parsed, err := strict.GetFile("a.json")
+	if err != nil || parsed.Hello != "json" {
+		t.Errorf("a.json not parsed correctly: %v", err)
+	}
+	_, err = strict.GetFile("d.txt")
+	if !errors.Is(err, ErrUnknownExtension) {
+		t.Errorf("d.txt didn't fail with ErrUnknownExtension: %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package parsecache
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// GetFileWithFallback returns the parsed content of the file at `primary`, like `GetFile`, or, if it
+// doesn't exist, of the file at `fallback`, such as a user's override of a config file and the
+// system default. Both files are cached separately. If the fallback fails too, its error is
+// returned.
+func (cache *FsCache[T]) GetFileWithFallback(primary, fallback string) (T, error) {
+	content, err := cache.GetFile(primary)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cache.GetFile(fallback)
+	}
+	return content, err
+}
+
+// GetFileWithFallback returns the parsed content of the file at `primary`, like `GetFile`, or, if it
+// doesn't exist, of the file at `fallback`, such as a user's override of a config file and the
+// system default. Both files are cached separately. If the fallback fails too, its error is
+// returned.
+func (cache *ConcurrentFsCache[T]) GetFileWithFallback(primary, fallback string) (T, error) {
+	content, err := cache.GetFile(primary)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cache.GetFile(fallback)
+	}
+	return content, err
+}
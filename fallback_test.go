@@ -0,0 +1,56 @@
+package parsecache
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+type testFallbackInterface interface {
+	testInterface
+	GetFileWithFallback(primary, fallback string) (testFileStructure, error)
+}
+
+func fallbackTests(t *testing.T, cache testFallbackInterface, mapFS fstest.MapFS) {
+	parsed, err := cache.GetFileWithFallback("user.json", "default.json")
+	if err != nil || parsed.Hello != "default" {
+		t.Errorf("fallback not used for a missing file: %+v, %v", parsed, err)
+	}
+
+	mapFS["user.json"] = &fstest.MapFile{Data: []byte(`{"Hello": "user"}`)}
+	time.Sleep(time.Second / 20)
+	parsed, err = cache.GetFileWithFallback("user.json", "default.json")
+	if err != nil || parsed.Hello != "user" {
+		t.Errorf("primary file not used: %+v, %v", parsed, err)
+	}
+
+	// Other errors aren't replaced by the fallback.
+	_, err = cache.GetFileWithFallback("bad.json", "default.json")
+	if !isParseError(err) {
+		t.Errorf("parse error of the primary file not returned: %v", err)
+	}
+
+	_, err = cache.GetFileWithFallback("missing.json", "also-missing.json")
+	var pathErr *fs.PathError
+	if !errors.As(err, &pathErr) || pathErr.Path != "/also-missing.json" || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("error of the fallback not returned: %v", err)
+	}
+}
+
+func TestGetFileWithFallback(t *testing.T) {
+	for _, concurrent := range []bool{false, true} {
+		mapFS := fstest.MapFS{
+			"default.json": &fstest.MapFile{Data: []byte(`{"Hello": "default"}`)},
+			"bad.json":     &fstest.MapFile{Data: []byte(`{"Hello": nope}`)},
+		}
+		if concurrent {
+			cache := NewConcurrentFsCache(mapFS, JsonParser[testFileStructure], time.Second/20)
+			fallbackTests(t, cache, mapFS)
+		} else {
+			cache := NewFsCache(mapFS, JsonParser[testFileStructure], time.Second/20)
+			fallbackTests(t, &cache, mapFS)
+		}
+	}
+}
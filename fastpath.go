@@ -0,0 +1,141 @@
+package parsecache
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+)
+
+// WithStreamingReads opens files and streams them to the parser, rather than reading them with
+// `ReadFile` when the cache's filesystem implements `fs.StatFS` and `fs.ReadFileFS`. This keeps
+// less of a large file in memory at once, if the parser doesn't read all of it before parsing it.
+func WithStreamingReads[T any]() Option[T] {
+	return func(o *options[T]) {
+		o.load.streamingReads = true
+	}
+}
+
+// statFile is the file opened by a `source` for a filesystem which implements `fs.StatFS`. It's
+// stat-ed when it's opened, and the file itself is only opened once it's read, so revalidating an
+// unchanged entry only stats it.
+//
+// If the filesystem implements `fs.ReadDirFS`, a directory is read with `ReadDir`, without opening
+// it, and if it implements `fs.ReadFileFS`, a file is read with `ReadFile`, see `statFile.load`.
+type statFile struct {
+	fsys fs.FS
+	name string
+	info fs.FileInfo
+	// file is the opened file, once it's been read.
+	file fs.File
+	// used is true once the file has been read, by any means.
+	used bool
+	// openErr is the error opening or reading the whole of the file after it was stat-ed, if there
+	// was one.
+	openErr error
+}
+
+// openStatFile returns a function which stats `name` in `fsys` and returns it as a `*statFile`.
+func openStatFile(fsys fs.StatFS, name string) func() (fs.File, error) {
+	return func() (fs.File, error) {
+		info, err := fsys.Stat(name)
+		if err != nil {
+			return nil, err
+		}
+		return &statFile{fsys: fsys, name: name, info: info}, nil
+	}
+}
+
+func (f *statFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+// open opens the file, if it hasn't been opened already.
+func (f *statFile) open() (fs.File, error) {
+	f.used = true
+	if f.file == nil {
+		file, err := f.fsys.Open(f.name)
+		if err != nil {
+			f.openErr = err
+			return nil, err
+		}
+		f.file = file
+	}
+	return f.file, nil
+}
+
+func (f *statFile) Read(p []byte) (int, error) {
+	file, err := f.open()
+	if err != nil {
+		return 0, err
+	}
+	return file.Read(p)
+}
+
+func (f *statFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if readDirFS, ok := f.fsys.(fs.ReadDirFS); ok && n <= 0 && !f.used {
+		f.used = true
+		return readDirFS.ReadDir(f.name)
+	}
+	file, err := f.open()
+	if err != nil {
+		return nil, err
+	}
+	dir, ok := file.(fs.ReadDirFile)
+	if !ok {
+		return nil, ErrReadDirUnsupported
+	}
+	return dir.ReadDir(n)
+}
+
+// load returns the file to parse the content of the file from. Unless `streaming` is true, if the
+// filesystem implements `fs.ReadFileFS` and the file hasn't been read yet, the whole file is read
+// with `ReadFile`, and returned as a `*bytesFile`. Otherwise, the file itself is opened and returned,
+// so that the parser can use any other interfaces it implements, such as `io.Seeker`.
+func (f *statFile) load(streaming bool) (fs.File, error) {
+	readFileFS, ok := f.fsys.(fs.ReadFileFS)
+	if streaming || !ok || f.used {
+		return f.open()
+	}
+	f.used = true
+	content, err := readFileFS.ReadFile(f.name)
+	if err != nil {
+		f.openErr = err
+		return nil, err
+	}
+	return &bytesFile{Reader: bytes.NewReader(content), info: f.info, content: content}, nil
+}
+
+func (f *statFile) Close() error {
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Close()
+}
+
+// bytesFile is an `fs.File` of content which has already been read, with the info it was stat-ed
+// with.
+type bytesFile struct {
+	*bytes.Reader
+	info    fs.FileInfo
+	content []byte
+}
+
+func (f *bytesFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *bytesFile) Close() error {
+	return nil
+}
+
+// readAll returns the rest of the content of `f`, without copying it, and true, if `f` is a
+// `*bytesFile`. Otherwise, it returns false, and `f` should be read as usual.
+func readAll(f io.Reader) ([]byte, bool) {
+	file, ok := f.(*bytesFile)
+	if !ok {
+		return nil, false
+	}
+	content := file.content[len(file.content)-file.Len():]
+	file.Seek(0, io.SeekEnd)
+	return content, true
+}
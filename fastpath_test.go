@@ -0,0 +1,228 @@
+package parsecache
+
+import (
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// callCountingFS wraps an `fstest.MapFS`, counting the calls of each of its methods.
+type callCountingFS struct {
+	fs    fstest.MapFS
+	lock  sync.Mutex
+	calls map[string]int
+}
+
+func (c *callCountingFS) count(method string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.calls == nil {
+		c.calls = make(map[string]int)
+	}
+	c.calls[method]++
+}
+
+// take returns the calls counted so far, and resets them.
+func (c *callCountingFS) take() map[string]int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	calls := c.calls
+	c.calls = nil
+	return calls
+}
+
+func (c *callCountingFS) Open(name string) (fs.File, error) {
+	c.count("Open")
+	return c.fs.Open(name)
+}
+
+func (c *callCountingFS) Stat(name string) (fs.FileInfo, error) {
+	c.count("Stat")
+	return c.fs.Stat(name)
+}
+
+func (c *callCountingFS) ReadFile(name string) ([]byte, error) {
+	c.count("ReadFile")
+	return c.fs.ReadFile(name)
+}
+
+func (c *callCountingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	c.count("ReadDir")
+	return c.fs.ReadDir(name)
+}
+
+// equalCalls returns true if the counts of `a` and `b` are the same.
+func equalCalls(a, b map[string]int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for method, n := range a {
+		if b[method] != n {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFastPaths(t *testing.T) {
+	maxAge := time.Second / 20
+	modTime := time.Now().Add(-time.Hour)
+	bytesParser := FromBytesParser(func(content []byte) (testFileStructure, error) {
+		var parsed testFileStructure
+		err := json.Unmarshal(content, &parsed)
+		return parsed, err
+	})
+	for _, concurrent := range []bool{false, true} {
+		filesystem := &callCountingFS{fs: fstest.MapFS{
+			"a.json":    &fstest.MapFile{Data: []byte(`{"Number": 1}`), ModTime: modTime},
+			"dir":       &fstest.MapFile{Mode: fs.ModeDir, ModTime: modTime},
+			"dir/b.txt": &fstest.MapFile{ModTime: modTime},
+		}}
+		var cache, jsonCache, streaming testInterface
+		if concurrent {
+			cache = NewConcurrentFsCache(filesystem, bytesParser, maxAge)
+			jsonCache = NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], maxAge)
+			streaming = NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], maxAge, WithStreamingReads[testFileStructure]())
+		} else {
+			c := NewFsCache(filesystem, bytesParser, maxAge)
+			j := NewFsCache(filesystem, JsonParser[testFileStructure], maxAge)
+			s := NewFsCache(filesystem, JsonParser[testFileStructure], maxAge, WithStreamingReads[testFileStructure]())
+			cache, jsonCache, streaming = &c, &j, &s
+		}
+
+		a, err := cache.GetFile("a.json")
+		if err != nil || a.Number != 1 {
+			t.Errorf("concurrent=%v: a.json not parsed: %+v, %v", concurrent, a, err)
+		}
+		if calls := filesystem.take(); !equalCalls(calls, map[string]int{"Stat": 1, "ReadFile": 1}) {
+			t.Errorf("concurrent=%v: a.json not read with ReadFile: %v", concurrent, calls)
+		}
+		entries, err := cache.GetDir("dir")
+		if err != nil || len(entries) != 1 {
+			t.Errorf("concurrent=%v: dir not read: %d entries, %v", concurrent, len(entries), err)
+		}
+		if calls := filesystem.take(); !equalCalls(calls, map[string]int{"Stat": 1, "ReadDir": 1}) {
+			t.Errorf("concurrent=%v: dir not read with ReadDir: %v", concurrent, calls)
+		}
+
+		// Revalidating unchanged entries only stats them.
+		time.Sleep(maxAge)
+		cache.GetFile("a.json")
+		cache.GetDir("dir")
+		if calls := filesystem.take(); !equalCalls(calls, map[string]int{"Stat": 2}) {
+			t.Errorf("concurrent=%v: unchanged entries not only stat-ed: %v", concurrent, calls)
+		}
+
+		// Other parsers are given the content read with ReadFile too.
+		a, err = jsonCache.GetFile("a.json")
+		if err != nil || a.Number != 1 {
+			t.Errorf("concurrent=%v: a.json not parsed by a streaming parser: %+v, %v", concurrent, a, err)
+		}
+		if calls := filesystem.take(); !equalCalls(calls, map[string]int{"Stat": 1, "ReadFile": 1}) {
+			t.Errorf("concurrent=%v: a.json not read with ReadFile for a streaming parser: %v", concurrent, calls)
+		}
+
+		// With WithStreamingReads, the opened file is streamed instead.
+		a, err = streaming.GetFile("a.json")
+		if err != nil || a.Number != 1 {
+			t.Errorf("concurrent=%v: a.json not parsed by a streaming parser: %+v, %v", concurrent, a, err)
+		}
+		if calls := filesystem.take(); !equalCalls(calls, map[string]int{"Stat": 1, "Open": 1}) {
+			t.Errorf("concurrent=%v: a.json not opened for a streaming parser: %v", concurrent, calls)
+		}
+	}
+}
+
+// statOnlyFS is a `callCountingFS` which only implements `fs.StatFS`.
+type statOnlyFS struct {
+	fs *callCountingFS
+}
+
+func (s statOnlyFS) Open(name string) (fs.File, error) {
+	return s.fs.Open(name)
+}
+
+func (s statOnlyFS) Stat(name string) (fs.FileInfo, error) {
+	return s.fs.Stat(name)
+}
+
+func TestFastPathsWithoutReadFile(t *testing.T) {
+	filesystem := &callCountingFS{fs: fstest.MapFS{
+		"a.json": &fstest.MapFile{Data: []byte(`{"Number": 1}`), ModTime: time.Now().Add(-time.Hour)},
+	}}
+	cache := NewConcurrentFsCache(statOnlyFS{filesystem}, JsonParser[testFileStructure], time.Minute)
+	a, err := cache.GetFile("a.json")
+	if err != nil || a.Number != 1 {
+		t.Errorf("a.json not parsed: %+v, %v", a, err)
+	}
+	if calls := filesystem.take(); !equalCalls(calls, map[string]int{"Stat": 1, "Open": 1}) {
+		t.Errorf("a.json not opened without ReadFile: %v", calls)
+	}
+
+	// Revalidating the unchanged file only stats it, whichever other interfaces the filesystem has.
+	if _, err := cache.GetFileWithMaxAge("a.json", 0); err != nil {
+		t.Error(err)
+	}
+	if calls := filesystem.take(); !equalCalls(calls, map[string]int{"Stat": 1}) {
+		t.Errorf("unchanged a.json not only stat-ed: %v", calls)
+	}
+}
+
+func TestFastPathsKeepFileInterfaces(t *testing.T) {
+	dir, err := os.MkdirTemp("", "parsecache-test-fastpath-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"Number": 1}`), 0o644); err != nil {
+		panic(err)
+	}
+
+	for _, streaming := range []bool{false, true} {
+		for _, contentHash := range []bool{false, true} {
+			var file fs.File
+			parser := func(f io.Reader) (testFileStructure, error) {
+				file, _ = f.(fs.File)
+				return JsonParser[testFileStructure](f)
+			}
+			var opts []Option[testFileStructure]
+			if streaming {
+				opts = append(opts, WithStreamingReads[testFileStructure]())
+			}
+			if contentHash {
+				opts = append(opts, WithContentHash[testFileStructure]())
+			}
+			cache := NewConcurrentFsCache(os.DirFS(dir), parser, 0, opts...)
+
+			// Change the file, keeping its size, so that it's hashed before it's parsed again.
+			for i := 1; i <= 2; i++ {
+				if err := os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"Number": `+string(rune('0'+i))+`}`), 0o644); err != nil {
+					panic(err)
+				}
+				a, err := cache.GetFile("a.json")
+				if err != nil || int(a.Number) != i {
+					t.Errorf("streaming=%v contentHash=%v: a.json not parsed: %+v, %v", streaming, contentHash, a, err)
+				}
+				if contentHash && i == 1 {
+					// The first parse is hashed as it's read.
+					continue
+				}
+				if _, ok := file.(io.Seeker); !ok {
+					t.Errorf("streaming=%v contentHash=%v: parsed file %T isn't an io.Seeker", streaming, contentHash, file)
+				}
+				if _, ok := file.(io.ReaderAt); !ok {
+					t.Errorf("streaming=%v contentHash=%v: parsed file %T isn't an io.ReaderAt", streaming, contentHash, file)
+				}
+				if _, ok := file.(*os.File); streaming && !ok {
+					t.Errorf("streaming=%v contentHash=%v: parsed file %T isn't the opened *os.File", streaming, contentHash, file)
+				}
+			}
+		}
+	}
+}
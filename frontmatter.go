@@ -0,0 +1,70 @@
+package parsecache
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// errUnterminatedFrontMatter is returned when a file starts a front matter block, but doesn't end
+// it.
+var errUnterminatedFrontMatter = errors.New("parsecache: unterminated front matter")
+
+// frontMatterDelimiter is the line which starts and ends a front matter block.
+const frontMatterDelimiter = "---"
+
+// Document is a file parsed by a `FrontMatterParser`.
+type Document[Meta any] struct {
+	// Meta is the parsed front matter, or the zero value if the file has none.
+	Meta Meta
+	// Body is the content of the file after the front matter.
+	Body []byte
+	// BodyOffset is the offset in the file at which `Body` starts.
+	BodyOffset int
+}
+
+// FrontMatterParser returns a `Parser` for content files, such as markdown files, which may start
+// with a front matter block: a block of metadata between two lines of "---". The metadata is parsed
+// with `metaParser`, and the rest of the file is kept as the body. Both LF and CRLF line endings
+// are accepted.
+//
+// Files which don't start with a "---" line have no front matter, so the whole file is the body,
+// and `metaParser` isn't called. It's an error for the front matter not to be ended by a second
+// "---" line.
+func FrontMatterParser[Meta any](metaParser func([]byte) (Meta, error)) Parser[Document[Meta]] {
+	return func(f io.Reader) (Document[Meta], error) {
+		var doc Document[Meta]
+		content, err := BytesParser(f)
+		if err != nil {
+			return doc, err
+		}
+
+		line, rest, ok := nextLine(content)
+		if !ok || string(line) != frontMatterDelimiter {
+			doc.Body = content
+			return doc, nil
+		}
+		metaStart := len(content) - len(rest)
+		for len(rest) > 0 {
+			lineStart := len(content) - len(rest)
+			line, rest, _ = nextLine(rest)
+			if string(line) == frontMatterDelimiter {
+				doc.Meta, err = metaParser(content[metaStart:lineStart])
+				doc.BodyOffset = len(content) - len(rest)
+				doc.Body = rest
+				return doc, err
+			}
+		}
+		return doc, errUnterminatedFrontMatter
+	}
+}
+
+// nextLine splits the first line, without its line ending, from `content`. `ok` is false if the
+// line isn't terminated by a newline.
+func nextLine(content []byte) (line, rest []byte, ok bool) {
+	i := bytes.IndexByte(content, '\n')
+	if i < 0 {
+		return content, nil, false
+	}
+	return bytes.TrimSuffix(content[:i], []byte("\r")), content[i+1:], true
+}
@@ -0,0 +1,58 @@
+package parsecache
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func jsonMeta(content []byte) (testFileStructure, error) {
+	var meta testFileStructure
+	err := json.Unmarshal(content, &meta)
+	return meta, err
+}
+
+func TestFrontMatterParser(t *testing.T) {
+	parser := FrontMatterParser(jsonMeta)
+	for _, content := range []string{
+		"---\n{\"Hello\": \"world!\"}\n---\n# Title\n\nBody\n",
+		"---\r\n{\"Hello\": \"world!\"}\r\n---\r\n# Title\n\nBody\n",
+	} {
+		doc, err := parser(strings.NewReader(content))
+		if err != nil {
+			panic(err)
+		}
+		if doc.Meta.Hello != "world!" {
+			t.Errorf("front matter not parsed correctly: %+v", doc.Meta)
+		}
+		if string(doc.Body) != "# Title\n\nBody\n" || content[doc.BodyOffset:] != string(doc.Body) {
+			t.Errorf("body not split correctly: %q at %d", doc.Body, doc.BodyOffset)
+		}
+	}
+
+	doc, err := parser(strings.NewReader("---\n{\"Hello\": \"only front matter\"}\n---"))
+	if err != nil || doc.Meta.Hello != "only front matter" || len(doc.Body) != 0 {
+		t.Errorf("file ending with the front matter not parsed correctly: %+v, %v", doc, err)
+	}
+
+	for _, content := range []string{"# Title\n---\nBody\n", "--- not front matter\n", ""} {
+		doc, err := parser(strings.NewReader(content))
+		if err != nil {
+			t.Errorf("%q failed to parse: %v", content, err)
+		}
+		if doc.Meta.Hello != "" || string(doc.Body) != content || doc.BodyOffset != 0 {
+			t.Errorf("%q without front matter not parsed correctly: %+v", content, doc)
+		}
+	}
+
+	_, err = parser(strings.NewReader("---\n{\"Hello\": \"world!\"}\n# Title\n"))
+	if !errors.Is(err, errUnterminatedFrontMatter) {
+		t.Errorf("unterminated front matter didn't fail: %v", err)
+	}
+	_, err = parser(strings.NewReader("---\n{\"Hello\": nope}\n---\n"))
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Errorf("invalid front matter didn't fail with the parser's error: %v", err)
+	}
+}
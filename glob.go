@@ -0,0 +1,77 @@
+package parsecache
+
+import (
+	"io/fs"
+	pathpkg "path"
+	"sort"
+	"strings"
+)
+
+// Glob returns the cleaned paths of the files and directories which match `pattern`, sorted, with
+// the syntax of `path.Match` for each element of the path, like `fs.Glob`. Every directory which is
+// traversed is read with `GetDir`, so repeated globs are served from the cached directories.
+//
+// As with `fs.Glob`, errors reading directories are ignored, and the only possible error is
+// `path.ErrBadPattern`, when `pattern` is malformed.
+func (cache *FsCache[T]) Glob(pattern string) ([]string, error) {
+	return glob(cache.GetDirShared, pattern)
+}
+
+// Glob returns the cleaned paths of the files and directories which match `pattern`, sorted, with
+// the syntax of `path.Match` for each element of the path, like `fs.Glob`. Every directory which is
+// traversed is read with `GetDir`, so repeated globs are served from the cached directories.
+//
+// As with `fs.Glob`, errors reading directories are ignored, and the only possible error is
+// `path.ErrBadPattern`, when `pattern` is malformed.
+func (cache *ConcurrentFsCache[T]) Glob(pattern string) ([]string, error) {
+	return glob(cache.GetDirShared, pattern)
+}
+
+// glob returns the sorted matches of `pattern`, reading directories with `getDir`.
+func glob(getDir func(string) ([]fs.DirEntry, error), pattern string) ([]string, error) {
+	// Check the pattern is well-formed before it's cleaned, like `fs.Glob`.
+	if _, err := pathpkg.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	matches := globPath(getDir, cleanPath(pattern), false)
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// globPath returns the matches of the cleaned `pattern`, which is well-formed, or only the matching
+// directories if `dirsOnly`.
+func globPath(getDir func(string) ([]fs.DirEntry, error), pattern string, dirsOnly bool) []string {
+	if pattern == "/" {
+		return []string{"/"}
+	}
+	dir, name := pathpkg.Split(pattern)
+	dir = cleanPath(dir)
+	dirs := []string{dir}
+	if hasMeta(dir) {
+		dirs = globPath(getDir, dir, true)
+	}
+
+	// Names without meta characters are matched against the entries of their directory too, so
+	// their existence is checked through the cache.
+	var matches []string
+	for _, dir := range dirs {
+		entries, err := getDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if dirsOnly && !entry.IsDir() {
+				continue
+			}
+			if matched, _ := pathpkg.Match(name, entry.Name()); matched {
+				matches = append(matches, pathpkg.Join(dir, entry.Name()))
+			}
+		}
+	}
+	return matches
+}
+
+// hasMeta returns true if `path` has any of the special characters of `path.Match`.
+func hasMeta(path string) bool {
+	return strings.ContainsAny(path, `*?[\`)
+}
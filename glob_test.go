@@ -0,0 +1,58 @@
+package parsecache
+
+import (
+	"errors"
+	"path"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestGlob(t *testing.T) {
+	for _, concurrent := range []bool{false, true} {
+		filesystem := &countingFS{fs: fstest.MapFS{
+			"locales/en/messages.json": &fstest.MapFile{Data: []byte(`{}`)},
+			"locales/fr/messages.json": &fstest.MapFile{Data: []byte(`{}`)},
+			"locales/de/other.json":    &fstest.MapFile{Data: []byte(`{}`)},
+			"locales/README":           &fstest.MapFile{Data: []byte(`locales`)},
+		}}
+		var glob func(string) ([]string, error)
+		if concurrent {
+			glob = NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute).Glob
+		} else {
+			c := NewFsCache[testFileStructure](filesystem, JsonParser[testFileStructure], time.Minute)
+			glob = c.Glob
+		}
+
+		for _, test := range []struct {
+			pattern  string
+			expected []string
+		}{
+			{"locales/*/messages.json", []string{"/locales/en/messages.json", "/locales/fr/messages.json"}},
+			{"/locales/[ef]?", []string{"/locales/en", "/locales/fr"}},
+			{"locales/README", []string{"/locales/README"}},
+			{"locales/README/*", nil},
+			{"missing/*", nil},
+			{"/", []string{"/"}},
+		} {
+			matches, err := glob(test.pattern)
+			if err != nil || !reflect.DeepEqual(matches, test.expected) {
+				t.Errorf("concurrent=%v: incorrect matches for %s: %v, %v", concurrent, test.pattern, matches, err)
+			}
+		}
+
+		opens := filesystem.Opens()
+		matches, err := glob("locales/*/messages.json")
+		if err != nil || len(matches) != 2 {
+			t.Errorf("concurrent=%v: incorrect matches the second time: %v, %v", concurrent, matches, err)
+		}
+		if filesystem.Opens() != opens {
+			t.Errorf("concurrent=%v: second glob opened %d files", concurrent, filesystem.Opens()-opens)
+		}
+
+		if _, err := glob("locales/[/*"); !errors.Is(err, path.ErrBadPattern) {
+			t.Errorf("concurrent=%v: bad pattern didn't fail with path.ErrBadPattern: %v", concurrent, err)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package parsecache
+
+import (
+	"encoding/gob"
+	"io"
+)
+
+// GobParser[T] is a value of type Parser[T] which decodes a single gob value from a file, such as
+// one written by `EncodeGob`.
+func GobParser[T any](f io.Reader) (T, error) {
+	var parsed T
+	err := gob.NewDecoder(f).Decode(&parsed)
+	return parsed, err
+}
+
+// EncodeGob writes `v` to `w` as a single gob value, which can be read back with `GobParser`.
+func EncodeGob[T any](w io.Writer, v T) error {
+	return gob.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,46 @@
+package parsecache
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGobParser(t *testing.T) {
+	dir, err := os.MkdirTemp("", "parsecache-test-gob-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var encoded bytes.Buffer
+	err = EncodeGob(&encoded, testFileStructure{Hello: "world", Number: 12, Float: -0.3})
+	if err != nil {
+		panic(err)
+	}
+	os.WriteFile(filepath.Join(dir, "a.gob"), encoded.Bytes(), 0660)
+	os.WriteFile(filepath.Join(dir, "truncated.gob"), encoded.Bytes()[:encoded.Len()-4], 0660)
+
+	cache := NewFsCache(os.DirFS(dir), GobParser[testFileStructure], time.Minute)
+	a, err := cache.GetFile("a.gob")
+	if err != nil {
+		panic(err)
+	}
+	if a.Hello != "world" || a.Number != 12 || a.Float != -0.3 {
+		t.Error("a.gob not decoded correctly")
+	}
+
+	_, err = cache.GetFile("truncated.gob")
+	var pathErr *fs.PathError
+	if !errors.As(err, &pathErr) || pathErr.Path != "/truncated.gob" || pathErr.Op != "parsecache.parse" {
+		t.Errorf("truncated.gob error not wrapped with the path: %v", err)
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("truncated.gob didn't fail with io.ErrUnexpectedEOF: %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package parsecache
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+)
+
+// GoFile is a parsed Go source file.
+type GoFile struct {
+	// Fset is the file set holding the positions in `File`, which is created for each parse so that
+	// cached files are independent.
+	Fset *token.FileSet
+	// File is the syntax tree of the file.
+	File *ast.File
+}
+
+// GoFileParser returns a `PathParser`, for use with `WithPathParser`, which parses Go source files
+// with `parser.ParseFile` using `mode`. The positions in each file are relative to the cleaned path
+// of the file.
+//
+// Syntax errors are returned as a `scanner.ErrorList`, which the cache wraps with the path as for
+// any other parse error. With `parser.AllErrors` the list includes every error, not just the first
+// ten.
+func GoFileParser(mode parser.Mode) PathParser[GoFile] {
+	return func(path string, f fs.File) (GoFile, error) {
+		content, err := io.ReadAll(f)
+		if err != nil {
+			return GoFile{}, err
+		}
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, path, content, mode)
+		if err != nil {
+			return GoFile{}, err
+		}
+		return GoFile{Fset: fset, File: file}, nil
+	}
+}
@@ -0,0 +1,70 @@
+package parsecache
+
+import (
+	"errors"
+	"go/parser"
+	"go/scanner"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGoFileParser(t *testing.T) {
+	dir, err := os.MkdirTemp("", "parsecache-test-gofile-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	os.WriteFile(filepath.Join(dir, "a.go"), []byte("package example\n\n// A is a function.\nfunc A() {}\n"), 0660)
+	os.WriteFile(filepath.Join(dir, "b.go"), []byte("package example\n\nvar B = 1\n\ntype C struct{}\n"), 0660)
+	os.WriteFile(filepath.Join(dir, "bad.go"), []byte("package example\n\nfunc {\n"), 0660)
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# example\n"), 0660)
+
+	cache := NewFsCache[GoFile](os.DirFS(dir), nil, time.Minute, WithPathParser(GoFileParser(parser.ParseComments)))
+	entries, err := cache.GetDir("/")
+	if err != nil {
+		panic(err)
+	}
+
+	decls := map[string]int{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+		parsed, err := cache.GetFile(entry.Name())
+		if entry.Name() == "bad.go" {
+			var pathErr *fs.PathError
+			var scanErrs scanner.ErrorList
+			if !errors.As(err, &pathErr) || pathErr.Path != "/bad.go" || !errors.As(err, &scanErrs) {
+				t.Errorf("bad.go didn't fail with a wrapped scanner.ErrorList: %v", err)
+			} else if scanErrs[0].Pos.Filename != "/bad.go" || scanErrs[0].Pos.Line != 3 {
+				t.Errorf("bad.go error at the wrong position: %v", scanErrs[0].Pos)
+			}
+			continue
+		}
+		if err != nil {
+			panic(err)
+		}
+		if parsed.File.Name.Name != "example" {
+			t.Errorf("%s package not parsed correctly", entry.Name())
+		}
+		decls[entry.Name()] = len(parsed.File.Decls)
+	}
+	if len(decls) != 2 || decls["a.go"] != 1 || decls["b.go"] != 2 {
+		t.Errorf("Go files not parsed correctly: %v", decls)
+	}
+
+	a, err := cache.GetFile("a.go")
+	if err != nil {
+		panic(err)
+	}
+	if len(a.File.Comments) != 1 {
+		t.Error("a.go comments not parsed")
+	}
+	if position := a.Fset.Position(a.File.Decls[0].Pos()); position.Filename != "/a.go" || position.Line != 4 {
+		t.Errorf("a.go declaration at the wrong position: %v", position)
+	}
+}
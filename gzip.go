@@ -0,0 +1,82 @@
+package parsecache
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"io"
+)
+
+// GzipError is returned by the parsers from `GzipParser` and `AutoGzipParser` when a file can't be
+// decompressed, as opposed to when the decompressed content can't be parsed.
+type GzipError struct {
+	Err error
+}
+
+func (err *GzipError) Error() string {
+	return "parsecache: gzip: " + err.Err.Error()
+}
+
+func (err *GzipError) Unwrap() error {
+	return err.Err
+}
+
+// gzipReader wraps the errors from reading a `gzip.Reader` in a `*GzipError`, so that they're
+// distinguishable when they're returned by a parser.
+type gzipReader struct {
+	r *gzip.Reader
+}
+
+func (r gzipReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF {
+		err = &GzipError{err}
+	}
+	return n, err
+}
+
+// GzipParser returns a `Parser` which decompresses a gzipped file and parses the decompressed
+// content with `inner`.
+//
+// Errors from decompressing the file are returned as a `*GzipError`, unless `inner` replaces the
+// read error with one of its own. The file is decompressed to the end, even if `inner` doesn't
+// read all of it, so that a corrupt file is always detected by its checksum.
+func GzipParser[T any](inner Parser[T]) Parser[T] {
+	return func(f io.Reader) (T, error) {
+		var parsed T
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return parsed, &GzipError{err}
+		}
+		defer zr.Close()
+		r := gzipReader{zr}
+		parsed, err = inner(r)
+		if err != nil {
+			return parsed, err
+		}
+		_, err = io.Copy(io.Discard, r)
+		return parsed, err
+	}
+}
+
+// gzipMagic is the first two bytes of gzipped content.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// AutoGzipParser returns a `Parser` which parses a file with `inner`, like `GzipParser`, if it's
+// gzipped, or parses the file with `inner` directly if it isn't. Gzipped files are detected by the
+// two bytes at the start of the file, rather than by their names.
+func AutoGzipParser[T any](inner Parser[T]) Parser[T] {
+	gzipped := GzipParser(inner)
+	return func(f io.Reader) (T, error) {
+		r := bufio.NewReader(f)
+		magic, err := r.Peek(len(gzipMagic))
+		if err != nil && !errors.Is(err, io.EOF) {
+			var zero T
+			return zero, err
+		}
+		if string(magic) == string(gzipMagic) {
+			return gzipped(r)
+		}
+		return inner(r)
+	}
+}
@@ -0,0 +1,83 @@
+package parsecache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// gzipped returns `content` compressed with gzip.
+func gzipped(content []byte) []byte {
+	var compressed bytes.Buffer
+	w := gzip.NewWriter(&compressed)
+	_, err := w.Write(content)
+	if err != nil {
+		panic(err)
+	}
+	err = w.Close()
+	if err != nil {
+		panic(err)
+	}
+	return compressed.Bytes()
+}
+
+func TestGzipParser(t *testing.T) {
+	content := gzipped([]byte(`{"Hello": "world"}`))
+	corrupt := append([]byte{}, content...)
+	// Change the CRC-32 in the gzip footer.
+	corrupt[len(corrupt)-8] ^= 0xff
+	filesystem := fstest.MapFS{
+		"a.json.gz":       &fstest.MapFile{Data: content},
+		"plain.json":      &fstest.MapFile{Data: []byte(`{"Hello": "plain"}`)},
+		"empty.json":      &fstest.MapFile{},
+		"corrupt.json.gz": &fstest.MapFile{Data: corrupt},
+		"bad.json.gz":     &fstest.MapFile{Data: gzipped([]byte(`{"Hello": nope}`))},
+	}
+
+	cache := NewFsCache(filesystem, GzipParser(JsonParser[testFileStructure]), time.Minute)
+	a, err := cache.GetFile("a.json.gz")
+	if err != nil {
+		panic(err)
+	}
+	if a.Hello != "world" {
+		t.Error("a.json.gz not parsed correctly")
+	}
+
+	var gzipErr *GzipError
+	_, err = cache.GetFile("plain.json")
+	if !errors.As(err, &gzipErr) || !errors.Is(err, gzip.ErrHeader) {
+		t.Errorf("plain.json didn't fail with a GzipError: %v", err)
+	}
+	_, err = cache.GetFile("corrupt.json.gz")
+	if !errors.As(err, &gzipErr) || !errors.Is(err, gzip.ErrChecksum) {
+		t.Errorf("corrupt.json.gz didn't fail with a GzipError: %v", err)
+	}
+	_, err = cache.GetFile("bad.json.gz")
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &gzipErr) || !errors.As(err, &syntaxErr) {
+		t.Errorf("bad.json.gz didn't fail with a SyntaxError: %v", err)
+	}
+
+	auto := NewConcurrentFsCache(filesystem, AutoGzipParser(JsonParser[testFileStructure]), time.Minute)
+	for file, expected := range map[string]string{"a.json.gz": "world", "plain.json": "plain"} {
+		parsed, err := auto.GetFile(file)
+		if err != nil {
+			panic(err)
+		}
+		if parsed.Hello != expected {
+			t.Errorf("%s not parsed correctly", file)
+		}
+	}
+	_, err = auto.GetFile("empty.json")
+	if err == nil || errors.As(err, &gzipErr) {
+		t.Errorf("empty.json didn't fail to parse as JSON: %v", err)
+	}
+	_, err = auto.GetFile("corrupt.json.gz")
+	if !errors.As(err, &gzipErr) {
+		t.Errorf("corrupt.json.gz didn't fail with a GzipError: %v", err)
+	}
+}
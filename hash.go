@@ -0,0 +1,116 @@
+package parsecache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"hash/crc32"
+	"io"
+	"io/fs"
+)
+
+// contentHashTable is the CRC-32 table used by `WithContentHash`, Castagnoli's polynomial is used
+// since it's hardware accelerated on most platforms.
+var contentHashTable = crc32.MakeTable(crc32.Castagnoli)
+
+// WithContentHash revalidates files by a hash of their content, rather than by their modtime, so
+// that a file which is rewritten, with the same size, within the granularity of the filesystem's
+// modtimes is still seen to have changed, and a file which is touched without being changed isn't
+// parsed again.
+//
+// The hash is computed from the same opened file which is parsed. When a cached file of the same
+// size is revalidated, the whole file is read to hash it, instead of only being stat-ed, and if it
+// has changed, it's parsed by seeking back to the start of the file if it implements `io.Seeker`,
+// or from a copy of it in memory otherwise. Files of a different size are parsed immediately, and
+// hashed as they're parsed.
+func WithContentHash[T any]() Option[T] {
+	return func(o *options[T]) {
+		o.load.contentHash = true
+	}
+}
+
+// readerFile is an `fs.File` which is read from `r`, rather than from the file itself.
+type readerFile struct {
+	fs.File
+	r io.Reader
+}
+
+func (f readerFile) Read(p []byte) (int, error) {
+	return f.r.Read(p)
+}
+
+// contentHashValidator is the `Validator` used by `WithContentHash`: a file is unchanged if its
+// size and the hash of its content are. `Stamp` requires the file to implement `io.Seeker`. When a
+// file of a different size is revalidated, its token is stamped as it's parsed, rather than by
+// `Stamp`, see `loadHashedFile`.
+type contentHashValidator struct{}
+
+// sizeHash is the token of `contentHashValidator`.
+type sizeHash struct {
+	size int64
+	hash uint32
+}
+
+// errHashUnseekable is returned by `contentHashValidator.Stamp` for a file which isn't an
+// `io.Seeker`.
+var errHashUnseekable = errors.New("parsecache: can't hash a file which doesn't implement io.Seeker")
+
+func (contentHashValidator) Stamp(info fs.FileInfo, file fs.File) (any, error) {
+	seeker, ok := file.(io.Seeker)
+	if !ok {
+		return nil, errHashUnseekable
+	}
+	h := crc32.New(contentHashTable)
+	if _, err := io.Copy(h, file); err != nil {
+		return nil, err
+	}
+	if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return sizeHash{info.Size(), h.Sum32()}, nil
+}
+
+func (contentHashValidator) Same(old, new any) bool {
+	o, ok := old.(sizeHash)
+	return ok && o == new
+}
+
+// loadHashedFile completes `loadFile` for an opened and stat-ed `file`, with `WithContentHash`.
+// `load` is the load so far, and `sameSize` is true if the cache entry is loaded and its size
+// matches `load.size`, in which case the file is stamped by `contentHashValidator` and only parsed
+// if it isn't the same as when `lastToken` was stamped. `config` is the configuration of the load.
+func loadHashedFile[T any](ctx context.Context, src source, parser fileParser[T], file fs.File, stats fs.FileInfo, load fileLoad[T], sameSize bool, lastToken any, config loadConfig) (fileLoad[T], error) {
+	if !sameSize {
+		// Hash the file as it's parsed, and then hash whatever the parser didn't read.
+		h := crc32.New(contentHashTable)
+		r := io.TeeReader(file, h)
+		if err := parseSummed(ctx, src.path, parser, readerFile{file, r}, stats, &load, config); err != nil {
+			return load, src.wrapErr("parse", err)
+		}
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			return load, src.wrapErr("load", err)
+		}
+		load.token = sizeHash{load.size, h.Sum32()}
+		return load, nil
+	}
+
+	// Hash the whole file first, reading it into memory if it can't be read again.
+	if _, ok := file.(io.Seeker); !ok {
+		content, err := io.ReadAll(file)
+		if err != nil {
+			return load, src.wrapErr("load", err)
+		}
+		file = &bytesFile{Reader: bytes.NewReader(content), info: stats, content: content}
+	}
+	var err error
+	load.token, err = contentHashValidator{}.Stamp(stats, file)
+	if err != nil {
+		return load, src.wrapErr("load", err)
+	}
+	if (contentHashValidator{}).Same(lastToken, load.token) {
+		load.unchanged = true
+		return load, nil
+	}
+	err = parseSummed(ctx, src.path, parser, file, stats, &load, config)
+	return load, src.wrapErr("parse", err)
+}
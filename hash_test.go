@@ -0,0 +1,123 @@
+package parsecache
+
+import (
+	"io"
+	"io/fs"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// noSeekFS wraps a filesystem, hiding any methods of its files other than those of `fs.File`.
+type noSeekFS struct {
+	fs fs.FS
+}
+
+func (n noSeekFS) Open(name string) (fs.File, error) {
+	f, err := n.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return struct{ fs.File }{f}, nil
+}
+
+func contentHashTests(t *testing.T, cache testInterface, mapFS fstest.MapFS, parses func() int, maxAge time.Duration) {
+	modTime := time.Now().Add(-time.Hour)
+	a, err := cache.GetFile("a.json")
+	if err != nil || a.Number != 1 {
+		t.Errorf("a.json not parsed correctly: %v", err)
+	}
+
+	// Changing the content without changing the size or modtime is seen.
+	mapFS["a.json"] = &fstest.MapFile{Data: []byte(`{"Number": 2}`), ModTime: modTime}
+	time.Sleep(maxAge)
+	a, err = cache.GetFile("a.json")
+	if err != nil || a.Number != 2 {
+		t.Errorf("a.json change not seen: %+v, %v", a, err)
+	}
+
+	// Touching the file without changing it doesn't parse it again.
+	mapFS["a.json"] = &fstest.MapFile{Data: []byte(`{"Number": 2}`), ModTime: time.Now()}
+	time.Sleep(maxAge)
+	a, err = cache.GetFile("a.json")
+	if err != nil || a.Number != 2 {
+		t.Errorf("a.json not cached correctly: %+v, %v", a, err)
+	}
+	if parses() != 2 {
+		t.Errorf("a.json parsed %d times", parses())
+	}
+
+	// Files which change size are parsed immediately, and hashed as they're parsed.
+	mapFS["a.json"] = &fstest.MapFile{Data: []byte(`{"Number": 30}`), ModTime: modTime}
+	time.Sleep(maxAge)
+	a, err = cache.GetFile("a.json")
+	if err != nil || a.Number != 30 {
+		t.Errorf("a.json size change not seen: %+v, %v", a, err)
+	}
+	mapFS["a.json"] = &fstest.MapFile{Data: []byte(`{"Number": 30}`), ModTime: time.Now()}
+	time.Sleep(maxAge)
+	cache.GetFile("a.json")
+	if parses() != 3 {
+		t.Errorf("a.json parsed %d times", parses())
+	}
+}
+
+func TestContentHash(t *testing.T) {
+	maxAge := time.Second / 20
+	for _, concurrent := range []bool{false, true} {
+		for _, seekable := range []bool{false, true} {
+			mapFS := fstest.MapFS{
+				"a.json": &fstest.MapFile{Data: []byte(`{"Number": 1}`), ModTime: time.Now().Add(-time.Hour)},
+			}
+			var filesystem fs.FS = mapFS
+			if !seekable {
+				filesystem = noSeekFS{mapFS}
+			}
+			var lock sync.Mutex
+			count := 0
+			parser := func(f io.Reader) (testFileStructure, error) {
+				lock.Lock()
+				count++
+				lock.Unlock()
+				return JsonParser[testFileStructure](f)
+			}
+			parses := func() int {
+				lock.Lock()
+				defer lock.Unlock()
+				return count
+			}
+			opt := WithContentHash[testFileStructure]()
+			if concurrent {
+				cache := NewConcurrentFsCache(filesystem, parser, maxAge, opt)
+				contentHashTests(t, cache, mapFS, parses, maxAge)
+			} else {
+				cache := NewFsCache(filesystem, parser, maxAge, opt)
+				contentHashTests(t, &cache, mapFS, parses, maxAge)
+			}
+		}
+	}
+}
+
+func TestContentHashPartialRead(t *testing.T) {
+	modTime := time.Now().Add(-time.Hour)
+	mapFS := fstest.MapFS{
+		"a.txt": &fstest.MapFile{Data: []byte("abc"), ModTime: modTime},
+	}
+	parses := 0
+	// The parser only reads the first byte, so the rest of the file must be hashed after it's parsed.
+	parser := func(f io.Reader) (testFileStructure, error) {
+		parses++
+		first := make([]byte, 1)
+		_, err := io.ReadFull(f, first)
+		return testFileStructure{Hello: string(first)}, err
+	}
+	cache := NewFsCache(mapFS, parser, time.Second/20, WithContentHash[testFileStructure]())
+	cache.GetFile("a.txt")
+	mapFS["a.txt"] = &fstest.MapFile{Data: []byte("abd"), ModTime: modTime}
+	time.Sleep(time.Second / 20)
+	cache.GetFile("a.txt")
+	if parses != 2 {
+		t.Error("change after the part of the file read by the parser not seen")
+	}
+}
@@ -0,0 +1,104 @@
+// Package httpjson provides an `http.Handler` which serves the parsed content of the files in a
+// parsecache cache as JSON, with caching headers derived from the cache entries.
+package httpjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/JOT85/parsecache"
+)
+
+// Option configures the handler returned by `NewHandler`.
+type Option func(*options)
+
+// options is the configuration set by `Option`s.
+type options struct {
+	// cacheControl, if set, is the Cache-Control header of successful responses.
+	cacheControl string
+	// onError, if set, is called with the errors which are served as internal server errors.
+	onError func(r *http.Request, err error)
+}
+
+// WithCacheControl sets the Cache-Control header of successful responses to `value`, such as
+// "max-age=60". By default, it isn't set.
+func WithCacheControl(value string) Option {
+	return func(o *options) {
+		o.cacheControl = value
+	}
+}
+
+// WithErrorHandler calls `onError` with each error which is served as an internal server error,
+// such as a file which failed to parse, since the response only says that an error occurred.
+func WithErrorHandler(onError func(r *http.Request, err error)) Option {
+	return func(o *options) {
+		o.onError = onError
+	}
+}
+
+// NewHandler returns an `http.Handler` which serves the content of the file at each URL path under
+// `prefix`, with `prefix` removed, got from `cache`, encoded as JSON. So, with the prefix "/config/",
+// "/config/app.json" serves the content of "app.json".
+//
+// The responses have a Last-Modified header of the modtime of the file when it was last loaded,
+// and an ETag derived from its size and modtime, and conditional requests with If-Modified-Since or
+// If-None-Match are answered with 304 Not Modified. Paths outside of `prefix`, files which don't
+// exist and directories are served as 404 Not Found, and other errors, such as files which fail to
+// parse, as 500 Internal Server Error, without the details of the error. Only GET and HEAD requests
+// are allowed.
+func NewHandler[T any](cache *parsecache.ConcurrentFsCache[T], prefix string, opts ...Option) http.Handler {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !strings.HasPrefix(r.URL.Path, prefix) {
+			http.NotFound(w, r)
+			return
+		}
+		path := strings.TrimPrefix(r.URL.Path, prefix)
+
+		content, err := cache.GetFile(path)
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, parsecache.ErrIsADirectory) {
+			http.NotFound(w, r)
+			return
+		}
+		var body []byte
+		if err == nil {
+			body, err = json.Marshal(content)
+		}
+		if err != nil {
+			if o.onError != nil {
+				o.onError(r, err)
+			}
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if o.cacheControl != "" {
+			w.Header().Set("Cache-Control", o.cacheControl)
+		}
+		entry, ok := cache.GetFileEntry(path)
+		if !ok {
+			// The entry was cleared or evicted since it was loaded, so it's served without being
+			// validated.
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(body))
+			return
+		}
+		modTime := entry.ModTime()
+		w.Header().Set("ETag", fmt.Sprintf(`W/"%x-%x"`, entry.Size(), modTime.UnixNano()))
+		http.ServeContent(w, r, "", modTime, bytes.NewReader(body))
+	})
+}
@@ -0,0 +1,88 @@
+package httpjson
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/JOT85/parsecache"
+)
+
+type testFileStructure struct {
+	Hello string
+}
+
+func TestHandler(t *testing.T) {
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	filesystem := fstest.MapFS{
+		"a.json":       &fstest.MapFile{Data: []byte(`{"Hello": "a"}`), ModTime: modTime},
+		"invalid.json": &fstest.MapFile{Data: []byte(`{"Hello": `), ModTime: modTime},
+		"dir/b.json":   &fstest.MapFile{Data: []byte(`{"Hello": "b"}`), ModTime: modTime},
+	}
+	cache := parsecache.NewConcurrentFsCache(filesystem, parsecache.JsonParser[testFileStructure], time.Minute)
+	var errs []error
+	handler := NewHandler(cache, "/config/", WithCacheControl("max-age=60"), WithErrorHandler(func(r *http.Request, err error) {
+		errs = append(errs, err)
+	}))
+	serve := func(method, path string, header http.Header) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		r := httptest.NewRequest(method, path, nil)
+		for key, values := range header {
+			r.Header[key] = values
+		}
+		handler.ServeHTTP(recorder, r)
+		return recorder
+	}
+
+	hit := serve("GET", "/config/a.json", nil)
+	if hit.Code != http.StatusOK || strings.TrimSpace(hit.Body.String()) != `{"Hello":"a"}` {
+		t.Errorf("incorrect response for a.json: %d %q", hit.Code, hit.Body.String())
+	}
+	if hit.Header().Get("Content-Type") != "application/json" || hit.Header().Get("Cache-Control") != "max-age=60" {
+		t.Errorf("incorrect headers: %v", hit.Header())
+	}
+	if hit.Header().Get("Last-Modified") != modTime.UTC().Format(http.TimeFormat) {
+		t.Errorf("incorrect Last-Modified: %q", hit.Header().Get("Last-Modified"))
+	}
+	etag := hit.Header().Get("ETag")
+	if etag == "" {
+		t.Error("no ETag")
+	}
+	if b := serve("GET", "/config/dir/b.json", nil); b.Code != http.StatusOK || strings.TrimSpace(b.Body.String()) != `{"Hello":"b"}` {
+		t.Errorf("incorrect response for dir/b.json: %d %q", b.Code, b.Body.String())
+	}
+
+	if r := serve("GET", "/config/a.json", http.Header{"If-None-Match": {etag}}); r.Code != http.StatusNotModified || r.Body.Len() != 0 {
+		t.Errorf("If-None-Match not answered with 304: %d", r.Code)
+	}
+	if r := serve("GET", "/config/a.json", http.Header{"If-None-Match": {`W/"other"`}}); r.Code != http.StatusOK {
+		t.Errorf("mismatched If-None-Match not answered with 200: %d", r.Code)
+	}
+	if r := serve("GET", "/config/a.json", http.Header{"If-Modified-Since": {modTime.UTC().Format(http.TimeFormat)}}); r.Code != http.StatusNotModified {
+		t.Errorf("If-Modified-Since not answered with 304: %d", r.Code)
+	}
+	before := modTime.Add(-time.Minute).UTC().Format(http.TimeFormat)
+	if r := serve("GET", "/config/a.json", http.Header{"If-Modified-Since": {before}}); r.Code != http.StatusOK {
+		t.Errorf("older If-Modified-Since not answered with 200: %d", r.Code)
+	}
+
+	for _, path := range []string{"/config/missing.json", "/config/dir", "/other/a.json"} {
+		if r := serve("GET", path, nil); r.Code != http.StatusNotFound {
+			t.Errorf("%s not answered with 404: %d", path, r.Code)
+		}
+	}
+	if r := serve("POST", "/config/a.json", nil); r.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST not answered with 405: %d", r.Code)
+	}
+
+	invalid := serve("GET", "/config/invalid.json", nil)
+	if invalid.Code != http.StatusInternalServerError || strings.Contains(invalid.Body.String(), "invalid.json") {
+		t.Errorf("incorrect response for invalid.json: %d %q", invalid.Code, invalid.Body.String())
+	}
+	if len(errs) != 1 {
+		t.Errorf("expected 1 error to be handled, got %v", errs)
+	}
+}
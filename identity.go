@@ -0,0 +1,27 @@
+package parsecache
+
+// WithFileIdentity also revalidates files by their identity on the platform, when the filesystem
+// provides it through `fs.FileInfo.Sys`, as the `os` package does. On Unix, that's the inode number
+// and the ctime (the time the inode last changed) of the file, and on Windows it's the creation time
+// of the file. This catches a file which is rewritten within the granularity of the filesystem's
+// modtimes without changing its size, and a file which is atomically replaced (by renaming another
+// file over it) with one which has an older modtime.
+//
+// Files whose `Sys` isn't recognized, such as those of an `fstest.MapFS` or an `embed.FS`, are
+// revalidated by their size and modtime only. It has no effect with `WithContentHash`, which
+// revalidates files by their hash instead.
+func WithFileIdentity[T any]() Option[T] {
+	return func(o *options[T]) {
+		o.load.fileIdentity = true
+	}
+}
+
+// fileID is the platform-specific identity of a file, used by `WithFileIdentity`. It's the zero
+// value if it's not known.
+type fileID struct {
+	// ino is the inode number of the file, or 0 if the platform doesn't have them.
+	ino uint64
+	// changed is the ctime of the file on Unix, or its creation time on Windows, in nanoseconds since
+	// the Unix epoch.
+	changed int64
+}
@@ -0,0 +1,16 @@
+package parsecache
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileIdentity returns the identity of the file described by `info`, from the inode number and
+// ctime of its `syscall.Stat_t`.
+func fileIdentity(info fs.FileInfo) fileID {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}
+	}
+	return fileID{ino: stat.Ino, changed: stat.Ctim.Nano()}
+}
@@ -0,0 +1,57 @@
+package parsecache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileIdentity(t *testing.T) {
+	maxAge := time.Second / 20
+	for _, identity := range []bool{false, true} {
+		dir, err := os.MkdirTemp("", "parsecache-test-identity-*")
+		if err != nil {
+			panic(err)
+		}
+		defer os.RemoveAll(dir)
+		modTime := time.Now().Add(-time.Hour)
+		write := func(name, content string) {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte(content), 0660); err != nil {
+				panic(err)
+			}
+			if err := os.Chtimes(path, modTime, modTime); err != nil {
+				panic(err)
+			}
+		}
+		write("a.json", `{"Number": 1}`)
+
+		var opts []Option[testFileStructure]
+		if identity {
+			opts = append(opts, WithFileIdentity[testFileStructure]())
+		}
+		cache := NewConcurrentFsCache(os.DirFS(dir), JsonParser[testFileStructure], maxAge, opts...)
+		a, err := cache.GetFile("a.json")
+		if err != nil || a.Number != 1 {
+			t.Errorf("a.json not parsed correctly: %v", err)
+		}
+
+		// Atomically replace the file with one of the same size and modtime.
+		write("a.json.new", `{"Number": 2}`)
+		if err := os.Rename(filepath.Join(dir, "a.json.new"), filepath.Join(dir, "a.json")); err != nil {
+			panic(err)
+		}
+		time.Sleep(maxAge)
+		a, err = cache.GetFile("a.json")
+		if err != nil {
+			panic(err)
+		}
+		if identity && a.Number != 2 {
+			t.Error("replaced a.json not seen with WithFileIdentity")
+		}
+		if !identity && a.Number != 1 {
+			t.Error("replaced a.json seen without WithFileIdentity")
+		}
+	}
+}
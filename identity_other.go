@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !windows
+
+package parsecache
+
+import "io/fs"
+
+// fileIdentity returns the zero `fileID`, since the identity of files isn't known on this platform,
+// so they're revalidated by their size and modtime only.
+func fileIdentity(info fs.FileInfo) fileID {
+	return fileID{}
+}
@@ -0,0 +1,35 @@
+package parsecache
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestFileIdentityFallback(t *testing.T) {
+	maxAge := time.Second / 20
+	modTime := time.Now().Add(-time.Hour)
+	mapFS := fstest.MapFS{
+		"a.json": &fstest.MapFile{Data: []byte(`{"Number": 1}`), ModTime: modTime},
+	}
+	filesystem := &countingFS{fs: mapFS}
+	cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], maxAge, WithFileIdentity[testFileStructure]())
+	cache.GetFile("a.json")
+
+	// Without a recognized `Sys`, only the size and modtime are compared.
+	mapFS["a.json"] = &fstest.MapFile{Data: []byte(`{"Number": 2}`), ModTime: modTime}
+	time.Sleep(maxAge)
+	a, err := cache.GetFile("a.json")
+	if err != nil || a.Number != 1 {
+		t.Errorf("a.json not revalidated by its size and modtime: %+v, %v", a, err)
+	}
+	mapFS["a.json"] = &fstest.MapFile{Data: []byte(`{"Number": 2}`), ModTime: time.Now()}
+	time.Sleep(maxAge)
+	a, err = cache.GetFile("a.json")
+	if err != nil || a.Number != 2 {
+		t.Errorf("a.json change not seen: %+v, %v", a, err)
+	}
+	if filesystem.Opens() != 3 {
+		t.Errorf("a.json opened %d times", filesystem.Opens())
+	}
+}
@@ -0,0 +1,16 @@
+package parsecache
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileIdentity returns the identity of the file described by `info`, from the creation time of its
+// `syscall.Win32FileAttributeData`. Windows doesn't report file IDs through `os.Stat`.
+func fileIdentity(info fs.FileInfo) fileID {
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return fileID{}
+	}
+	return fileID{changed: data.CreationTime.Nanoseconds()}
+}
@@ -0,0 +1,41 @@
+package parsecache
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io"
+)
+
+// ImageInfo is the configuration of an image, along with its format.
+type ImageInfo struct {
+	// Config is the color model and dimensions of the image.
+	Config image.Config
+	// Format is the name of the image's format, such as "png", as registered with
+	// `image.RegisterFormat`.
+	Format string
+}
+
+// ImageConfigParser is a `Parser` which decodes the color model and dimensions of an image, with
+// `image.DecodeConfig`, without decoding the entire image.
+//
+// No image formats are registered by this package, so the decoders for the required formats must
+// be registered, usually by importing their packages, for example:
+//
+//	import _ "image/png"
+//
+// Images in unregistered formats fail with an error wrapping `image.ErrFormat`.
+func ImageConfigParser(f io.Reader) (image.Config, error) {
+	info, err := ImageInfoParser(f)
+	return info.Config, err
+}
+
+// ImageInfoParser is a `Parser` which decodes the configuration of an image, like
+// `ImageConfigParser`, along with the name of its format.
+func ImageInfoParser(f io.Reader) (ImageInfo, error) {
+	config, format, err := image.DecodeConfig(f)
+	if errors.Is(err, image.ErrFormat) {
+		err = fmt.Errorf("parsecache: %w (the decoder for the image format must be registered, for example by importing image/png)", err)
+	}
+	return ImageInfo{Config: config, Format: format}, err
+}
@@ -0,0 +1,58 @@
+package parsecache
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestImageParsers(t *testing.T) {
+	dir, err := os.MkdirTemp("", "parsecache-test-image-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var pngData, jpegData bytes.Buffer
+	err = png.Encode(&pngData, image.NewRGBA(image.Rect(0, 0, 3, 2)))
+	if err != nil {
+		panic(err)
+	}
+	err = jpeg.Encode(&jpegData, image.NewGray(image.Rect(0, 0, 5, 4)), nil)
+	if err != nil {
+		panic(err)
+	}
+	os.WriteFile(filepath.Join(dir, "a.png"), pngData.Bytes(), 0660)
+	os.WriteFile(filepath.Join(dir, "b.jpg"), jpegData.Bytes(), 0660)
+	// The BMP decoder isn't in the standard library, so it's never registered.
+	os.WriteFile(filepath.Join(dir, "c.bmp"), []byte("BM\x3a\x00\x00\x00\x00\x00\x00\x00\x36\x00\x00\x00"), 0660)
+
+	configs := NewFsCache(os.DirFS(dir), ImageConfigParser, time.Minute)
+	config, err := configs.GetFile("a.png")
+	if err != nil {
+		panic(err)
+	}
+	if config.Width != 3 || config.Height != 2 {
+		t.Errorf("a.png dimensions not decoded correctly: %dx%d", config.Width, config.Height)
+	}
+
+	infos := NewConcurrentFsCache(os.DirFS(dir), ImageInfoParser, time.Minute)
+	info, err := infos.GetFile("b.jpg")
+	if err != nil {
+		panic(err)
+	}
+	if info.Format != "jpeg" || info.Config.Width != 5 || info.Config.Height != 4 {
+		t.Errorf("b.jpg not decoded correctly: %+v", info)
+	}
+
+	_, err = infos.GetFile("c.bmp")
+	if !errors.Is(err, image.ErrFormat) {
+		t.Errorf("c.bmp didn't fail with image.ErrFormat: %v", err)
+	}
+}
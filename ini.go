@@ -0,0 +1,182 @@
+package parsecache
+
+import (
+	"bufio"
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IniParser returns a `Parser` which parses an INI file into `T`, which must be a struct, using
+// `ParseIni`.
+func IniParser[T any]() Parser[T] {
+	return func(f io.Reader) (T, error) {
+		var parsed T
+		err := ParseIni(f, &parsed)
+		return parsed, err
+	}
+}
+
+// ParseIni parses an INI file from `r` into `v`, which must be a pointer to a struct.
+//
+// Keys before the first section are stored in the fields of the struct, and keys in a section are
+// stored in the fields of the struct field for the section. A field is matched by its `ini` tag, if
+// it has one, otherwise by its name, ignoring case; a tag of "-" skips the field. Sections and keys
+// without a matching field are ignored, and so are comment lines, starting with ';' or '#'.
+//
+// Values may be strings, booleans, integers, floats, `time.Duration`s or implement
+// `encoding.TextUnmarshaler`. A section may also be stored in a `map[string]string`, which gets
+// every key in the section. Values wrapped in double quotes are unquoted.
+func ParseIni(r io.Reader, v any) error {
+	root := reflect.ValueOf(v)
+	if root.Kind() != reflect.Pointer || root.IsNil() || root.Elem().Kind() != reflect.Struct {
+		return errors.New("parsecache: ParseIni requires a non-nil pointer to a struct")
+	}
+	section := root.Elem()
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == ';' || text[0] == '#' {
+			continue
+		}
+
+		if text[0] == '[' {
+			if text[len(text)-1] != ']' {
+				return fmt.Errorf("parsecache: ini line %d: unterminated section header", line)
+			}
+			name := strings.TrimSpace(text[1 : len(text)-1])
+			section = iniField(root.Elem(), name)
+			continue
+		}
+
+		i := strings.IndexAny(text, "=:")
+		if i < 0 {
+			return fmt.Errorf("parsecache: ini line %d: expected key = value", line)
+		}
+		key := strings.TrimSpace(text[:i])
+		value := strings.TrimSpace(text[i+1:])
+		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return fmt.Errorf("parsecache: ini line %d: %w", line, err)
+			}
+			value = unquoted
+		}
+
+		err := setIniValue(section, key, value)
+		if err != nil {
+			return fmt.Errorf("parsecache: ini line %d: key %q: %w", line, key, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// iniField returns the field of the struct `v` for the INI section or key `name`, or the zero Value
+// if there isn't one.
+func iniField(v reflect.Value, name string) reflect.Value {
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fieldName := field.Name
+		if tag, ok := field.Tag.Lookup("ini"); ok {
+			if tag == "-" {
+				continue
+			}
+			fieldName = tag
+		}
+		if strings.EqualFold(fieldName, name) {
+			return v.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+// setIniValue stores `value` for `key` in `section`, which is the struct or map for the current
+// section, or the zero Value if the section is ignored.
+func setIniValue(section reflect.Value, key, value string) error {
+	switch section.Kind() {
+	case reflect.Map:
+		if section.Type().Key().Kind() != reflect.String || section.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported section type %s", section.Type())
+		}
+		if section.IsNil() {
+			section.Set(reflect.MakeMap(section.Type()))
+		}
+		section.SetMapIndex(reflect.ValueOf(key).Convert(section.Type().Key()), reflect.ValueOf(value).Convert(section.Type().Elem()))
+		return nil
+	case reflect.Struct:
+		field := iniField(section, key)
+		if !field.IsValid() {
+			return nil
+		}
+		return setIniField(field, value)
+	default:
+		return nil
+	}
+}
+
+// setIniField parses `value` into `field`.
+func setIniField(field reflect.Value, value string) error {
+	if field.Addr().Type().Implements(textUnmarshalerType) {
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 0, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 0, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
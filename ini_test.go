@@ -0,0 +1,90 @@
+package parsecache
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+type testIniStructure struct {
+	Name    string
+	Verbose bool `ini:"verbose"`
+	Server  struct {
+		Host    net.IP        `ini:"host"`
+		Port    uint16        `ini:"port"`
+		Timeout time.Duration `ini:"timeout"`
+		Ratio   float64
+	} `ini:"server"`
+	Env     map[string]string `ini:"env"`
+	Ignored string            `ini:"-"`
+}
+
+const testIni = `
+; A comment
+name = "example app"
+verbose: true
+ignored = value
+
+[server]
+host = 127.0.0.1
+port = 8080
+timeout = 1m30s
+RATIO = 0.5
+
+# Another comment
+[env]
+PATH = /usr/bin
+HOME = /home/example
+
+[unknown]
+key = value
+`
+
+func TestParseIni(t *testing.T) {
+	var parsed testIniStructure
+	err := ParseIni(strings.NewReader(testIni), &parsed)
+	if err != nil {
+		panic(err)
+	}
+	if parsed.Name != "example app" || !parsed.Verbose || parsed.Ignored != "" {
+		t.Error("top level keys not parsed correctly")
+	}
+	if !parsed.Server.Host.Equal(net.IPv4(127, 0, 0, 1)) || parsed.Server.Port != 8080 || parsed.Server.Timeout != 90*time.Second || parsed.Server.Ratio != 0.5 {
+		t.Error("section not parsed correctly")
+	}
+	if len(parsed.Env) != 2 || parsed.Env["PATH"] != "/usr/bin" || parsed.Env["HOME"] != "/home/example" {
+		t.Error("map section not parsed correctly")
+	}
+
+	for _, invalid := range []string{
+		"[server",
+		"just a line",
+		"[server]\nport = not a number",
+		"verbose = maybe",
+	} {
+		err := ParseIni(strings.NewReader(invalid), &parsed)
+		if err == nil {
+			t.Errorf("invalid ini parsed without an error: %q", invalid)
+		}
+	}
+
+	if ParseIni(strings.NewReader(testIni), parsed) == nil {
+		t.Error("ParseIni doesn't require a pointer")
+	}
+}
+
+func TestIniParser(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"app.ini": &fstest.MapFile{Data: []byte(testIni)},
+	}
+	cache := NewFsCache(filesystem, IniParser[testIniStructure](), time.Minute)
+	parsed, err := cache.GetFile("app.ini")
+	if err != nil {
+		panic(err)
+	}
+	if parsed.Name != "example app" || parsed.Server.Port != 8080 {
+		t.Error("app.ini not parsed correctly")
+	}
+}
@@ -0,0 +1,39 @@
+package parsecache
+
+import "time"
+
+// Invalidate marks the entry as stale, so the next get revalidates it, whatever its age, rather
+// than returning it from memory. Unlike removing the entry from the cache, the entry and its content
+// are kept, so it's only parsed again if the file has changed.
+func (f *CachedFile[T]) Invalidate() {
+	f.stale = true
+	// Forget that the file didn't exist, if `WithNegativeCacheTTL` remembered it.
+	f.notExistAt = time.Time{}
+}
+
+// Invalidate marks the entry as stale, like `CachedFile.Invalidate`.
+func (f *ConcurrentCachedFile[T]) Invalidate() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.cachedFile.Invalidate()
+}
+
+// Invalidate marks the entry as stale, so the next get revalidates it, whatever its age, rather
+// than returning it from memory. Unlike removing the entry from the cache, the entry and its
+// entries are kept, so the directory is only read again if it has changed.
+func (f *CachedDir) Invalidate() {
+	f.stale = true
+}
+
+// Invalidate marks the entry as stale, like `CachedDir.Invalidate`.
+func (f *ConcurrentCachedDir) Invalidate() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.cachedDir.Invalidate()
+}
+
+// fresh returns true if the entry was loaded less than `maxAge`, offset by `WithTTLJitter`, before
+// `now`, and hasn't been invalidated since.
+func (f *CachedDir) fresh(now time.Time, maxAge time.Duration) bool {
+	return !f.stale && !f.lastLoadTime.IsZero() && now.Sub(f.lastLoadTime) < withTTLOffset(maxAge, f.ttlOffset)
+}
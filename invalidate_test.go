@@ -0,0 +1,86 @@
+package parsecache
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestInvalidate(t *testing.T) {
+	modTime := time.Now().Add(-time.Hour)
+	mapFS := fstest.MapFS{
+		"a.json":    &fstest.MapFile{Data: []byte(`{"Number": 1}`), ModTime: modTime},
+		"dir":       &fstest.MapFile{Mode: fs.ModeDir, ModTime: modTime},
+		"dir/b.txt": &fstest.MapFile{ModTime: modTime},
+	}
+	filesystem := &countingFS{fs: mapFS}
+	for _, concurrent := range []bool{false, true} {
+		var cache testInterface
+		var invalidateFile, invalidateDir func()
+		if concurrent {
+			c := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+			cache = c
+			invalidateFile = func() {
+				entry, _ := c.GetFileEntry("a.json")
+				entry.Invalidate()
+			}
+			invalidateDir = func() {
+				entry, _ := c.GetDirEntry("dir")
+				entry.Invalidate()
+			}
+		} else {
+			c := NewFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+			cache = &c
+			invalidateFile = func() {
+				entry, _ := c.GetFileEntry("a.json")
+				entry.Invalidate()
+			}
+			invalidateDir = func() {
+				entry, _ := c.GetDirEntry("dir")
+				entry.Invalidate()
+			}
+		}
+		mapFS["a.json"] = &fstest.MapFile{Data: []byte(`{"Number": 1}`), ModTime: modTime}
+		delete(mapFS, "dir/c.txt")
+		if _, err := cache.GetFile("a.json"); err != nil {
+			panic(err)
+		}
+		if _, err := cache.GetDir("dir"); err != nil {
+			panic(err)
+		}
+
+		// An invalidated entry of an unchanged file is revalidated, but kept.
+		opens := filesystem.Opens()
+		invalidateFile()
+		a, err := cache.GetFile("a.json")
+		if err != nil || a.Number != 1 || filesystem.Opens() != opens+1 {
+			t.Errorf("concurrent=%v: unchanged a.json not revalidated: %+v, %v", concurrent, a, err)
+		}
+		a, err = cache.GetFile("a.json")
+		if err != nil || a.Number != 1 || filesystem.Opens() != opens+1 {
+			t.Errorf("concurrent=%v: a.json not cached after revalidation: %+v, %v", concurrent, a, err)
+		}
+
+		// Changes are seen once the entries are invalidated.
+		mapFS["a.json"] = &fstest.MapFile{Data: []byte(`{"Number": 2}`), ModTime: time.Now()}
+		mapFS["dir/c.txt"] = &fstest.MapFile{ModTime: modTime}
+		mapFS["dir"] = &fstest.MapFile{Mode: fs.ModeDir, ModTime: time.Now()}
+		a, _ = cache.GetFile("a.json")
+		entries, _ := cache.GetDir("dir")
+		if a.Number != 1 || len(entries) != 1 {
+			t.Errorf("concurrent=%v: entries revalidated before they were invalidated", concurrent)
+		}
+		invalidateFile()
+		invalidateDir()
+		a, err = cache.GetFile("a.json")
+		if err != nil || a.Number != 2 {
+			t.Errorf("concurrent=%v: changed a.json not reparsed: %+v, %v", concurrent, a, err)
+		}
+		entries, err = cache.GetDir("dir")
+		if err != nil || len(entries) != 2 {
+			t.Errorf("concurrent=%v: changed dir not reread: %d entries, %v", concurrent, len(entries), err)
+		}
+		mapFS["dir"] = &fstest.MapFile{Mode: fs.ModeDir, ModTime: modTime}
+	}
+}
@@ -0,0 +1,44 @@
+package parsecache
+
+import (
+	"math/rand"
+	"time"
+)
+
+// WithTTLJitter randomly offsets the maximum age of each entry by up to `jitter` either way, with a
+// new offset chosen each time the entry is loaded or revalidated, so entries which are loaded
+// together, such as when a server starts, don't all expire, and get revalidated, at the same time.
+//
+// The maximum age of an entry is never made negative, and unlimited and non-positive maximum ages,
+// and the maximum ages set by `WithAdaptiveTTL`, aren't offset.
+func WithTTLJitter[T any](jitter time.Duration) Option[T] {
+	return func(o *options[T]) {
+		o.load.ttlJitter = jitter
+	}
+}
+
+// ttlOffset returns a random offset of the maximum age of an entry, within `ttlJitter` either way,
+// or 0 if `WithTTLJitter` isn't used.
+func (config loadConfig) ttlOffset() time.Duration {
+	if config.ttlJitter <= 0 {
+		return 0
+	}
+	if config.ttlJitter > forever/2 {
+		config.ttlJitter = forever / 2
+	}
+	return time.Duration(rand.Int63n(int64(2*config.ttlJitter)+1)) - config.ttlJitter
+}
+
+// withTTLOffset returns `maxAge` offset by `offset`, but never negative, unless `maxAge` isn't
+// positive or is unlimited.
+func withTTLOffset(maxAge, offset time.Duration) time.Duration {
+	switch {
+	case offset == 0 || maxAge <= 0 || maxAge == forever:
+		return maxAge
+	case offset > 0 && maxAge > forever-offset:
+		return forever
+	case maxAge+offset < 0:
+		return 0
+	}
+	return maxAge + offset
+}
@@ -0,0 +1,58 @@
+package parsecache
+
+import (
+	"strconv"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestTTLJitter(t *testing.T) {
+	filesystem := fstest.MapFS{}
+	for i := 0; i < 20; i++ {
+		filesystem["dir/"+strconv.Itoa(i)+".json"] = &fstest.MapFile{Data: []byte(`{}`)}
+	}
+	jitter := time.Minute
+	cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Hour, WithTTLJitter[testFileStructure](jitter))
+	offsets := make(map[time.Duration]bool)
+	for i := 0; i < 20; i++ {
+		path := "dir/" + strconv.Itoa(i) + ".json"
+		if _, err := cache.GetFile(path); err != nil {
+			panic(err)
+		}
+		entry, _ := cache.GetFileEntry(path)
+		offset := entry.cachedFile.ttlOffset
+		if offset < -jitter || offset > jitter {
+			t.Errorf("%s has an offset of %v, outside the jitter", path, offset)
+		}
+		offsets[offset] = true
+	}
+	if len(offsets) < 2 {
+		t.Error("all files have the same offset")
+	}
+
+	if _, err := cache.GetDir("dir"); err != nil {
+		panic(err)
+	}
+	dir, _ := cache.GetDirEntry("dir")
+	if offset := dir.cachedDir.ttlOffset; offset < -jitter || offset > jitter {
+		t.Errorf("dir has an offset of %v, outside the jitter", offset)
+	}
+}
+
+func TestWithTTLOffset(t *testing.T) {
+	for _, test := range []struct {
+		maxAge, offset, expected time.Duration
+	}{
+		{time.Minute, time.Second, time.Minute + time.Second},
+		{time.Minute, -time.Second, time.Minute - time.Second},
+		{time.Second, -time.Minute, 0},
+		{0, time.Second, 0},
+		{forever, -time.Second, forever},
+		{forever - time.Second, time.Minute, forever},
+	} {
+		if maxAge := withTTLOffset(test.maxAge, test.offset); maxAge != test.expected {
+			t.Errorf("%v offset by %v is %v, expected %v", test.maxAge, test.offset, maxAge, test.expected)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package parsecache
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJsonParserTrailingData(t *testing.T) {
+	parsed, err := JsonParser[testFileStructure](strings.NewReader("{\"Hello\": \"world!\"}\n\t \n"))
+	if err != nil || parsed.Hello != "world!" {
+		t.Errorf("trailing whitespace not allowed: %v", err)
+	}
+	for _, content := range []string{`{"Hello": "a"}{"Hello": "b"}`, `{"Hello": "a"} x`, `{"Hello": "a"}}`} {
+		if _, err := JsonParser[testFileStructure](strings.NewReader(content)); err == nil {
+			t.Errorf("trailing data in %q not rejected", content)
+		}
+		if _, err := JsonParserStrict[testFileStructure](strings.NewReader(content)); err == nil {
+			t.Errorf("trailing data in %q not rejected by JsonParserStrict", content)
+		}
+	}
+}
+
+func TestJsonParserStrict(t *testing.T) {
+	content := `{"Hello": "world!", "Numbr": 12}`
+	parsed, err := JsonParser[testFileStructure](strings.NewReader(content))
+	if err != nil || parsed.Hello != "world!" {
+		t.Errorf("unknown field not ignored by JsonParser: %v", err)
+	}
+	if _, err := JsonParserStrict[testFileStructure](strings.NewReader(content)); err == nil {
+		t.Error("unknown field not rejected by JsonParserStrict")
+	}
+	parsed, err = JsonParserStrict[testFileStructure](strings.NewReader(`{"Hello": "world!", "Number": 12}`))
+	if err != nil || parsed.Number != 12 {
+		t.Errorf("known fields not parsed by JsonParserStrict: %v", err)
+	}
+}
+
+func TestJsonParserUseNumber(t *testing.T) {
+	content := `{"big": 12345678901234567890}`
+	parsed, err := JsonParserUseNumber[map[string]interface{}](strings.NewReader(content))
+	if err != nil {
+		panic(err)
+	}
+	if number, ok := parsed["big"].(json.Number); !ok || number.String() != "12345678901234567890" {
+		t.Errorf("number not decoded as a json.Number: %#v", parsed["big"])
+	}
+	parsed, err = JsonParser[map[string]interface{}](strings.NewReader(content))
+	if err != nil {
+		panic(err)
+	}
+	if _, ok := parsed["big"].(float64); !ok {
+		t.Errorf("number not decoded as a float64 by JsonParser: %#v", parsed["big"])
+	}
+}
+
+func TestJsonParserWith(t *testing.T) {
+	parser := JsonParserWith[map[string]interface{}](JsonOptions{DisallowUnknownFields: true, UseNumber: true})
+	parsed, err := parser(strings.NewReader(`{"a": 1}`))
+	if err != nil {
+		panic(err)
+	}
+	if _, ok := parsed["a"].(json.Number); !ok {
+		t.Error("UseNumber not applied")
+	}
+	type strict struct{ A int }
+	if _, err := JsonParserWith[strict](JsonOptions{DisallowUnknownFields: true})(strings.NewReader(`{"A": 1, "B": 2}`)); err == nil {
+		t.Error("DisallowUnknownFields not applied")
+	}
+}
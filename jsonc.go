@@ -0,0 +1,188 @@
+package parsecache
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// errJsoncUnterminatedComment is returned by the JSONC parsers when a file ends inside a /* */
+// comment.
+var errJsoncUnterminatedComment = errors.New("parsecache: jsonc: unterminated comment")
+
+// JsoncParser[T] is a value of type Parser[T] which parses a file as JSON with comments (JSONC), as
+// used by VS Code configuration files, for example. It's like `JsonParser`, but allows // and /* */
+// comments, and trailing commas in objects and arrays.
+//
+// Comments and trailing commas are replaced with spaces while the file is read, so the offsets in
+// syntax errors match the original file.
+func JsoncParser[T any](f io.Reader) (T, error) {
+	return JsoncParserWith[T](JsonOptions{})(f)
+}
+
+// JsoncParserWith returns a `Parser` which parses a file as JSON with comments, like `JsoncParser`,
+// with the given options.
+func JsoncParserWith[T any](opts JsonOptions) Parser[T] {
+	parser := JsonParserWith[T](opts)
+	return func(f io.Reader) (T, error) {
+		return parser(&jsoncReader{r: bufio.NewReader(f)})
+	}
+}
+
+// jsoncState is the position of a `jsoncReader` in the JSONC syntax.
+type jsoncState int
+
+const (
+	jsoncValue jsoncState = iota
+	jsoncString
+	jsoncStringEscape
+	// jsoncSlash is after a '/' which may start a comment.
+	jsoncSlash
+	jsoncLineComment
+	jsoncBlockComment
+	// jsoncBlockCommentStar is after a '*' in a block comment, which may end it.
+	jsoncBlockCommentStar
+)
+
+// jsoncReader converts JSONC to JSON as it's read, by replacing comments and trailing commas with
+// spaces. Newlines in comments are kept, so that line numbers are unchanged too.
+type jsoncReader struct {
+	r     *bufio.Reader
+	state jsoncState
+	// out is the converted content which is ready to be read.
+	out []byte
+	// pending is a comma and the whitespace after it, which are held until the next value shows
+	// whether the comma is a trailing comma.
+	pending []byte
+	err     error
+}
+
+func (r *jsoncReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 && r.err == nil {
+		r.fill()
+	}
+	if len(r.out) > 0 {
+		n := copy(p, r.out)
+		r.out = r.out[n:]
+		return n, nil
+	}
+	return 0, r.err
+}
+
+// fill converts up to a buffer's worth of content, setting `r.err` once the underlying reader
+// fails.
+func (r *jsoncReader) fill() {
+	r.out = r.out[:0]
+	for len(r.out) < 4096 {
+		c, err := r.r.ReadByte()
+		if err != nil {
+			switch r.state {
+			case jsoncSlash:
+				r.write('/', true)
+			case jsoncBlockComment, jsoncBlockCommentStar:
+				if err == io.EOF {
+					err = errJsoncUnterminatedComment
+				}
+			}
+			r.out = append(r.out, r.pending...)
+			r.pending = r.pending[:0]
+			r.err = err
+			return
+		}
+		r.convert(c)
+	}
+}
+
+// convert converts the next byte, `c`.
+func (r *jsoncReader) convert(c byte) {
+	switch r.state {
+	case jsoncValue:
+		switch c {
+		case '"':
+			r.state = jsoncString
+			r.write(c, true)
+		case '/':
+			r.state = jsoncSlash
+		case ',':
+			r.flush(c)
+			r.pending = append(r.pending, c)
+		case ' ', '\t', '\n', '\r':
+			r.write(c, false)
+		default:
+			r.write(c, true)
+		}
+	case jsoncString:
+		switch c {
+		case '\\':
+			r.state = jsoncStringEscape
+		case '"':
+			r.state = jsoncValue
+		}
+		r.write(c, true)
+	case jsoncStringEscape:
+		r.state = jsoncString
+		r.write(c, true)
+	case jsoncSlash:
+		switch c {
+		case '/':
+			r.state = jsoncLineComment
+		case '*':
+			r.state = jsoncBlockComment
+		default:
+			// It's not a comment, so it's invalid JSON, which is left for the decoder to report.
+			r.state = jsoncValue
+			r.write('/', true)
+			r.convert(c)
+			return
+		}
+		r.write(' ', false)
+		r.write(' ', false)
+	case jsoncLineComment:
+		if c == '\n' {
+			r.state = jsoncValue
+		}
+		r.writeComment(c)
+	case jsoncBlockComment, jsoncBlockCommentStar:
+		switch {
+		case c == '/' && r.state == jsoncBlockCommentStar:
+			r.state = jsoncValue
+		case c == '*':
+			r.state = jsoncBlockCommentStar
+		default:
+			r.state = jsoncBlockComment
+		}
+		r.writeComment(c)
+	}
+}
+
+// writeComment writes a byte of a comment, which is replaced by a space unless it's a newline.
+func (r *jsoncReader) writeComment(c byte) {
+	if c != '\n' {
+		c = ' '
+	}
+	r.write(c, false)
+}
+
+// write writes `c` to the output. `significant` is false for whitespace and comments, which don't
+// affect whether a pending comma is a trailing comma.
+func (r *jsoncReader) write(c byte, significant bool) {
+	if len(r.pending) > 0 && !significant {
+		r.pending = append(r.pending, c)
+		return
+	}
+	r.flush(c)
+	r.out = append(r.out, c)
+}
+
+// flush writes the pending comma, if there is one, given the significant byte after it, `next`. The
+// comma is replaced by a space if it's a trailing comma.
+func (r *jsoncReader) flush(next byte) {
+	if len(r.pending) == 0 {
+		return
+	}
+	if next == '}' || next == ']' {
+		r.pending[0] = ' '
+	}
+	r.out = append(r.out, r.pending...)
+	r.pending = r.pending[:0]
+}
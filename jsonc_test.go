@@ -0,0 +1,65 @@
+package parsecache
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestJsoncParser(t *testing.T) {
+	content := `// Settings
+{
+	/* The greeting, which isn't a // comment */
+	"Hello": "https://example.com/* not a comment */", // trailing
+	"Number": 12, /* a
+	multi-line ** comment */
+	"Float": 0.5,
+}
+`
+	for _, r := range []io.Reader{strings.NewReader(content), iotest.OneByteReader(strings.NewReader(content))} {
+		parsed, err := JsoncParser[testFileStructure](r)
+		if err != nil {
+			panic(err)
+		}
+		if parsed.Hello != "https://example.com/* not a comment */" || parsed.Number != 12 || parsed.Float != 0.5 {
+			t.Errorf("JSONC not parsed correctly: %+v", parsed)
+		}
+	}
+
+	list, err := JsoncParser[[]string](strings.NewReader(`["a", "b\",", "c" , // comment
+	]`))
+	if err != nil {
+		panic(err)
+	}
+	if !equalNames(list, []string{"a", `b",`, "c"}) {
+		t.Errorf("array not parsed correctly: %v", list)
+	}
+}
+
+func TestJsoncParserErrors(t *testing.T) {
+	// Offsets in syntax errors are those of the original file.
+	content := "{ /* comment */ \"Hello\": nope }"
+	_, err := JsoncParser[testFileStructure](strings.NewReader(content))
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("invalid JSONC didn't fail with a syntax error: %v", err)
+	}
+	jsonOffset := syntaxErr.Offset
+	_, err = JsonParser[testFileStructure](strings.NewReader("{               \"Hello\": nope }"))
+	if !errors.As(err, &syntaxErr) || syntaxErr.Offset != jsonOffset {
+		t.Errorf("syntax error at offset %d, expected %d", jsonOffset, syntaxErr.Offset)
+	}
+
+	for _, content := range []string{`{"Hello": "a",,}`, `[,]`, `{"Hello": "a"} /* unterminated`, `{"Hello": / "a"}`} {
+		if _, err := JsoncParser[testFileStructure](strings.NewReader(content)); err == nil {
+			t.Errorf("invalid JSONC %q didn't fail", content)
+		}
+	}
+	_, err = JsoncParser[testFileStructure](strings.NewReader(`{} /* unterminated`))
+	if !errors.Is(err, errJsoncUnterminatedComment) {
+		t.Errorf("unterminated comment didn't fail correctly: %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package parsecache
+
+import "strings"
+
+// WithKeyFunc keys the cache's entries by `keyFunc` of their normalized paths, rather than by the
+// paths themselves, so requests for different paths with the same key share an entry, and are only
+// parsed once. For example, `keyFunc` could strip a content hash from a file name, so
+// "/app.3fa9b2.js" and "/app.js" are the same entry, or normalize the Unicode of paths.
+//
+// Like `WithCaseInsensitiveKeys`, which folds the case of the key after `keyFunc`, it applies to
+// every way of getting or clearing an entry, including entries remembering that a file doesn't
+// exist, and `Entries` returns the keys. The path opened is still the normalized path as it was
+// given, unless `WithOpenPathFunc` is used, so an entry is loaded from the path of the first get to
+// load it successfully.
+func WithKeyFunc[T any](keyFunc func(cleaned string) string) Option[T] {
+	return func(o *options[T]) {
+		o.keyFunc = keyFunc
+	}
+}
+
+// WithOpenPathFunc opens `openPathFunc` of the normalized path of each entry, rather than the path
+// itself, for example to open "/app.js" for "/app.3fa9b2.js" along with `WithKeyFunc`. The path
+// returned is cleaned like any other path. The parser is still chosen by the normalized path, and the
+// circuit breaker and the filter of missing files still use it.
+func WithOpenPathFunc[T any](openPathFunc func(cleaned string) string) Option[T] {
+	return func(o *options[T]) {
+		o.openPathFunc = openPathFunc
+	}
+}
+
+// key returns the key of the entry for the normalized `path` in the cache's maps.
+func (o *options[T]) key(path string) string {
+	if o.keyFunc != nil {
+		path = o.keyFunc(path)
+	}
+	if o.caseInsensitiveKeys {
+		path = strings.ToLower(path)
+	}
+	return path
+}
+
+// openPath returns the path opened for the entry for the normalized `path`.
+func (o *options[T]) openPath(path string) string {
+	if o.openPathFunc != nil {
+		return cleanPath(o.openPathFunc(path))
+	}
+	return path
+}
@@ -0,0 +1,89 @@
+package parsecache
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// contentHashSuffix matches the content hash in a file name like "app.3fa9b2.js".
+var contentHashSuffix = regexp.MustCompile(`\.[0-9a-f]{6}(\.[a-z]+)$`)
+
+func stripContentHash(path string) string {
+	return contentHashSuffix.ReplaceAllString(path, "$1")
+}
+
+func TestKeyFunc(t *testing.T) {
+	for _, concurrent := range []bool{false, true} {
+		filesystem := &countingFS{fs: fstest.MapFS{
+			"app.js":        &fstest.MapFile{Data: []byte(`{"Hello": "app"}`)},
+			"app.3fa9b2.js": &fstest.MapFile{Data: []byte(`{"Hello": "hashed"}`)},
+		}}
+		var parses int64
+		parser := func(f io.Reader) (testFileStructure, error) {
+			atomic.AddInt64(&parses, 1)
+			return JsonParser[testFileStructure](f)
+		}
+		var cache testInterface
+		var stats func() Stats
+		opts := []Option[testFileStructure]{WithKeyFunc[testFileStructure](stripContentHash)}
+		if concurrent {
+			c := NewConcurrentFsCache(filesystem, parser, time.Minute, opts...)
+			cache, stats = c, c.Stats
+		} else {
+			c := NewFsCache(filesystem, parser, time.Minute, opts...)
+			cache, stats = &c, c.Stats
+		}
+
+		// The first get opens its own path, and the other shares its entry.
+		a, err := cache.GetFile("app.3fa9b2.js")
+		if err != nil || a.Hello != "hashed" {
+			t.Errorf("concurrent=%v: app.3fa9b2.js not parsed: %+v, %v", concurrent, a, err)
+		}
+		a, err = cache.GetFile("/app.js")
+		if err != nil || a.Hello != "hashed" {
+			t.Errorf("concurrent=%v: app.js doesn't share the entry: %+v, %v", concurrent, a, err)
+		}
+		if parses != 1 || filesystem.Opens() != 1 {
+			t.Errorf("concurrent=%v: %d parses and %d opens, expected 1 of each", concurrent, parses, filesystem.Opens())
+		}
+		if stats().Files != 1 {
+			t.Errorf("concurrent=%v: expected 1 entry: %+v", concurrent, stats())
+		}
+	}
+}
+
+func TestOpenPathFunc(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"app.js": &fstest.MapFile{Data: []byte(`{"Hello": "app"}`)},
+	}
+	cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute,
+		WithKeyFunc[testFileStructure](stripContentHash),
+		WithOpenPathFunc[testFileStructure](stripContentHash),
+		WithNegativeCacheTTL[testFileStructure](time.Minute),
+	)
+	a, err := cache.GetFile("app.3fa9b2.js")
+	if err != nil || a.Hello != "app" {
+		t.Errorf("app.js not opened for app.3fa9b2.js: %+v, %v", a, err)
+	}
+	if _, ok := cache.GetFileEntry("app.000000.js"); !ok {
+		t.Error("no entry for app.000000.js")
+	}
+	entries := cache.Entries()
+	if len(entries) != 1 || entries[0].Path != "/app.js" {
+		t.Errorf("incorrect entries: %+v", entries)
+	}
+
+	// Entries remembering that a file doesn't exist are keyed in the same way.
+	if _, err := cache.GetFile("missing.111111.js"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing.111111.js didn't fail with fs.ErrNotExist: %v", err)
+	}
+	if _, ok := cache.GetFileEntry("missing.js"); !ok {
+		t.Error("missing file not remembered under its key")
+	}
+}
@@ -0,0 +1,52 @@
+package parsecache
+
+import "time"
+
+// WithLogger logs the cache's activity to `logger`, such as one returned by `NewSlogLogger` (with
+// Go 1.21 or later). If `logger` is nil (the default) nothing is logged.
+func WithLogger[T any](logger cacheLogger) Option[T] {
+	return func(o *options[T]) {
+		o.logger = logger
+	}
+}
+
+// cacheLogger receives the events logged by `WithLogger`.
+type cacheLogger interface {
+	// hit is called when a file or directory (`kind`) is returned from memory, with the age of the
+	// entry.
+	hit(kind, path string, age time.Duration)
+	// miss is called when a file or directory is loaded or revalidated, with the age of the entry
+	// before it was, or 0 if it wasn't cached.
+	miss(kind, path string, age time.Duration)
+	// loadError is called when a file or directory fails to load, with the age of the entry, or 0
+	// if it wasn't cached.
+	loadError(kind, path string, age time.Duration, err error)
+	// evicted is called when a file is evicted because of `WithMaxEntries`.
+	evicted(path string)
+	// notCached is called when a file isn't cached because its `size` is larger than the limit set
+	// by `WithMaxCachedFileSize`.
+	notCached(path string, size int64)
+	// unchanged is called when a file is parsed again, but the detector set by `WithChangeDetector`
+	// finds its content unchanged, so the cached content is kept.
+	unchanged(path string)
+}
+
+// logLoad logs the result of getting a file or directory, given the time its entry was cached
+// before and after, which are zero if it wasn't cached. It does nothing if `logger` is nil.
+func logLoad(logger cacheLogger, kind, path string, before, after time.Time, err error) {
+	if logger == nil {
+		return
+	}
+	var age time.Duration
+	if !before.IsZero() {
+		age = time.Since(before)
+	}
+	switch {
+	case err != nil:
+		logger.loadError(kind, path, age, err)
+	case !before.IsZero() && after.Equal(before):
+		logger.hit(kind, path, age)
+	default:
+		logger.miss(kind, path, age)
+	}
+}
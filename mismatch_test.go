@@ -0,0 +1,58 @@
+package parsecache
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestFileDirMismatch(t *testing.T) {
+	mapFS := fstest.MapFS{
+		"a.json":    &fstest.MapFile{Data: []byte(`{"Number": 1}`)},
+		"dir/b.txt": &fstest.MapFile{},
+	}
+	// The `countingFS` only implements `Open`, so both ways of loading entries are checked.
+	for _, filesystem := range []fs.FS{mapFS, &countingFS{fs: mapFS}} {
+		for _, concurrent := range []bool{false, true} {
+			var cache testInterface
+			var fileCached, dirCached func(string) bool
+			if concurrent {
+				c := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+				cache = c
+				fileCached = func(path string) bool { _, ok := c.GetFileEntry(path); return ok }
+				dirCached = func(path string) bool { _, ok := c.GetDirEntry(path); return ok }
+			} else {
+				c := NewFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+				cache = &c
+				fileCached = func(path string) bool { _, ok := c.GetFileEntry(path); return ok }
+				dirCached = func(path string) bool { _, ok := c.GetDirEntry(path); return ok }
+			}
+
+			var pathErr *fs.PathError
+			_, err := cache.GetDir("a.json")
+			if !errors.Is(err, ErrNotADirectory) || !errors.As(err, &pathErr) || pathErr.Path != "/a.json" {
+				t.Errorf("concurrent=%v: GetDir of a file didn't fail correctly: %v", concurrent, err)
+			}
+			if dirCached("a.json") {
+				t.Errorf("concurrent=%v: directory entry cached for a file", concurrent)
+			}
+			_, err = cache.GetFile("dir")
+			if !errors.Is(err, ErrIsADirectory) || !errors.As(err, &pathErr) || pathErr.Path != "/dir" {
+				t.Errorf("concurrent=%v: GetFile of a directory didn't fail correctly: %v", concurrent, err)
+			}
+			if fileCached("dir") {
+				t.Errorf("concurrent=%v: file entry cached for a directory", concurrent)
+			}
+
+			// The paths can still be got the right way.
+			if _, err := cache.GetFile("a.json"); err != nil {
+				t.Errorf("concurrent=%v: GetFile failed after the mismatch: %v", concurrent, err)
+			}
+			if _, err := cache.GetDir("dir"); err != nil {
+				t.Errorf("concurrent=%v: GetDir failed after the mismatch: %v", concurrent, err)
+			}
+		}
+	}
+}
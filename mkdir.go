@@ -0,0 +1,53 @@
+package parsecache
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// WriteableFS is a filesystem which can create directories, which `GetDirOrCreate` uses to create
+// missing directories. `fs.FS` is read-only, so a writeable filesystem, such as `os.DirFS`, must be
+// wrapped to implement it.
+type WriteableFS interface {
+	fs.FS
+	// MkdirAll creates the directory `name`, which is a path in the filesystem (valid according to
+	// `fs.ValidPath`), along with any missing parents, with the permissions `perm`, like
+	// `os.MkdirAll`.
+	MkdirAll(name string, perm fs.FileMode) error
+}
+
+// GetDirOrCreate gets the entries of a directory, like `GetDir`, but if it doesn't exist, and the
+// filesystem implements `WriteableFS`, it's created, with any missing parents, with the permissions
+// `perm`, and then read. On other filesystems, it's the same as `GetDir`.
+func (cache *FsCache[T]) GetDirOrCreate(dir string, perm fs.FileMode) ([]fs.DirEntry, error) {
+	entries, err := cache.GetDir(dir)
+	writeable, ok := cache.fs.(WriteableFS)
+	if !ok || !errors.Is(err, fs.ErrNotExist) {
+		return entries, err
+	}
+	if err := mkdirAll(writeable, cache.options.openPath(cache.normalize(dir)), perm); err != nil {
+		return nil, err
+	}
+	return cache.GetDir(dir)
+}
+
+// GetDirOrCreate gets the entries of a directory, like `GetDir`, but if it doesn't exist, and the
+// filesystem implements `WriteableFS`, it's created, with any missing parents, with the permissions
+// `perm`, and then read. On other filesystems, it's the same as `GetDir`.
+func (cache *ConcurrentFsCache[T]) GetDirOrCreate(dir string, perm fs.FileMode) ([]fs.DirEntry, error) {
+	entries, err := cache.GetDir(dir)
+	writeable, ok := cache.loadSettings().fs.(WriteableFS)
+	if !ok || !errors.Is(err, fs.ErrNotExist) {
+		return entries, err
+	}
+	if err := mkdirAll(writeable, cache.options.openPath(cache.normalize(dir)), perm); err != nil {
+		return nil, err
+	}
+	return cache.GetDir(dir)
+}
+
+// mkdirAll creates the directory at the cleaned `path` in `filesystem`.
+func mkdirAll(filesystem WriteableFS, path string, perm fs.FileMode) error {
+	src := source{path: path}
+	return src.wrapErr("mkdir", filesystem.MkdirAll(fsName(path), perm))
+}
@@ -0,0 +1,62 @@
+package parsecache
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// mkdirFS is an `os.DirFS` which implements `WriteableFS`.
+type mkdirFS struct {
+	fs.FS
+	root string
+}
+
+func (m mkdirFS) MkdirAll(name string, perm fs.FileMode) error {
+	return os.MkdirAll(filepath.Join(m.root, filepath.FromSlash(name)), perm)
+}
+
+func TestGetDirOrCreate(t *testing.T) {
+	for _, concurrent := range []bool{false, true} {
+		dir := t.TempDir()
+		filesystem := mkdirFS{os.DirFS(dir), dir}
+		var getDirOrCreate func(string, fs.FileMode) ([]fs.DirEntry, error)
+		if concurrent {
+			getDirOrCreate = NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute).GetDirOrCreate
+		} else {
+			c := NewFsCache[testFileStructure](filesystem, JsonParser[testFileStructure], time.Minute)
+			getDirOrCreate = c.GetDirOrCreate
+		}
+
+		entries, err := getDirOrCreate("a/b", 0o755)
+		if err != nil || len(entries) != 0 {
+			t.Errorf("concurrent=%v: a/b not created: %d entries, %v", concurrent, len(entries), err)
+		}
+		if info, err := os.Stat(filepath.Join(dir, "a", "b")); err != nil || !info.IsDir() {
+			t.Errorf("concurrent=%v: a/b isn't a directory: %v", concurrent, err)
+		}
+
+		// Existing directories are read as usual.
+		entries, err = getDirOrCreate("a", 0o755)
+		if err != nil || len(entries) != 1 || entries[0].Name() != "b" {
+			t.Errorf("concurrent=%v: a not read: %d entries, %v", concurrent, len(entries), err)
+		}
+
+		// A file in the way of the directory fails to be created.
+		os.WriteFile(filepath.Join(dir, "file"), nil, 0o644)
+		if _, err = getDirOrCreate("file/c", 0o755); err == nil {
+			t.Errorf("concurrent=%v: directory created inside a file", concurrent)
+		}
+	}
+}
+
+func TestGetDirOrCreateReadOnly(t *testing.T) {
+	cache := NewConcurrentFsCache(fstest.MapFS{}, JsonParser[testFileStructure], time.Minute)
+	if _, err := cache.GetDirOrCreate("a", 0o755); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing directory on a read-only filesystem didn't fail with fs.ErrNotExist: %v", err)
+	}
+}
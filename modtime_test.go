@@ -0,0 +1,57 @@
+package parsecache
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestModTimeEqual(t *testing.T) {
+	maxAge := time.Second / 20
+	// time.Now has a monotonic reading, which the copies in other locations don't.
+	modTime := time.Now().Add(-time.Hour)
+	for _, concurrent := range []bool{false, true} {
+		for _, sameTime := range []time.Time{
+			modTime.Round(0),
+			modTime.UTC(),
+			modTime.In(time.FixedZone("test", 3*60*60)),
+		} {
+			if sameTime == modTime || !sameTime.Equal(modTime) {
+				panic("times should be Equal but not ==")
+			}
+			filesystem := fstest.MapFS{
+				"a.json":    &fstest.MapFile{Data: []byte(`{"Number": 1}`), ModTime: modTime},
+				"dir":       &fstest.MapFile{Mode: fs.ModeDir, ModTime: modTime},
+				"dir/b.txt": &fstest.MapFile{ModTime: modTime},
+			}
+			var cache testInterface
+			if concurrent {
+				cache = NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], maxAge)
+			} else {
+				fsCache := NewFsCache(filesystem, JsonParser[testFileStructure], maxAge)
+				cache = &fsCache
+			}
+			if _, err := cache.GetFile("a.json"); err != nil {
+				panic(err)
+			}
+			if _, err := cache.GetDir("dir"); err != nil {
+				panic(err)
+			}
+
+			// The changes aren't seen, since the sizes and modtimes are the same.
+			filesystem["a.json"] = &fstest.MapFile{Data: []byte(`{"Number": 2}`), ModTime: sameTime}
+			filesystem["dir"] = &fstest.MapFile{Mode: fs.ModeDir, ModTime: sameTime}
+			filesystem["dir/c.txt"] = &fstest.MapFile{ModTime: modTime}
+			time.Sleep(maxAge)
+			a, err := cache.GetFile("a.json")
+			if err != nil || a.Number != 1 {
+				t.Errorf("concurrent=%v, %v: a.json reparsed: %+v, %v", concurrent, sameTime, a, err)
+			}
+			entries, err := cache.GetDir("dir")
+			if err != nil || len(entries) != 1 {
+				t.Errorf("concurrent=%v, %v: dir reread: %d entries, %v", concurrent, sameTime, len(entries), err)
+			}
+		}
+	}
+}
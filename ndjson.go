@@ -0,0 +1,76 @@
+package parsecache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// NdjsonOptions configures the parser returned by `NdjsonParserWith`.
+type NdjsonOptions struct {
+	// SkipBlankLines ignores lines which are empty or only contain whitespace, instead of failing to
+	// parse them. A single trailing newline at the end of the file is always allowed.
+	SkipBlankLines bool
+	// SkipComments ignores lines whose first non-whitespace character is '#'.
+	SkipComments bool
+}
+
+// NdjsonError is returned by the parsers from `NdjsonParser` and `NdjsonParserWith` when a record
+// can't be decoded.
+type NdjsonError struct {
+	// Line is the line number of the record, starting at 1.
+	Line int
+	Err  error
+}
+
+func (err *NdjsonError) Error() string {
+	return fmt.Sprintf("parsecache: ndjson line %d: %v", err.Line, err.Err)
+}
+
+func (err *NdjsonError) Unwrap() error {
+	return err.Err
+}
+
+// NdjsonParser[T] is a value of type Parser[[]T] which decodes a file of newline-delimited JSON
+// records, one per line.
+func NdjsonParser[T any](f io.Reader) ([]T, error) {
+	return NdjsonParserWith[T](NdjsonOptions{})(f)
+}
+
+// NdjsonParserWith returns a `Parser` which decodes a file of newline-delimited JSON records, one per
+// line, with the given options.
+//
+// The file is read a line at a time, so only one record is held in memory before it's decoded,
+// unless a cache has read the whole file with `ReadFile`, see `WithStreamingReads`.
+func NdjsonParserWith[T any](opts NdjsonOptions) Parser[[]T] {
+	return func(f io.Reader) ([]T, error) {
+		r := bufio.NewReader(f)
+		var records []T
+		for line := 1; ; line++ {
+			content, err := r.ReadBytes('\n')
+			if err != nil && err != io.EOF {
+				return records, err
+			}
+			eof := err == io.EOF
+			if eof && len(content) == 0 {
+				return records, nil
+			}
+
+			trimmed := bytes.TrimSpace(content)
+			skip := (opts.SkipBlankLines && len(trimmed) == 0) ||
+				(opts.SkipComments && len(trimmed) > 0 && trimmed[0] == '#')
+			if !skip {
+				var record T
+				if err := json.Unmarshal(trimmed, &record); err != nil {
+					return records, &NdjsonError{Line: line, Err: err}
+				}
+				records = append(records, record)
+			}
+			if eof {
+				return records, nil
+			}
+		}
+	}
+}
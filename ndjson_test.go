@@ -0,0 +1,90 @@
+package parsecache
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// countingReader counts the number of bytes read from a reader.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += n
+	return n, err
+}
+
+func TestNdjsonParser(t *testing.T) {
+	records, err := NdjsonParser[testFileStructure](strings.NewReader("{\"Hello\": \"a\"}\n{\"Number\": 2}\r\n"))
+	if err != nil {
+		panic(err)
+	}
+	if len(records) != 2 || records[0].Hello != "a" || records[1].Number != 2 {
+		t.Error("records not parsed correctly")
+	}
+	records, err = NdjsonParser[testFileStructure](strings.NewReader(`{"Hello": "no trailing newline"}`))
+	if err != nil || len(records) != 1 {
+		t.Errorf("file without a trailing newline not parsed correctly: %v", err)
+	}
+	records, err = NdjsonParser[testFileStructure](strings.NewReader(""))
+	if err != nil || len(records) != 0 {
+		t.Errorf("empty file not parsed correctly: %v", err)
+	}
+
+	var ndjsonErr *NdjsonError
+	_, err = NdjsonParser[testFileStructure](strings.NewReader("{}\n\n{}\n"))
+	if !errors.As(err, &ndjsonErr) || ndjsonErr.Line != 2 {
+		t.Errorf("blank line didn't fail on line 2: %v", err)
+	}
+	_, err = NdjsonParser[testFileStructure](strings.NewReader("{}\n{}\n{\"Hello\": nope}\n{}\n"))
+	if !errors.As(err, &ndjsonErr) || ndjsonErr.Line != 3 {
+		t.Errorf("invalid record didn't fail on line 3: %v", err)
+	}
+}
+
+func TestNdjsonParserWith(t *testing.T) {
+	parser := NdjsonParserWith[testFileStructure](NdjsonOptions{SkipBlankLines: true, SkipComments: true})
+	records, err := parser(strings.NewReader("# events\n{\"Hello\": \"a\"}\n\n  # later\n  \n{\"Hello\": \"b\"}\n"))
+	if err != nil {
+		panic(err)
+	}
+	if len(records) != 2 || records[0].Hello != "a" || records[1].Hello != "b" {
+		t.Error("blank lines and comments not skipped")
+	}
+
+	_, err = NdjsonParserWith[testFileStructure](NdjsonOptions{SkipBlankLines: true})(strings.NewReader("{}\n# comment\n"))
+	var ndjsonErr *NdjsonError
+	if !errors.As(err, &ndjsonErr) || ndjsonErr.Line != 2 {
+		t.Errorf("comment skipped without SkipComments: %v", err)
+	}
+}
+
+func TestNdjsonParserStreams(t *testing.T) {
+	var file strings.Builder
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&file, "{\"Hello\": \"record %d\", \"Number\": %d}\n", i, i)
+	}
+	records, err := NdjsonParser[testFileStructure](strings.NewReader(file.String()))
+	if err != nil {
+		panic(err)
+	}
+	if len(records) != 5000 || records[4999].Number != 4999 {
+		t.Error("records not parsed correctly")
+	}
+
+	// A bad record near the start fails without reading the rest of the file.
+	r := &countingReader{r: strings.NewReader("{\"Hello\": nope}\n" + file.String())}
+	_, err = NdjsonParser[testFileStructure](r)
+	if err == nil {
+		t.Error("bad record didn't fail")
+	}
+	if r.n >= file.Len() {
+		t.Errorf("read %d bytes of %d before failing", r.n, file.Len())
+	}
+}
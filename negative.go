@@ -0,0 +1,32 @@
+package parsecache
+
+import "time"
+
+// WithNegativeCacheTTL remembers that files don't exist for `ttl`. When getting a file fails because
+// it doesn't exist, an entry is cached for it, and getting it again returns the same error, which
+// unwraps to `fs.ErrNotExist`, without touching the filesystem until `ttl` has passed. A file which
+// is created in the meantime isn't seen until then, or until the cache is cleared.
+//
+// Entries for missing files count towards the limit set by `WithMaxEntries`, but aren't included in
+// `Entries`.
+func WithNegativeCacheTTL[T any](ttl time.Duration) Option[T] {
+	return func(o *options[T]) {
+		o.load.negativeTTL = ttl
+	}
+}
+
+// notExist returns the error from finding that the file doesn't exist, if it was found not to
+// exist, as of `now`, within the time set by `WithNegativeCacheTTL` in `config`.
+func (f *CachedFile[T]) notExist(now time.Time, config loadConfig) error {
+	if f.notExistErr == nil || now.Sub(f.notExistAt) >= config.negativeTTL {
+		return nil
+	}
+	return f.notExistErr
+}
+
+// notExistCached returns true if the entry remembers that the file doesn't exist.
+func (f *ConcurrentCachedFile[T]) notExistCached() bool {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return f.cachedFile.notExistErr != nil
+}
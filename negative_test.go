@@ -0,0 +1,74 @@
+package parsecache
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+type testNegativeInterface interface {
+	testInterface
+	Entries() []EntryInfo
+}
+
+func negativeCacheTests(t *testing.T, cache testNegativeInterface, filesystem *countingFS, mapFS fstest.MapFS, ttl time.Duration) {
+	for i := 0; i < 3; i++ {
+		_, err := cache.GetFile("missing.json")
+		if !errors.Is(err, fs.ErrNotExist) || !os.IsNotExist(err) {
+			t.Errorf("missing.json didn't fail with fs.ErrNotExist: %v", err)
+		}
+	}
+	if filesystem.Opens() != 1 {
+		t.Errorf("missing.json opened %d times", filesystem.Opens())
+	}
+	if len(cache.Entries()) != 0 {
+		t.Error("missing file included in Entries")
+	}
+
+	// The file is seen once the TTL has passed.
+	mapFS["missing.json"] = &fstest.MapFile{Data: []byte(`{"Hello": "found"}`)}
+	if _, err := cache.GetFile("missing.json"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing.json loaded before the TTL passed: %v", err)
+	}
+	time.Sleep(ttl)
+	found, err := cache.GetFile("missing.json")
+	if err != nil || found.Hello != "found" {
+		t.Errorf("missing.json not loaded after the TTL: %v", err)
+	}
+	if len(cache.Entries()) != 1 {
+		t.Error("found file not included in Entries")
+	}
+
+	// A cached file which is removed is remembered as missing once it's revalidated.
+	delete(mapFS, "missing.json")
+	time.Sleep(ttl)
+	opens := filesystem.Opens()
+	for i := 0; i < 3; i++ {
+		_, err := cache.GetFile("missing.json")
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("removed missing.json didn't fail with fs.ErrNotExist: %v", err)
+		}
+	}
+	if filesystem.Opens() != opens+1 {
+		t.Errorf("removed missing.json opened %d times", filesystem.Opens()-opens)
+	}
+}
+
+func TestNegativeCacheTTL(t *testing.T) {
+	ttl := time.Second / 10
+	for _, concurrent := range []bool{false, true} {
+		mapFS := fstest.MapFS{}
+		filesystem := &countingFS{fs: mapFS}
+		opt := WithNegativeCacheTTL[testFileStructure](ttl)
+		if concurrent {
+			cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], ttl/2, opt)
+			negativeCacheTests(t, cache, filesystem, mapFS, ttl)
+		} else {
+			cache := NewFsCache(filesystem, JsonParser[testFileStructure], ttl/2, opt)
+			negativeCacheTests(t, &cache, filesystem, mapFS, ttl)
+		}
+	}
+}
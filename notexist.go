@@ -0,0 +1,145 @@
+package parsecache
+
+import (
+	"errors"
+	"hash/fnv"
+	"io/fs"
+	"math"
+	"sync"
+	"time"
+)
+
+// NotExistFilter configures the filter set by `WithNotExistFilter`.
+type NotExistFilter struct {
+	// ExpectedPaths is the number of missing paths the filter is sized for.
+	ExpectedPaths int
+	// FalsePositiveRate is the probability, once `ExpectedPaths` paths have been added, that a path
+	// which was never missing is treated as missing. It defaults to 0.001.
+	FalsePositiveRate float64
+	// ResetAfter, if positive, is how long paths are remembered for. The whole filter is reset once
+	// it's this old.
+	ResetAfter time.Duration
+}
+
+// WithNotExistFilter remembers the paths of files which don't exist in a bloom filter, so that
+// getting them again returns an error which unwraps to `fs.ErrNotExist` immediately, without
+// touching the filesystem.
+//
+// Paths can't be removed from a bloom filter, so a file which is created after it was found to be
+// missing isn't seen until the filter is reset, by `filter.ResetAfter` passing or by `ClearFiles` or
+// `Clear`. Also, a bloom filter has false positives, so rarely, a file which exists is reported as
+// missing, more often once more than `filter.ExpectedPaths` paths have been added. This only makes
+// sense if the set of files is fixed, or the paths being looked up are mostly missing.
+func WithNotExistFilter[T any](filter NotExistFilter) Option[T] {
+	return func(o *options[T]) {
+		o.notExistFilter = filter
+	}
+}
+
+// notExistFilter is a bloom filter of the paths which don't exist in a cache. A nil
+// `notExistFilter` never contains a path.
+type notExistFilter struct {
+	config NotExistFilter
+	// hashes is the number of bits set for each path.
+	hashes int
+
+	lock sync.Mutex
+	bits []uint64
+	// resetAt is the time the filter was last reset.
+	resetAt time.Time
+}
+
+// newNotExistFilter returns the `notExistFilter` for `config`, or nil if it's disabled.
+func newNotExistFilter(config NotExistFilter) *notExistFilter {
+	if config.ExpectedPaths <= 0 {
+		return nil
+	}
+	rate := config.FalsePositiveRate
+	if rate <= 0 || rate >= 1 {
+		rate = 0.001
+	}
+	// The optimal size and number of hashes for the expected number of paths and false positive
+	// rate.
+	size := math.Ceil(-float64(config.ExpectedPaths) * math.Log(rate) / (math.Ln2 * math.Ln2))
+	hashes := int(math.Round(size / float64(config.ExpectedPaths) * math.Ln2))
+	if hashes < 1 {
+		hashes = 1
+	}
+	return &notExistFilter{
+		config:  config,
+		hashes:  hashes,
+		bits:    make([]uint64, (int(size)+63)/64),
+		resetAt: time.Now(),
+	}
+}
+
+// positions calls `f` with the index of each bit for the cleaned `path`.
+func (filter *notExistFilter) positions(path string, f func(i uint64)) {
+	h := fnv.New64a()
+	h.Write([]byte(path))
+	sum := h.Sum64()
+	// Double hashing, using the two halves of the hash.
+	h1, h2 := sum&math.MaxUint32, sum>>32|1
+	size := uint64(len(filter.bits)) * 64
+	for i := uint64(0); i < uint64(filter.hashes); i++ {
+		f((h1 + i*h2) % size)
+	}
+}
+
+// check returns an error which unwraps to `fs.ErrNotExist` if the file at the cleaned `path` is in
+// the filter.
+func (filter *notExistFilter) check(path string) error {
+	if filter == nil {
+		return nil
+	}
+	filter.lock.Lock()
+	defer filter.lock.Unlock()
+	filter.expire()
+	found := true
+	filter.positions(path, func(i uint64) {
+		found = found && filter.bits[i/64]&(1<<(i%64)) != 0
+	})
+	if !found {
+		return nil
+	}
+	return &fs.PathError{Op: "parsecache.open", Path: path, Err: fs.ErrNotExist}
+}
+
+// record adds the file at the cleaned `path` to the filter if `err`, from loading it, shows it
+// doesn't exist.
+func (filter *notExistFilter) record(path string, err error) {
+	if filter == nil || !errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	filter.lock.Lock()
+	defer filter.lock.Unlock()
+	filter.expire()
+	filter.positions(path, func(i uint64) {
+		filter.bits[i/64] |= 1 << (i % 64)
+	})
+}
+
+// expire resets the filter if it's older than `ResetAfter`. `filter.lock` must be held.
+func (filter *notExistFilter) expire() {
+	if filter.config.ResetAfter > 0 && time.Since(filter.resetAt) >= filter.config.ResetAfter {
+		filter.reset()
+	}
+}
+
+// reset removes every path from the filter. `filter.lock` must be held.
+func (filter *notExistFilter) reset() {
+	for i := range filter.bits {
+		filter.bits[i] = 0
+	}
+	filter.resetAt = time.Now()
+}
+
+// clear removes every path from the filter.
+func (filter *notExistFilter) clear() {
+	if filter == nil {
+		return
+	}
+	filter.lock.Lock()
+	defer filter.lock.Unlock()
+	filter.reset()
+}
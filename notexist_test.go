@@ -0,0 +1,89 @@
+package parsecache
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func notExistFilterTests(t *testing.T, cache testInterface, clear func(), filesystem *countingFS, mapFS fstest.MapFS, resetAfter time.Duration) {
+	for i := 0; i < 3; i++ {
+		_, err := cache.GetFile("missing.json")
+		if !errors.Is(err, fs.ErrNotExist) || !os.IsNotExist(err) {
+			t.Errorf("missing.json didn't fail with fs.ErrNotExist: %v", err)
+		}
+	}
+	if filesystem.Opens() != 1 {
+		t.Errorf("missing.json opened %d times", filesystem.Opens())
+	}
+	a, err := cache.GetFile("a.json")
+	if err != nil || a.Hello != "world!" {
+		t.Errorf("a.json not loaded correctly: %v", err)
+	}
+
+	// Files created after they were missing aren't seen until the filter is reset.
+	mapFS["missing.json"] = &fstest.MapFile{Data: []byte(`{"Hello": "found"}`)}
+	if _, err := cache.GetFile("missing.json"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing.json loaded before the filter was reset: %v", err)
+	}
+	clear()
+	found, err := cache.GetFile("missing.json")
+	if err != nil || found.Hello != "found" {
+		t.Errorf("missing.json not loaded after Clear: %v", err)
+	}
+
+	delete(mapFS, "missing.json")
+	clear()
+	cache.GetFile("missing.json")
+	mapFS["missing.json"] = &fstest.MapFile{Data: []byte(`{"Hello": "found"}`)}
+	time.Sleep(resetAfter)
+	found, err = cache.GetFile("missing.json")
+	if err != nil || found.Hello != "found" {
+		t.Errorf("missing.json not loaded after ResetAfter: %v", err)
+	}
+}
+
+func TestNotExistFilter(t *testing.T) {
+	resetAfter := time.Second / 10
+	filter := WithNotExistFilter[testFileStructure](NotExistFilter{ExpectedPaths: 100, ResetAfter: resetAfter})
+	for _, concurrent := range []bool{false, true} {
+		mapFS := fstest.MapFS{
+			"a.json": &fstest.MapFile{Data: []byte(`{"Hello": "world!"}`)},
+		}
+		filesystem := &countingFS{fs: mapFS}
+		if concurrent {
+			cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute, filter)
+			notExistFilterTests(t, cache, cache.Clear, filesystem, mapFS, resetAfter)
+		} else {
+			cache := NewFsCache(filesystem, JsonParser[testFileStructure], time.Minute, filter)
+			notExistFilterTests(t, &cache, cache.Clear, filesystem, mapFS, resetAfter)
+		}
+	}
+}
+
+func TestNotExistFilterFalsePositives(t *testing.T) {
+	filter := newNotExistFilter(NotExistFilter{ExpectedPaths: 1000, FalsePositiveRate: 0.01})
+	for i := 0; i < 1000; i++ {
+		filter.record(fmt.Sprintf("/missing-%d.json", i), fs.ErrNotExist)
+	}
+	falsePositives := 0
+	for i := 0; i < 1000; i++ {
+		if filter.check(fmt.Sprintf("/missing-%d.json", i)) == nil {
+			t.Fatal("missing path not in the filter")
+		}
+		if filter.check(fmt.Sprintf("/present-%d.json", i)) != nil {
+			falsePositives++
+		}
+	}
+	if falsePositives > 30 {
+		t.Errorf("%d false positives in 1000 paths", falsePositives)
+	}
+	filter.record("/other.json", errors.New("not a missing file"))
+	if filter.check("/other.json") != nil {
+		t.Error("path added for an error other than fs.ErrNotExist")
+	}
+}
@@ -0,0 +1,19 @@
+package parsecache
+
+// WithOnMiss calls `onMiss` each time a file or directory which isn't fresh in memory is loaded (or
+// revalidated), with its cleaned path and the error of the load, which is nil if it succeeded. With
+// `WithRetry`, it's called for each attempt, so every failure is passed to it.
+//
+// It's called while the entry is being loaded, so it mustn't get the same path from the cache.
+func WithOnMiss[T any](onMiss func(path string, err error)) Option[T] {
+	return func(o *options[T]) {
+		o.load.onMiss = onMiss
+	}
+}
+
+// missed calls the hook set by `WithOnMiss` with the result of loading `path`, if `config` has one.
+func missed(config loadConfig, path string, err error) {
+	if config.onMiss != nil {
+		config.onMiss(path, err)
+	}
+}
@@ -0,0 +1,48 @@
+package parsecache
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestOnMiss(t *testing.T) {
+	for _, concurrent := range []bool{false, true} {
+		filesystem := &flakyFS{
+			fs: fstest.MapFS{
+				"a.json": &fstest.MapFile{Data: []byte(`{"Hello": "world"}`)},
+				"dir":    &fstest.MapFile{Mode: fs.ModeDir},
+			},
+			failures: 2,
+		}
+		var paths []string
+		var errs []error
+		opts := []Option[testFileStructure]{
+			WithRetry[testFileStructure](3, time.Millisecond),
+			WithOnMiss[testFileStructure](func(path string, err error) {
+				paths = append(paths, path)
+				errs = append(errs, err)
+			}),
+		}
+		var cache testInterface
+		if concurrent {
+			cache = NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute, opts...)
+		} else {
+			c := NewFsCache(filesystem, JsonParser[testFileStructure], time.Minute, opts...)
+			cache = &c
+		}
+
+		// Each failed attempt is passed to the hook, and hits aren't.
+		cache.GetFile("a.json")
+		cache.GetFile("a.json")
+		cache.GetDir("dir")
+		if len(paths) != 4 || paths[0] != "/a.json" || paths[2] != "/a.json" || paths[3] != "/dir" {
+			t.Errorf("concurrent=%v: incorrect missed paths: %v", concurrent, paths)
+		}
+		if len(errs) != 4 || !errors.Is(errs[0], errFlaky) || !errors.Is(errs[1], errFlaky) || errs[2] != nil || errs[3] != nil {
+			t.Errorf("concurrent=%v: incorrect missed errors: %v", concurrent, errs)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package parsecache
+
+import "time"
+
+// WithOnParse calls `onParse` each time a file is parsed successfully, with its cleaned path, the
+// parsed content and how long the parser took, so parsing can be instrumented, or parsed values
+// checked, without wrapping the parser. It's called synchronously, after the parser returns and
+// before the content is cached, including when `WithChangeDetector` then keeps the cached content.
+//
+// It's called while the entry is being loaded, so it mustn't get the same file from the cache.
+func WithOnParse[T any](onParse func(path string, value T, duration time.Duration)) Option[T] {
+	return func(o *options[T]) {
+		o.load.onParse = onParse
+	}
+}
+
+// parsed calls the hook set by `WithOnParse` with the result of parsing `path`, if `config` has one
+// for `T`.
+func parsed[T any](config loadConfig, path string, content T, duration time.Duration) {
+	if onParse, ok := config.onParse.(func(string, T, time.Duration)); ok {
+		onParse(path, content, duration)
+	}
+}
@@ -0,0 +1,52 @@
+package parsecache
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestOnParse(t *testing.T) {
+	for _, concurrent := range []bool{false, true} {
+		for _, sha := range []bool{false, true} {
+			filesystem := fstest.MapFS{
+				"a.json":       &fstest.MapFile{Data: []byte(`{"Hello": "a"}`), ModTime: time.Now().Add(-time.Hour)},
+				"invalid.json": &fstest.MapFile{Data: []byte(`{"Hello": `)},
+			}
+			var paths []string
+			var values []string
+			opts := []Option[testFileStructure]{WithOnParse(func(path string, value testFileStructure, duration time.Duration) {
+				paths = append(paths, path)
+				values = append(values, value.Hello)
+				if duration < 0 {
+					t.Errorf("negative parse duration %v", duration)
+				}
+			})}
+			if sha {
+				opts = append(opts, WithSHA256[testFileStructure]())
+			}
+			var cache testInterface
+			if concurrent {
+				cache = NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], 0, opts...)
+			} else {
+				c := NewFsCache(filesystem, JsonParser[testFileStructure], 0, opts...)
+				cache = &c
+			}
+
+			cache.GetFile("a.json")
+			// Unchanged files aren't parsed again, and files which fail to parse aren't passed to the
+			// hook.
+			cache.GetFile("a.json")
+			cache.GetFile("invalid.json")
+			filesystem["a.json"] = &fstest.MapFile{Data: []byte(`{"Hello": "b"}`)}
+			cache.GetFile("a.json")
+
+			if len(paths) != 2 || paths[0] != "/a.json" || paths[1] != "/a.json" {
+				t.Errorf("concurrent=%v sha=%v: incorrect parsed paths: %v", concurrent, sha, paths)
+			}
+			if len(values) != 2 || values[0] != "a" || values[1] != "b" {
+				t.Errorf("concurrent=%v sha=%v: incorrect parsed values: %v", concurrent, sha, values)
+			}
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package parsecache
+
+import (
+	"errors"
+	"time"
+)
+
+// Option configures an `FsCache` or `ConcurrentFsCache` when it's created.
+type Option[T any] func(*options[T])
+
+// options holds the configuration set by a cache's `Option`s.
+type options[T any] struct {
+	// load configures how entries are loaded.
+	load loadConfig
+
+	// parserFor, if set, returns the parser for a cleaned path, instead of the parser given to the
+	// constructor.
+	parserFor func(path string) fileParser[T]
+
+	// secondLevel, if set, wraps the parser of the file with the key `key`, whichever parser it
+	// is, to load it from the second level of a `TwoLevelCache`.
+	secondLevel func(key string, parser fileParser[T]) fileParser[T]
+
+	// parsersByExt are the parsers set by `WithParserFor`, keyed by extension.
+	parsersByExt map[string]fileParser[T]
+	// unknownExtensionError is true if files which don't match any of `parsersByExt` should fail.
+	unknownExtensionError bool
+
+	// maxEntries is the maximum number of cached files, if positive.
+	maxEntries int
+
+	// shards is the number of shards of a `ConcurrentFsCache`, see `WithShards`.
+	shards int
+
+	// maxCachedFileSize is the size of the largest file which is cached, if positive.
+	maxCachedFileSize int64
+
+	// strictPaths is true if invalid paths should be rejected, see `WithStrictPaths`.
+	strictPaths bool
+
+	// caseInsensitiveKeys is true if entries are keyed by their lower cased paths, see
+	// `WithCaseInsensitiveKeys`.
+	caseInsensitiveKeys bool
+	// keyFunc, if set, returns the key of the entry for a normalized path, see `WithKeyFunc`.
+	keyFunc func(string) string
+	// openPathFunc, if set, returns the path opened for a normalized path, see `WithOpenPathFunc`.
+	openPathFunc func(string) string
+
+	// normalizer, if set, is used instead of `cleanPath` to standardize paths.
+	normalizer func(string) string
+
+	// circuitBreaker configures the circuit breaker, it's disabled if `MaxFailures` isn't positive.
+	circuitBreaker CircuitBreaker
+
+	// notExistFilter configures the filter of missing files, it's disabled if `ExpectedPaths` isn't
+	// positive.
+	notExistFilter NotExistFilter
+
+	// logger, if set, logs the cache's activity.
+	logger cacheLogger
+
+	// onEvict, if set, is called with each entry that's evicted to keep within `maxEntries`.
+	onEvict func(path string, entry *CachedFile[T])
+}
+
+// newOptions returns the configuration set by `opts`.
+func newOptions[T any](opts []Option[T]) options[T] {
+	o := options[T]{load: loadConfig{racyWindow: DefaultRacyModTimeWindow}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.load.logger = o.logger
+	return o
+}
+
+// loadConfig configures how an entry is loaded.
+type loadConfig struct {
+	// timeout is the maximum duration of the filesystem and parse work of a single load, if positive.
+	timeout time.Duration
+	// retry configures how failed loads are retried.
+	retry retryConfig
+	// adaptiveTTL, if enabled, replaces the maximum age of files with one which adapts to how often
+	// each file changes.
+	adaptiveTTL AdaptiveTTL
+	// ttlJitter, if positive, is the most the maximum age of each entry is randomly offset by, see
+	// `WithTTLJitter`.
+	ttlJitter time.Duration
+	// compress is true if parsed content should be kept compressed, see `WithCompression`.
+	compress bool
+	// serveStaleOnStatError is true if the cached content should be returned when a file is removed
+	// while it's being loaded, see `WithServeStaleOnStatError`.
+	serveStaleOnStatError bool
+	// negativeTTL, if positive, is how long files are remembered not to exist, see
+	// `WithNegativeCacheTTL`.
+	negativeTTL time.Duration
+	// sizeLimit, if positive, is the size of the largest file which is parsed, see
+	// `WithFileSizeLimit`.
+	sizeLimit int64
+	// contentHash is true if files should be revalidated by the hash of their content, see
+	// `WithContentHash`.
+	contentHash bool
+	// fileIdentity is true if files should also be revalidated by their platform-specific identity,
+	// see `WithFileIdentity`.
+	fileIdentity bool
+	// validator, if not nil, revalidates files instead of their size and modtime, see
+	// `WithValidator`.
+	validator Validator
+	// zeroModTime is how files with a zero modtime are revalidated, see `WithZeroModTimePolicy`.
+	zeroModTime ZeroModTimePolicy
+	// racyWindow is how close to the time a file was stat-ed its modtime must be for it to be parsed
+	// again when it's next revalidated, see `WithRacyModTimeWindow`.
+	racyWindow time.Duration
+	// sha256 is true if the SHA-256 of files should be kept, see `WithSHA256`.
+	sha256 bool
+	// streamingReads is true if files should be opened and streamed to the parser, rather than read
+	// with `ReadFile`, see `WithStreamingReads`.
+	streamingReads bool
+	// dirSort is the order of the entries of directories, see `WithDirSortOrder`.
+	dirSort DirSortOrder
+	// dirInfos is true if the info of the entries of directories should be read when they're loaded,
+	// see `WithDirInfos`.
+	dirInfos bool
+	// changeDetector, if set, is the `func(old, new T) bool` set by `WithChangeDetector`, for the
+	// `T` of the cache's files.
+	changeDetector any
+	// onParse, if set, is the `func(path string, value T, duration time.Duration)` set by
+	// `WithOnParse`, for the `T` of the cache's files.
+	onParse any
+	// onMiss, if set, is called with the result of each attempt at loading an entry, see
+	// `WithOnMiss`.
+	onMiss func(path string, err error)
+	// logger is the logger of the cache, if it has one.
+	logger cacheLogger
+	// result, if not nil, is where how a single get was served is recorded, see `GetResult`.
+	result *GetResult
+}
+
+// ErrLoadTimeout is returned when a load takes longer than the timeout set by `WithLoadTimeout`.
+var ErrLoadTimeout = errors.New("parsecache: load timed out")
+
+// WithLoadTimeout limits a single load of a file or directory (opening, stat-ing and parsing or
+// reading it) to `timeout`. A load which takes longer fails with `ErrLoadTimeout`, and, as with any
+// other failed load, the previously cached value is returned alongside the error.
+//
+// Only the filesystem and parse work is timed, not any time spent waiting for another load of the
+// same entry. The abandoned load carries on in the background, but its result is discarded.
+func WithLoadTimeout[T any](timeout time.Duration) Option[T] {
+	return func(o *options[T]) {
+		o.load.timeout = timeout
+	}
+}
+
+// WithServeStaleOnStatError controls what happens when a cached file fails to be stat-ed, or is
+// stat-ed but then fails to be opened, usually because it was removed in between. Files are opened
+// and then stat-ed, except with a filesystem which implements `fs.StatFS`, such as `os.DirFS`,
+// where they're stat-ed first.
+//
+// If `serveStale` is true, the previously cached content is returned without an error, and the
+// entry is kept, but it isn't revalidated, so the file is loaded again on the next access.
+// Otherwise (the default), the error is returned as with any other failed load.
+func WithServeStaleOnStatError[T any](serveStale bool) Option[T] {
+	return func(o *options[T]) {
+		o.load.serveStaleOnStatError = serveStale
+	}
+}
+
+// WithPathParser sets a `PathParser` to parse files, instead of the parser given to the constructor,
+// which may then be nil. It's passed the cleaned path of each file in the cache (or, for files
+// inside archives, the cleaned path inside the archive).
+//
+// This replaces any parser set by an earlier `WithPathParser` or `WithInfoParser`.
+func WithPathParser[T any](parser PathParser[T]) Option[T] {
+	return func(o *options[T]) {
+		o.parserFor = parser.fileParser
+	}
+}
+
+// WithInfoParser sets an `InfoParser` to parse files, instead of the parser given to the
+// constructor, which may then be nil.
+//
+// This replaces any parser set by an earlier `WithPathParser` or `WithInfoParser`.
+func WithInfoParser[T any](parser InfoParser[T]) Option[T] {
+	return func(o *options[T]) {
+		o.parserFor = func(string) fileParser[T] {
+			return parser.fileParser()
+		}
+	}
+}
+
+// WithPathNormalizer sets the function used to standardize paths before they're used as keys in the
+// cache and opened, instead of the default, which cleans the path and makes it absolute.
+//
+// The returned paths must start with "/", which is removed before opening them in the filesystem.
+// Getting a path which the normalizer returns any other path for fails with an `*fs.PathError`
+// wrapping `fs.ErrInvalid`. `CaseInsensitiveNormalizer` is provided for case-insensitive
+// filesystems.
+func WithPathNormalizer[T any](normalizer func(path string) string) Option[T] {
+	return func(o *options[T]) {
+		o.normalizer = normalizer
+	}
+}
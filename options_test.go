@@ -0,0 +1,240 @@
+package parsecache
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func loadTimeoutTests(t *testing.T, cache testInterface, filesystem *gatedFS, maxAge time.Duration, dir string) {
+	os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"Hello": "world!"}`), 0660)
+	a, err := cache.GetFile("a.json")
+	if err != nil {
+		panic(err)
+	}
+	if a.Hello != "world!" {
+		t.Error("a.json not parsed correctly")
+	}
+
+	os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"Hello": "changed"}`), 0660)
+	time.Sleep(maxAge + time.Second/10)
+	filesystem.gate.Lock()
+	start := time.Now()
+	a, err = cache.GetFile("a.json")
+	if !errors.Is(err, ErrLoadTimeout) {
+		t.Error("GetFile doesn't return ErrLoadTimeout")
+	}
+	if time.Since(start) > time.Second/2 {
+		t.Error("GetFile didn't time out promptly")
+	}
+	if a.Hello != "world!" {
+		t.Error("GetFile doesn't return the stale value on timeout")
+	}
+	_, err = cache.GetDir("/")
+	if !errors.Is(err, ErrLoadTimeout) {
+		t.Error("GetDir doesn't return ErrLoadTimeout")
+	}
+	filesystem.gate.Unlock()
+
+	a, err = cache.GetFile("a.json")
+	if err != nil {
+		panic(err)
+	}
+	if a.Hello != "changed" {
+		t.Error("a.json not reloaded correctly after a timeout")
+	}
+}
+
+func TestLoadTimeout(t *testing.T) {
+	maxAge := time.Second / 10
+	dir, err := os.MkdirTemp("", "parsecache-test-timeout-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	filesystem := &gatedFS{fs: os.DirFS(dir)}
+	cache := NewFsCache(filesystem, JsonParser[testFileStructure], maxAge, WithLoadTimeout[testFileStructure](time.Second/10))
+	loadTimeoutTests(t, &cache, filesystem, maxAge, dir)
+}
+
+func TestLoadTimeoutConcurrent(t *testing.T) {
+	maxAge := time.Second / 10
+	dir, err := os.MkdirTemp("", "parsecache-test-timeout-concurrent-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	filesystem := &gatedFS{fs: os.DirFS(dir)}
+	cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], maxAge, WithLoadTimeout[testFileStructure](time.Second/10))
+	loadTimeoutTests(t, cache, filesystem, maxAge, dir)
+}
+
+func TestPathParser(t *testing.T) {
+	dir, err := os.MkdirTemp("", "parsecache-test-path-parser-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"Hello": "world!"}`), 0660)
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte(`raw content`), 0660)
+
+	var paths []string
+	parser := func(path string, f fs.File) (any, error) {
+		paths = append(paths, path)
+		if filepath.Ext(path) == ".json" {
+			return JsonParser[map[string]any](f)
+		}
+		return io.ReadAll(f)
+	}
+
+	cache := NewFsCache(os.DirFS(dir), nil, time.Second, WithPathParser(parser))
+	concurrent := NewConcurrentFsCache(os.DirFS(dir), nil, time.Second, WithPathParser(parser))
+	for _, getFile := range []func(string) (any, error){cache.GetFile, concurrent.GetFile} {
+		paths = nil
+		a, err := getFile("a.json")
+		if err != nil {
+			panic(err)
+		}
+		if m, ok := a.(map[string]any); !ok || m["Hello"] != "world!" {
+			t.Error("a.json not parsed as JSON")
+		}
+		b, err := getFile("nested/../b.txt")
+		if err != nil {
+			panic(err)
+		}
+		if raw, ok := b.([]byte); !ok || string(raw) != "raw content" {
+			t.Error("b.txt not parsed as raw bytes")
+		}
+		if len(paths) != 2 || paths[0] != "/a.json" || paths[1] != "/b.txt" {
+			t.Errorf("parser not given the cleaned paths: %v", paths)
+		}
+	}
+}
+
+func TestPathNormalizer(t *testing.T) {
+	filesystem := &countingFS{fs: fstest.MapFS{
+		"about.json": &fstest.MapFile{Data: []byte(`{"Hello": "about"}`)},
+	}}
+	cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute, WithPathNormalizer[testFileStructure](CaseInsensitiveNormalizer))
+	for _, name := range []string{"About.JSON", "/about.json", "ABOUT.json"} {
+		about, err := cache.GetFile(name)
+		if err != nil {
+			panic(err)
+		}
+		if about.Hello != "about" {
+			t.Errorf("%s not parsed correctly", name)
+		}
+	}
+	if filesystem.Opens() != 1 {
+		t.Error("differently cased paths don't share an entry")
+	}
+	if _, ok := cache.GetFileEntry("aBoUt.json"); !ok {
+		t.Error("GetFileEntry doesn't normalize the path")
+	}
+
+	// A normalizer which removes URL-style queries.
+	stripQuery := func(path string) string {
+		if i := strings.IndexByte(path, '?'); i >= 0 {
+			path = path[:i]
+		}
+		return cleanPath(path)
+	}
+	plain := NewFsCache[testFileStructure](filesystem, JsonParser[testFileStructure], time.Minute, WithPathNormalizer[testFileStructure](stripQuery))
+	about, err := plain.GetFile("about.json?v=2")
+	if err != nil {
+		panic(err)
+	}
+	if about.Hello != "about" {
+		t.Error("about.json?v=2 not parsed correctly")
+	}
+
+	// A normalizer which doesn't return an absolute path fails, rather than panicking.
+	relative := NewConcurrentFsCache[testFileStructure](filesystem, JsonParser[testFileStructure], time.Minute, WithPathNormalizer[testFileStructure](func(path string) string {
+		return strings.TrimPrefix(cleanPath(path), "/")
+	}))
+	if _, err := relative.GetFile("about.json"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("relative normalized path not rejected with fs.ErrInvalid: %v", err)
+	}
+	if _, err := relative.GetDir("/"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("relative normalized directory not rejected with fs.ErrInvalid: %v", err)
+	}
+}
+
+func TestBackslashNormalizer(t *testing.T) {
+	for _, test := range []struct {
+		path, cleaned string
+	}{
+		{`a\b.json`, "/a/b.json"},
+		{"a/b.json", "/a/b.json"},
+		{"/a/./b.json", "/a/b.json"},
+		{`\a\..\a\b.json`, "/a/b.json"},
+		{"", "/"},
+	} {
+		if cleaned := BackslashNormalizer(test.path); cleaned != test.cleaned {
+			t.Errorf("%q cleaned to %q, expected %q", test.path, cleaned, test.cleaned)
+		}
+		// The default only treats backslashes as separators on Windows.
+		if cleaned := cleanPath(test.path); cleaned != cleanSlashPath(test.path, os.PathSeparator == '\\') {
+			t.Errorf("%q cleaned to %q by default", test.path, cleaned)
+		}
+	}
+	if cleanSlashPath(`a\b.json`, false) != `/a\b.json` {
+		t.Error("backslashes converted when they aren't separators")
+	}
+
+	filesystem := &countingFS{fs: fstest.MapFS{
+		"a/b.json": &fstest.MapFile{Data: []byte(`{"Hello": "b"}`)},
+	}}
+	opt := WithPathNormalizer[testFileStructure](BackslashNormalizer)
+	fsCache := NewFsCache(filesystem, JsonParser[testFileStructure], time.Minute, opt)
+	for _, cache := range []testInterface{&fsCache, NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute, opt)} {
+		opens := filesystem.Opens()
+		for _, name := range []string{`a\b.json`, "a/b.json", "/a/./b.json"} {
+			b, err := cache.GetFile(name)
+			if err != nil || b.Hello != "b" {
+				t.Errorf("%s not parsed correctly: %+v, %v", name, b, err)
+			}
+		}
+		if filesystem.Opens()-opens != 1 {
+			t.Error("paths with backslashes don't share an entry")
+		}
+	}
+}
+
+func TestInfoParser(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"small.txt": &fstest.MapFile{Data: []byte("small")},
+		"large.txt": &fstest.MapFile{Data: []byte("this file is too large")},
+	}
+	errTooLarge := errors.New("too large")
+	parser := func(f fs.File, info fs.FileInfo) ([]byte, error) {
+		if info.Size() > 10 {
+			return nil, errTooLarge
+		}
+		buf := make([]byte, info.Size())
+		_, err := io.ReadFull(f, buf)
+		return buf, err
+	}
+
+	cache := NewFsCache[[]byte](filesystem, nil, time.Minute, WithInfoParser(parser))
+	concurrent := NewConcurrentFsCache[[]byte](filesystem, nil, time.Minute, WithInfoParser(parser))
+	for _, getFile := range []func(string) ([]byte, error){cache.GetFile, concurrent.GetFile} {
+		small, err := getFile("small.txt")
+		if err != nil {
+			panic(err)
+		}
+		if string(small) != "small" {
+			t.Error("small.txt not parsed correctly")
+		}
+		_, err = getFile("large.txt")
+		if !errors.Is(err, errTooLarge) {
+			t.Error("parser not given the file's info")
+		}
+	}
+}
@@ -0,0 +1,21 @@
+module github.com/JOT85/parsecache/otel
+
+go 1.18
+
+// To build against a local checkout of github.com/JOT85/parsecache, rather than the version
+// required below, use a workspace in the repository root (which is ignored by git):
+//
+//	go work init . ./otel ./prometheus ./toml ./yaml
+
+require (
+	github.com/JOT85/parsecache v0.0.0-20261015013204-82d0fd020112
+	go.opentelemetry.io/otel v1.14.0
+	go.opentelemetry.io/otel/sdk v1.14.0
+	go.opentelemetry.io/otel/trace v1.14.0
+)
+
+require (
+	github.com/go-logr/logr v1.2.3 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	golang.org/x/sys v0.5.0 // indirect
+)
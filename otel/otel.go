@@ -0,0 +1,123 @@
+// Package otel provides an adapter which traces the operations of a parsecache cache with
+// OpenTelemetry.
+//
+// It's a separate module so that the main parsecache module doesn't depend on OpenTelemetry.
+package otel
+
+import (
+	"context"
+	"io/fs"
+	"time"
+
+	"github.com/JOT85/parsecache"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// resultCache is implemented by the caches of parsecache, such as `*parsecache.ConcurrentFsCache`
+// and `parsecache.SubCache`, and is used to tell whether an entry was returned from memory, how old
+// it is, and when it was parsed.
+type resultCache[T any] interface {
+	GetFileResult(file string) (T, parsecache.GetResult, error)
+	GetDirResult(dir string) ([]fs.DirEntry, parsecache.GetResult, error)
+}
+
+// TracedCache is a `parsecache.Cache` which creates a span for each operation on the cache it
+// wraps.
+type TracedCache[T any] struct {
+	inner  parsecache.Cache[T]
+	tracer trace.Tracer
+	// results is `inner`, if it implements `resultCache`, or nil.
+	results resultCache[T]
+}
+
+var _ parsecache.Cache[any] = (*TracedCache[any])(nil)
+
+// NewTracedCache returns a cache which wraps `inner`, using `tracer` to create a span for each
+// `GetFile` and `GetDir`.
+//
+// The spans have the attributes "cache.path", "cache.hit", which is true if the result came from
+// memory, and "cache.age_ms", the age of the cache entry in milliseconds. When a file is parsed, the
+// span has a child span, "parsecache.parse", covering the call of the parser. The hit and age, and
+// the child span, require `inner` to be one of the caches of parsecache, which report how each get
+// was served.
+//
+// Use `GetFileContext` and `GetDirContext` to make the spans part of an existing trace, the methods
+// of `parsecache.Cache` start new traces. The wrapper is only safe for concurrent use if `inner` is.
+func NewTracedCache[T any](inner parsecache.Cache[T], tracer trace.Tracer) *TracedCache[T] {
+	cache := &TracedCache[T]{
+		inner:  inner,
+		tracer: tracer,
+	}
+	cache.results, _ = inner.(resultCache[T])
+	return cache
+}
+
+// end records `result` and `err` on `span`, and ends it.
+func end(span trace.Span, result parsecache.GetResult, err error) {
+	span.SetAttributes(attribute.Bool("cache.hit", result.Hit))
+	if !result.CachedAt.IsZero() {
+		span.SetAttributes(attribute.Int64("cache.age_ms", time.Since(result.CachedAt).Milliseconds()))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// GetFile returns the parsed content of a file, which may be cached, in a new trace.
+func (cache *TracedCache[T]) GetFile(file string) (T, error) {
+	return cache.GetFileContext(context.Background(), file)
+}
+
+// GetFileContext returns the parsed content of a file, which may be cached, in a span which is a
+// child of any span in `ctx`.
+func (cache *TracedCache[T]) GetFileContext(ctx context.Context, file string) (T, error) {
+	ctx, span := cache.tracer.Start(ctx, "parsecache.GetFile", trace.WithAttributes(attribute.String("cache.path", file)))
+	if cache.results == nil {
+		content, err := cache.inner.GetFile(file)
+		end(span, parsecache.GetResult{}, err)
+		return content, err
+	}
+
+	content, result, err := cache.results.GetFileResult(file)
+	if result.Parsed {
+		// The parse is reported by the cache once the get returns, so the span is recorded with
+		// the times the parser was called and returned.
+		_, parseSpan := cache.tracer.Start(ctx, "parsecache.parse", trace.WithTimestamp(result.ParseStart), trace.WithAttributes(attribute.String("cache.path", file)))
+		if err != nil {
+			parseSpan.RecordError(err)
+			parseSpan.SetStatus(codes.Error, err.Error())
+		}
+		parseSpan.End(trace.WithTimestamp(result.ParseStart.Add(result.ParseDuration)))
+	}
+	end(span, result, err)
+	return content, err
+}
+
+// GetDir gets the entries of a directory, which may be cached, in a new trace.
+func (cache *TracedCache[T]) GetDir(dir string) ([]fs.DirEntry, error) {
+	return cache.GetDirContext(context.Background(), dir)
+}
+
+// GetDirContext gets the entries of a directory, which may be cached, in a span which is a child of
+// any span in `ctx`.
+func (cache *TracedCache[T]) GetDirContext(ctx context.Context, dir string) ([]fs.DirEntry, error) {
+	_, span := cache.tracer.Start(ctx, "parsecache.GetDir", trace.WithAttributes(attribute.String("cache.path", dir)))
+	if cache.results == nil {
+		entries, err := cache.inner.GetDir(dir)
+		end(span, parsecache.GetResult{}, err)
+		return entries, err
+	}
+
+	entries, result, err := cache.results.GetDirResult(dir)
+	end(span, result, err)
+	return entries, err
+}
+
+// Clear the cache.
+func (cache *TracedCache[T]) Clear() {
+	cache.inner.Clear()
+}
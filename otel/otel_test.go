@@ -0,0 +1,114 @@
+package otel
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/JOT85/parsecache"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type testFileStructure struct {
+	Hello string
+}
+
+// attributeValue returns the value of the attribute `key` of `span`, and whether it's set.
+func attributeValue(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestTracedCache(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"a.json": &fstest.MapFile{Data: []byte(`{"Hello": "a"}`)},
+	}
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	inner := parsecache.NewConcurrentFsCache(filesystem, parsecache.JsonParser[testFileStructure], time.Minute)
+	cache := NewTracedCache[testFileStructure](inner, provider.Tracer("test"))
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+	a, err := cache.GetFileContext(ctx, "a.json")
+	if err != nil {
+		panic(err)
+	}
+	if a.Hello != "a" {
+		t.Error("a.json not parsed correctly")
+	}
+	_, err = cache.GetFileContext(ctx, "a.json")
+	if err != nil {
+		panic(err)
+	}
+	_, err = cache.GetFile("missing.json")
+	if err == nil {
+		t.Error("missing.json didn't return an error")
+	}
+	_, err = cache.GetDir("/")
+	if err != nil {
+		panic(err)
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	var names []string
+	for _, span := range spans {
+		names = append(names, span.Name())
+	}
+	// Missing files aren't parsed, and parse spans end before their parents.
+	expected := []string{
+		"parsecache.parse", "parsecache.GetFile",
+		"parsecache.GetFile",
+		"parsecache.GetFile",
+		"parsecache.GetDir",
+		"request",
+	}
+	if len(names) != len(expected) {
+		t.Fatalf("spans not recorded correctly: %v", names)
+	}
+	for i := range names {
+		if names[i] != expected[i] {
+			t.Fatalf("spans not recorded correctly: %v", names)
+		}
+	}
+
+	parse, miss, hit, missing := spans[0], spans[1], spans[2], spans[3]
+	if miss.Parent().SpanID() != parent.SpanContext().SpanID() || hit.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("GetFileContext spans aren't children of the context's span")
+	}
+	if parse.Parent().SpanID() != miss.SpanContext().SpanID() {
+		t.Error("parse span isn't a child of the GetFile span")
+	}
+	if parse.StartTime().Before(miss.StartTime()) || parse.EndTime().After(miss.EndTime()) {
+		t.Error("parse span isn't within the GetFile span")
+	}
+	if missing.Parent().IsValid() {
+		t.Error("GetFile span isn't a new trace")
+	}
+
+	for span, expected := range map[sdktrace.ReadOnlySpan]bool{miss: false, hit: true, missing: false, spans[4]: false} {
+		value, ok := attributeValue(span, "cache.hit")
+		if !ok || value.AsBool() != expected {
+			t.Errorf("%s cache.hit is %v, expected %v", span.Name(), value.AsBool(), expected)
+		}
+		if path, _ := attributeValue(span, "cache.path"); path.AsString() == "" {
+			t.Errorf("%s has no cache.path", span.Name())
+		}
+	}
+	if _, ok := attributeValue(hit, "cache.age_ms"); !ok {
+		t.Error("hit has no cache.age_ms")
+	}
+	if _, ok := attributeValue(missing, "cache.age_ms"); ok {
+		t.Error("missing file has a cache.age_ms")
+	}
+	if len(missing.Events()) == 0 {
+		t.Error("error not recorded for missing.json")
+	}
+}
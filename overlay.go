@@ -0,0 +1,157 @@
+package parsecache
+
+import (
+	"errors"
+	"io/fs"
+	pathpkg "path"
+	"sort"
+	"time"
+)
+
+// NewOverlayFsCache returns a new cache, safe for concurrent access, on top of the filesystems
+// `layers` overlaid on each other, using `parser` to parse the content of files and with a maximum
+// age of cache entries of `maxAge`. The layers must be safe for concurrent use.
+//
+// A file is read from the first layer it exists in, so earlier layers override later ones, such as
+// a writable override directory over an embedded set of defaults. The entries of a directory are
+// merged from every layer it's a directory in, and an entry in an earlier layer shadows any entry
+// with the same name in a later layer.
+//
+// Files are revalidated by stat-ing them in the layer which now supplies them, so a file which is
+// added to or removed from an earlier layer is loaded from the new layer once its entry reaches its
+// maximum age, and deleting an override reveals the file beneath it. Merged directories are
+// revalidated by the latest modtime and total size of the directory over the layers.
+func NewOverlayFsCache[T any](layers []fs.FS, parser Parser[T], maxAge time.Duration, opts ...Option[T]) *ConcurrentFsCache[T] {
+	return NewConcurrentFsCache[T](overlayFS(append([]fs.FS(nil), layers...)), parser, maxAge, opts...)
+}
+
+// overlayFS is the filesystem of the layers of a `NewOverlayFsCache`, the topmost first.
+type overlayFS []fs.FS
+
+// find returns the index of the first layer which has `name`, and its info there.
+func (o overlayFS) find(name string) (int, fs.FileInfo, error) {
+	for i, layer := range o {
+		info, err := fs.Stat(layer, name)
+		if err == nil {
+			return i, info, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return i, nil, err
+		}
+	}
+	return 0, nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	i, info, err := o.find(name)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return o[i].Open(name)
+	}
+	info, entries, err := o.readDir(i, info, name)
+	if err != nil {
+		return nil, err
+	}
+	return &cachedDir{name: name, info: info.(cachedInfo), entries: entries}, nil
+}
+
+func (o overlayFS) Stat(name string) (fs.FileInfo, error) {
+	i, info, err := o.find(name)
+	if err != nil || !info.IsDir() {
+		return info, err
+	}
+	return o.dirInfo(i, info, name)
+}
+
+func (o overlayFS) ReadFile(name string) ([]byte, error) {
+	i, _, err := o.find(name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadFile(o[i], name)
+}
+
+func (o overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	i, info, err := o.find(name)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: ErrNotADirectory}
+	}
+	_, entries, err := o.readDir(i, info, name)
+	return entries, err
+}
+
+// dirInfo returns the info of the directory `name`, which is first in layer `i` with `info`, with
+// the latest modtime and the total size of the directory in layer `i` and the layers after it.
+func (o overlayFS) dirInfo(i int, info fs.FileInfo, name string) (fs.FileInfo, error) {
+	merged := cachedInfo{name: info.Name(), mode: info.Mode()}
+	for _, layer := range o[i:] {
+		layerInfo, err := fs.Stat(layer, name)
+		if errors.Is(err, fs.ErrNotExist) || err == nil && !layerInfo.IsDir() {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		merged.size += layerInfo.Size()
+		if layerInfo.ModTime().After(merged.modTime) {
+			merged.modTime = layerInfo.ModTime()
+		}
+	}
+	return merged, nil
+}
+
+// readDir returns the merged info and entries, sorted by name, of the directory `name`, which is
+// first in layer `i` with `info`.
+func (o overlayFS) readDir(i int, info fs.FileInfo, name string) (fs.FileInfo, []fs.DirEntry, error) {
+	info, err := o.dirInfo(i, info, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	var entries []fs.DirEntry
+	seen := make(map[string]bool)
+	for _, layer := range o[i:] {
+		layerEntries, err := fs.ReadDir(layer, name)
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, ErrNotADirectory) {
+			continue
+		} else if err != nil {
+			// A layer where `name` is a file fails to be read as a directory, and is skipped.
+			if layerInfo, statErr := fs.Stat(layer, name); statErr == nil && !layerInfo.IsDir() {
+				continue
+			}
+			return nil, nil, err
+		}
+		for _, entry := range layerEntries {
+			if seen[entry.Name()] {
+				continue
+			}
+			seen[entry.Name()] = true
+			if entry.IsDir() {
+				entry = overlayDirEntry{entry, o, pathpkg.Join(name, entry.Name())}
+			}
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(a, b int) bool {
+		return entries[a].Name() < entries[b].Name()
+	})
+	return info, entries, nil
+}
+
+// overlayDirEntry is an entry of a merged directory which is itself a directory, whose info is
+// merged from the layers, like that of the directory when it's stat-ed.
+type overlayDirEntry struct {
+	fs.DirEntry
+	fsys overlayFS
+	path string
+}
+
+func (e overlayDirEntry) Info() (fs.FileInfo, error) {
+	return e.fsys.Stat(e.path)
+}
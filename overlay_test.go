@@ -0,0 +1,81 @@
+package parsecache
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestOverlayFsCache(t *testing.T) {
+	modTime := time.Now().Add(-time.Hour)
+	override := fstest.MapFS{
+		"a.json":     &fstest.MapFile{Data: []byte(`{"Hello": "override a"}`), ModTime: modTime},
+		"dir/c.json": &fstest.MapFile{Data: []byte(`{"Hello": "override c"}`), ModTime: modTime},
+	}
+	base := fstest.MapFS{
+		"a.json":     &fstest.MapFile{Data: []byte(`{"Hello": "base a"}`), ModTime: modTime},
+		"b.json":     &fstest.MapFile{Data: []byte(`{"Hello": "base b"}`), ModTime: modTime},
+		"dir/c.json": &fstest.MapFile{Data: []byte(`{"Hello": "base c"}`), ModTime: modTime},
+		"dir/d.json": &fstest.MapFile{Data: []byte(`{"Hello": "base d"}`), ModTime: modTime},
+	}
+	cache := NewOverlayFsCache([]fs.FS{override, base}, JsonParser[testFileStructure], 0)
+
+	for path, expected := range map[string]string{
+		"a.json":     "override a",
+		"b.json":     "base b",
+		"dir/c.json": "override c",
+		"dir/d.json": "base d",
+	} {
+		content, err := cache.GetFile(path)
+		if err != nil || content.Hello != expected {
+			t.Errorf("%s not loaded from the correct layer: %+v, %v", path, content, err)
+		}
+	}
+	if _, err := cache.GetFile("missing.json"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing file didn't fail with fs.ErrNotExist: %v", err)
+	}
+
+	entries, err := cache.GetDir("dir")
+	if err != nil {
+		panic(err)
+	}
+	if len(entries) != 2 || entries[0].Name() != "c.json" || entries[1].Name() != "d.json" {
+		t.Errorf("dir not merged correctly: %v", entries)
+	}
+	entries, err = cache.GetDir("/")
+	if err != nil {
+		panic(err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("root not merged correctly: %v", entries)
+	}
+
+	// Deleting the override reveals the base file.
+	delete(override, "a.json")
+	if content, err := cache.GetFile("a.json"); err != nil || content.Hello != "base a" {
+		t.Errorf("base a.json not revealed: %+v, %v", content, err)
+	}
+
+	// A new override shadows the base file.
+	override["b.json"] = &fstest.MapFile{Data: []byte(`{"Hello": "override b"}`), ModTime: modTime}
+	if content, err := cache.GetFile("b.json"); err != nil || content.Hello != "override b" {
+		t.Errorf("new override of b.json not loaded: %+v, %v", content, err)
+	}
+
+	// A new file in a lower layer changes the merged directory.
+	base["dir/e.json"] = &fstest.MapFile{Data: []byte(`{"Hello": "base e"}`), ModTime: modTime}
+	base["dir"] = &fstest.MapFile{Mode: fs.ModeDir, ModTime: modTime.Add(time.Minute)}
+	entries, err = cache.GetDir("dir")
+	if err != nil {
+		panic(err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("dir not merged again after changing: %v", entries)
+	}
+
+	if err := fstest.TestFS(overlayFS{override, base}, "a.json", "b.json", "dir/c.json", "dir/e.json"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,81 @@
+package parsecache
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// panickingParser parses files as strings, but panics if the content is "panic".
+func panickingParser(f io.Reader) (string, error) {
+	content, err := io.ReadAll(f)
+	if string(content) == "panic" {
+		panic("pathological input")
+	}
+	return string(content), err
+}
+
+func parserPanicTests(t *testing.T, getFile func(string) (string, error), maxAge time.Duration, dir string) {
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("fine"), 0660)
+	a, err := getFile("a.txt")
+	if err != nil {
+		panic(err)
+	}
+	if a != "fine" {
+		t.Error("a.txt not parsed correctly")
+	}
+
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("panic"), 0660)
+	time.Sleep(maxAge + time.Second/10)
+	a, err = getFile("a.txt")
+	var panicErr *ParserPanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("parser panic not converted to an error: %v", err)
+	}
+	if panicErr.Path != "/a.txt" || panicErr.Value != "pathological input" || len(panicErr.Stack) == 0 {
+		t.Error("ParserPanicError not filled in correctly")
+	}
+	if !strings.HasPrefix(err.Error(), `parsecache: parser panicked for "/a.txt": pathological input`) {
+		t.Errorf("unexpected error message: %s", err)
+	}
+	if a != "fine" {
+		t.Error("previous value not returned after a parser panic")
+	}
+
+	// The entry must still be usable.
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("recovered"), 0660)
+	time.Sleep(maxAge + time.Second/10)
+	a, err = getFile("a.txt")
+	if err != nil {
+		panic(err)
+	}
+	if a != "recovered" {
+		t.Error("a.txt not parsed correctly after a parser panic")
+	}
+}
+
+func TestParserPanic(t *testing.T) {
+	maxAge := time.Second / 10
+	dir, err := os.MkdirTemp("", "parsecache-test-panic-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	cache := NewFsCache(os.DirFS(dir), panickingParser, maxAge)
+	parserPanicTests(t, cache.GetFile, maxAge, dir)
+}
+
+func TestParserPanicConcurrent(t *testing.T) {
+	maxAge := time.Second / 10
+	dir, err := os.MkdirTemp("", "parsecache-test-panic-concurrent-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	cache := NewConcurrentFsCache(os.DirFS(dir), panickingParser, maxAge)
+	parserPanicTests(t, cache.GetFile, maxAge, dir)
+}
@@ -2,38 +2,177 @@
 package parsecache
 
 import (
+	"context"
+	"embed"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"io/fs"
+	"math"
 	"os"
-	"path/filepath"
+	pathpkg "path"
+	"runtime/debug"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// forever is the maximum age used for entries which never change.
+const forever = time.Duration(math.MaxInt64)
+
 // cleanPath attempts to return a standardized path for internal use.
 //
-// More specifically, the returned path will start with "/" and all . and .. components should be
-// resolved.
+// More specifically, the returned path will start with "/", it's separated by forward slashes, as
+// `io/fs` paths are on every platform, and all . and .. components should be resolved. On Windows,
+// backslashes are also treated as separators.
 func cleanPath(path string) string {
-	return filepath.Clean("/" + path)
+	return cleanSlashPath(path, os.PathSeparator == '\\')
+}
+
+// cleanSlashPath is `cleanPath`, converting backslashes to forward slashes first if `backslashes`.
+func cleanSlashPath(path string, backslashes bool) string {
+	if backslashes {
+		path = strings.ReplaceAll(path, `\`, "/")
+	}
+	return pathpkg.Clean("/" + path)
+}
+
+// BackslashNormalizer is a path normalizer, for use with `WithPathNormalizer`, which cleans paths
+// in the same way as the default, but treats backslashes as separators on every platform, as the
+// default only does on Windows. Backslashes are valid in the names of `io/fs` paths, so this should
+// only be used if the filesystem has no names containing them.
+func BackslashNormalizer(path string) string {
+	return cleanSlashPath(path, true)
+}
+
+// CaseInsensitiveNormalizer is a path normalizer, for use with `WithPathNormalizer`, which cleans
+// paths in the same way as the default and then converts them to lower case. It's intended for
+// case-insensitive filesystems, where different casings of a path refer to the same file.
+func CaseInsensitiveNormalizer(path string) string {
+	return strings.ToLower(cleanPath(path))
+}
+
+// checkNormalized returns an error if `normalized`, the normalized `path`, can't be opened because
+// the normalizer set by `WithPathNormalizer` didn't return a path starting with "/".
+func checkNormalized(path, normalized string) error {
+	if strings.HasPrefix(normalized, "/") {
+		return nil
+	}
+	return &fs.PathError{Op: "parsecache.normalize", Path: path, Err: fs.ErrInvalid}
+}
+
+// normalize returns the standardized path for internal use, which is also the path opened.
+func (cache *FsCache[T]) normalize(path string) string {
+	if cache.options.normalizer != nil {
+		return cache.options.normalizer(path)
+	}
+	return cleanPath(path)
+}
+
+// normalize returns the standardized path for internal use, which is also the path opened.
+func (cache *ConcurrentFsCache[T]) normalize(path string) string {
+	if cache.options.normalizer != nil {
+		return cache.options.normalizer(path)
+	}
+	return cleanPath(path)
+}
+
+// source is where a cache entry is loaded from.
+type source struct {
+	// path is the cleaned path of the entry, used to describe it. It's empty if it isn't known.
+	path string
+	// open opens the file or directory.
+	open func() (fs.File, error)
+	// readDir, if set, reads the entries of the directory, when the opened directory doesn't
+	// implement `fs.ReadDirFile`.
+	readDir func() ([]fs.DirEntry, error)
+	// stats is true if `open` only stats the file, see `openStatFile`, so an error from it is from
+	// stat-ing the file.
+	stats bool
+}
+
+// wrapErr wraps an error from the operation `op` ("open", "stat", "readdir", "parse", "load",
+// "validate", "compress", "decompress" or "mkdir") in an `*fs.PathError` with the path of the source, so it
+// can be checked with `errors.Is` and `os.IsNotExist`. Errors are returned unchanged if the path
+// isn't known, and a `*ParserPanicError` is returned unchanged, since it already includes the path.
+func (src source) wrapErr(op string, err error) error {
+	if err == nil || src.path == "" {
+		return err
+	}
+	if _, ok := err.(*ParserPanicError); ok {
+		return err
+	}
+	// os.IsNotExist only unwraps a single *fs.PathError, so an existing one is replaced.
+	if pathErr, ok := err.(*fs.PathError); ok {
+		err = pathErr.Err
+	}
+	return &fs.PathError{
+		Op:   "parsecache." + op,
+		Path: src.path,
+		Err:  err,
+	}
+}
+
+// newSource returns the `source` for the cleaned `path` in a filesystem. If the filesystem
+// implements `fs.StatFS`, the source opens a `*statFile`, which is only opened once it's read.
+func newSource(filesystem fs.FS, path string) source {
+	if statFS, ok := filesystem.(fs.StatFS); ok {
+		return source{
+			path:  path,
+			open:  openStatFile(statFS, fsName(path)),
+			stats: true,
+		}
+	}
+	src := source{
+		path: path,
+		open: opener(filesystem, path),
+	}
+	if readDirFS, ok := filesystem.(fs.ReadDirFS); ok {
+		name := fsName(path)
+		src.readDir = func() ([]fs.DirEntry, error) {
+			return readDirFS.ReadDir(name)
+		}
+	}
+	return src
 }
 
 // opener returns an function that opens the specified, cleaned path, in a filesystem. It is for
 // internal use. Paths should be cleaned by `cleanPath` before being passed to this function.
 func opener(filesystem fs.FS, path string) func() (fs.File, error) {
-	if path == "/" {
-		path = "."
-	} else if path[0] == '/' || path[0] == os.PathSeparator {
-		path = path[1:]
-	} else {
-		panic("path not cleaned correctly")
-	}
+	path = fsName(path)
 	return func() (fs.File, error) {
 		return filesystem.Open(path)
 	}
 }
 
+// fsName returns the name in a filesystem of the specified, cleaned path.
+func fsName(path string) string {
+	if path == "/" {
+		return "."
+	} else if path[0] == '/' {
+		return path[1:]
+	}
+	panic("path not cleaned correctly")
+}
+
+// Cache is the interface implemented by `*FsCache`, `*ConcurrentFsCache` and the types which wrap
+// them.
+type Cache[T any] interface {
+	// GetFile returns the parsed content of a file, which may be cached.
+	GetFile(file string) (T, error)
+	// GetDir gets the entries of a directory, which may be cached.
+	GetDir(dir string) ([]fs.DirEntry, error)
+	// Clear the cache.
+	Clear()
+}
+
+var (
+	_ Cache[any] = (*FsCache[any])(nil)
+	_ Cache[any] = (*ConcurrentFsCache[any])(nil)
+)
+
 // FsCache is a cache on top of a generic filesystem. It's not safe for concurrent use, use
 // `ConcurrentFsCache` for a thread-safe version.
 //
@@ -48,50 +187,122 @@ type FsCache[T any] struct {
 	// MaxAge is the maximum allowed age of a cache entry.
 	MaxAge time.Duration
 
+	// options is the configuration set when the cache was created.
+	options options[T]
+
+	// breakers is the circuit breaker state of files, it's nil if there's no circuit breaker.
+	breakers *circuitBreakers
+
+	// notExist is the filter of files which don't exist, it's nil if there's no filter.
+	notExist *notExistFilter
+
+	// evictions is the number of files evicted because of `WithMaxEntries`.
+	evictions uint64
+
 	// dirs is the map of cleanedPath -> cachedDir
 	dirs map[string]*CachedDir
 
 	// files is the map of cleanedPath -> cachedFile
 	files map[string]*CachedFile[T]
+
+	// archives is the map of cleanedPath -> cachedArchive
+	archives map[string]*CachedFile[*archive[T]]
 }
 
 // FsCache is a concurrency safe cache on top of a generic filesystem.
 //
 // It can cache directory listings and parsed file content.
 type ConcurrentFsCache[T any] struct {
-	// fs is the underlying filesystem, it is assumed that this is safe for concurrent use.
-	fs fs.FS
+	// settings is the `*cacheSettings` holding the filesystem, parser and maximum age of the cache,
+	// it may be read without a lock. Replacing it must hold `settingsLock`.
+	settings     atomic.Value
+	settingsLock sync.Mutex
 
-	// parser is the function used to parse a file.
-	parser Parser[T]
+	// options is the configuration set when the cache was created, it may be read without a lock.
+	options options[T]
 
-	// maxAge is the maximum allowed age of a cache entry.
-	//
-	// Since this is used by both file and directory caching, reading must acquire at least one of
-	// `dirsLock` or `filesLock` and writing to must acquire both.
-	maxAge time.Duration
+	// breakers is the circuit breaker state of files, it's nil if there's no circuit breaker.
+	breakers *circuitBreakers
 
-	// dirs is the map of cleanedPath -> cachedDir
-	dirs     map[string]*ConcurrentCachedDir
-	dirsLock sync.RWMutex
+	// notExist is the filter of files which don't exist, it's nil if there's no filter.
+	notExist *notExistFilter
 
-	// files is the map of cleanedPath -> cachedFile
-	files     map[string]*ConcurrentCachedFile[T]
-	filesLock sync.RWMutex
+	// evictions is the number of files evicted because of `WithMaxEntries`. It must be accessed
+	// atomically.
+	evictions uint64
+
+	// shards hold the cached files and directories, see `WithShards`. Each path is in the shard
+	// returned by `shard`.
+	shards []cacheShard[T]
+
+	// archives is the map of cleanedPath -> cachedArchive
+	archives     map[string]*ConcurrentCachedFile[*concurrentArchive[T]]
+	archivesLock sync.RWMutex
+
+	// background is the goroutines the cache runs in the background, which are stopped by `Close`.
+	background backgroundTasks
+
+	// events is the channel returned by `Events`.
+	events cacheEvents
 }
 
+// SetMaxAge sets the maximum age of cache entries to `maxAge`.
 func (cache *ConcurrentFsCache[T]) SetMaxAge(maxAge time.Duration) {
-	cache.filesLock.Lock()
-	cache.dirsLock.Lock()
-	cache.maxAge = maxAge
-	cache.dirsLock.Unlock()
-	cache.filesLock.Unlock()
+	cache.updateSettings(func(settings *cacheSettings[T]) {
+		settings.maxAge = maxAge
+	})
+}
+
+// GetMaxAge returns the maximum age of cache entries, as set by `NewConcurrentFsCache` or
+// `SetMaxAge`.
+func (cache *ConcurrentFsCache[T]) GetMaxAge() time.Duration {
+	return cache.loadSettings().maxAge
+}
+
+// SetFS replaces the filesystem of the cache with `fs`, which must be safe for concurrent use.
+//
+// The cached entries are kept, and are revalidated against the new filesystem, by their size and
+// modtime, once they reach their maximum age. Use `Clear` to discard them instead. Loads which are
+// already in progress complete using the old filesystem.
+func (cache *ConcurrentFsCache[T]) SetFS(fs fs.FS) {
+	cache.updateSettings(func(settings *cacheSettings[T]) {
+		settings.fs = fs
+	})
+}
+
+// SetParser replaces the parser used for future cache misses with `parser`. Parsers set by options,
+// such as `WithParserFor` and `WithPathParser`, still take precedence.
+//
+// The cached entries keep their parsed content, and are only parsed again by `parser` once they
+// reach their maximum age and the file has changed. Use `ClearFiles` to parse them again instead.
+func (cache *ConcurrentFsCache[T]) SetParser(parser Parser[T]) {
+	cache.updateSettings(func(settings *cacheSettings[T]) {
+		settings.parser = parser.withContext()
+	})
+}
+
+// Parser returns the parser used for cache misses, which was given to the constructor or
+// `SetParser`. If it's a `ParserCtx`, it's passed `context.Background()`.
+func (cache *ConcurrentFsCache[T]) Parser() Parser[T] {
+	parser := cache.ParserCtx()
+	return func(f io.Reader) (T, error) {
+		return parser(context.Background(), f)
+	}
+}
+
+// ParserCtx returns the parser used for cache misses, like `Parser`, but as a `ParserCtx`.
+func (cache *ConcurrentFsCache[T]) ParserCtx() ParserCtx[T] {
+	return cache.loadSettings().parser
 }
 
 // ConcurrentCachedDir is a concurrency-safe wrapper around a `CachedDir`.
 type ConcurrentCachedDir struct {
 	lock      sync.RWMutex
 	cachedDir CachedDir
+
+	// last holds the entries that were last successfully loaded, so they can be read without
+	// waiting for `lock`, which is held for the duration of a load.
+	last atomic.Value
 }
 
 // CachedDir stores a cache entry for a directory.
@@ -99,18 +310,49 @@ type CachedDir struct {
 	// lastLoadTime is the time the cache was last *successfully* loaded or revalidated.
 	// It will be nil if this has never occurred.
 	lastLoadTime time.Time
+	// stale is true if the entry was invalidated by `Invalidate` since it was last loaded, so it's
+	// revalidated by the next get, whatever its age.
+	stale bool
 	// lastSize is the filesize of the cache entry
 	lastSize int64
 	// lastModTime is the modtime of the cache entry
 	lastModTime time.Time
+	// lastMode is the file mode of the cache entry
+	lastMode fs.FileMode
+	// lastToken is the token of the directory stamped by `DefaultValidator`, which the directory is
+	// revalidated by.
+	lastToken any
+	// ttlOffset is the offset of the maximum age of the entry chosen when it was last loaded, if
+	// `WithTTLJitter` is used.
+	ttlOffset time.Duration
 	// entries is the value that was last *successfully* loaded.
 	entries []fs.DirEntry
+	// entryInfos is the info of each of the entries, and infos the `fs.FileInfo` of each of the
+	// entries which could be read, if they've been read since the entry was last loaded or
+	// revalidated, at `infosLoadTime`.
+	entryInfos    []DirEntryInfo
+	infos         []fs.FileInfo
+	infosLoadTime time.Time
+	// names are the sorted names of the entries, if they've been read since the entries last
+	// changed.
+	names []string
+	// hits is the number of times the entries were returned without loading or revalidating the
+	// directory. It must be accessed atomically.
+	hits uint64
 }
 
 // ConcurrentCachedFile is a concurrency-safe wrapper around a `CachedFile`.
 type ConcurrentCachedFile[T any] struct {
 	lock       sync.RWMutex
 	cachedFile CachedFile[T]
+
+	// last holds a pointer to the content that was last successfully loaded, so it can be read
+	// without waiting for `lock`, which is held for the duration of a load.
+	last atomic.Value
+
+	// lastUsed is the time, in nanoseconds since the Unix epoch, that the entry was last accessed
+	// through the cache. It must be accessed atomically.
+	lastUsed int64
 }
 
 // CachedFile stores a cache entry for a file.
@@ -118,22 +360,59 @@ type CachedFile[T any] struct {
 	// lastLoadTime is the time the cache was last *successfully* loaded or revalidated.
 	// It will be nil if this has never occurred.
 	lastLoadTime time.Time
+	// stale is true if the entry was invalidated by `Invalidate` since it was last loaded, so it's
+	// revalidated by the next get, whatever its age.
+	stale bool
 	// lastSize is the filesize of the cache entry
 	lastSize int64
 	// lastModTime is the modtime of the cache entry
 	lastModTime time.Time
-	// entries is the value that was last *successfully* loaded and parsed from the file.
+	// lastMode is the file mode of the cache entry
+	lastMode fs.FileMode
+	// lastToken is the token of the file stamped by the `Validator` which the entry is revalidated
+	// by, see `loadConfig.fileValidator`.
+	lastToken any
+	// sum is the SHA-256 of the content of the file when it was last parsed, if `WithSHA256` is
+	// used.
+	sum [32]byte
+	// entries is the value that was last *successfully* loaded and parsed from the file. It's only
+	// replaced once a parse succeeds, but a parser which mutates a value it shares with it, such as
+	// one which decodes into the same pointer each time, can modify it partway through a parse
+	// which then fails. Use `GetFileAtomic` to rule that out.
 	content T
+	// compressed, if `WithCompression` is used, is the compressed encoding of the content, which is
+	// kept instead of `content`.
+	compressed []byte
+	// lastUsed is the time the entry was last accessed through an `FsCache`.
+	lastUsed time.Time
+	// ttl is the adaptive maximum age of the entry, set once it's loaded if `WithAdaptiveTTL` is
+	// used.
+	ttl time.Duration
+	// ttlOffset is the offset of the maximum age of the entry chosen when it was last loaded, if
+	// `WithTTLJitter` is used.
+	ttlOffset time.Duration
+	// parses is the number of times the file has been parsed into the entry.
+	parses uint64
+	// hits is the number of times the content was returned without loading or revalidating the
+	// file. It must be accessed atomically.
+	hits uint64
+	// notExistErr, if set by `WithNegativeCacheTTL`, is the error from finding that the file doesn't
+	// exist at `notExistAt`, in which case the entry isn't loaded.
+	notExistErr error
+	notExistAt  time.Time
 }
 
 // NewFsCache creates a new cache on top of the `fs` filesystem, using `parser` to parse the content
 // of files and sets the maximum age of cache entries to `maxAge`.
-func NewFsCache[T any](fs fs.FS, parser Parser[T], maxAge time.Duration) FsCache[T] {
+func NewFsCache[T any](fs fs.FS, parser Parser[T], maxAge time.Duration, opts ...Option[T]) FsCache[T] {
 	cache := FsCache[T]{
-		fs:     fs,
-		parser: parser,
-		MaxAge: maxAge,
+		fs:      fs,
+		parser:  parser,
+		MaxAge:  maxAge,
+		options: newOptions(opts),
 	}
+	cache.breakers = newCircuitBreakers(cache.options.circuitBreaker)
+	cache.notExist = newNotExistFilter(cache.options.notExistFilter)
 	cache.Clear()
 	return cache
 }
@@ -143,98 +422,221 @@ func NewFsCache[T any](fs fs.FS, parser Parser[T], maxAge time.Duration) FsCache
 // `maxAge`.
 //
 // `fs` must be safe for concurrent use.
-func NewConcurrentFsCache[T any](fs fs.FS, parser Parser[T], maxAge time.Duration) *ConcurrentFsCache[T] {
+func NewConcurrentFsCache[T any](fs fs.FS, parser Parser[T], maxAge time.Duration, opts ...Option[T]) *ConcurrentFsCache[T] {
+	return NewConcurrentFsCacheCtx(fs, parser.withContext(), maxAge, opts...)
+}
+
+// NewConcurrentFsCacheCtx is like `NewConcurrentFsCache`, but takes a `ParserCtx`, which is passed
+// the context given to `GetFileCtx` (or `context.Background()` for `GetFile`).
+func NewConcurrentFsCacheCtx[T any](fs fs.FS, parser ParserCtx[T], maxAge time.Duration, opts ...Option[T]) *ConcurrentFsCache[T] {
 	cache := ConcurrentFsCache[T]{
-		fs:     fs,
-		parser: parser,
-		maxAge: maxAge,
+		options: newOptions(opts),
 	}
+	cache.settings.Store(&cacheSettings[T]{fs: fs, parser: parser, maxAge: maxAge})
+	cache.shards = newShards[T](cache.options.shards)
+	cache.breakers = newCircuitBreakers(cache.options.circuitBreaker)
+	cache.notExist = newNotExistFilter(cache.options.notExistFilter)
 	cache.Clear()
 	return &cache
 }
 
+// NewFsCacheFromEmbed returns a new cache, safe for concurrent access, on top of an embedded
+// filesystem, using `parser` to parse the content of files.
+//
+// The content of an `embed.FS` never changes, so the maximum age of entries is unlimited: each file
+// is opened, stat-ed and parsed once, and every later access is served from memory.
+func NewFsCacheFromEmbed[T any](fsys embed.FS, parser Parser[T], opts ...Option[T]) *ConcurrentFsCache[T] {
+	return NewConcurrentFsCache[T](fsys, parser, forever, opts...)
+}
+
+// SetParser replaces the parser used for future cache misses with `parser`. Parsers set by options,
+// such as `WithParserFor` and `WithPathParser`, still take precedence.
+//
+// The cached entries keep their parsed content, and are only parsed again by `parser` once they
+// reach their maximum age and the file has changed. Use `ClearFiles` to parse them again instead.
+func (cache *FsCache[T]) SetParser(parser Parser[T]) {
+	cache.parser = parser
+}
+
+// Parser returns the parser used for cache misses, which was given to `NewFsCache` or `SetParser`.
+func (cache *FsCache[T]) Parser() Parser[T] {
+	return cache.parser
+}
+
 // GetDirEntry gets the `CachedDir` for the path if one exists.
 func (cache *FsCache[T]) GetDirEntry(path string) (entry *CachedDir, ok bool) {
-	entry, ok = cache.dirs[cleanPath(path)]
+	entry, ok = cache.dirs[cache.options.key(cache.normalize(path))]
 	return
 }
 
-// GetDir gets the entries of a directory, which may be cached.
+// GetDir gets the entries of a directory, which may be cached. The entries are copied into a new
+// slice, which the caller may modify, see `GetDirShared`.
 func (cache *FsCache[T]) GetDir(dir string) ([]fs.DirEntry, error) {
 	return cache.GetDirWithMaxAge(dir, cache.MaxAge)
 }
 
-// GetDirWithMaxAge gets the entries of a directory, with the specified maximum age.
+// GetDirWithMaxAge gets the entries of a directory, with the specified maximum age. The entries
+// are copied into a new slice, which the caller may modify.
 func (cache *FsCache[T]) GetDirWithMaxAge(dir string, maxAge time.Duration) ([]fs.DirEntry, error) {
-	path := cleanPath(dir)
-	cached, ok := cache.dirs[path]
+	entries, err := cache.getDir(dir, maxAge, nil)
+	return copyEntries(entries), err
+}
+
+// GetDirShared is `GetDir`, but returns the cached slice of entries itself, rather than a copy, so
+// it doesn't allocate. The slice is shared with every other caller, so it mustn't be modified.
+func (cache *FsCache[T]) GetDirShared(dir string) ([]fs.DirEntry, error) {
+	return cache.getDir(dir, cache.MaxAge, nil)
+}
+
+// getDir gets the cached entries of a directory, with the specified maximum age, recording how
+// they were got in `result`, if it isn't nil.
+func (cache *FsCache[T]) getDir(dir string, maxAge time.Duration, result *GetResult) ([]fs.DirEntry, error) {
+	if err := cache.options.checkPath(dir); err != nil {
+		return nil, err
+	}
+	path := cache.normalize(dir)
+	if err := checkNormalized(dir, path); err != nil {
+		return nil, err
+	}
+	key := cache.options.key(path)
+	cached, ok := cache.dirs[key]
 	if !ok {
 		cached = &CachedDir{}
-		cache.dirs[path] = cached
+		cache.dirs[key] = cached
 	}
-	entries, err := cached.Get(opener(cache.fs, path), maxAge)
+	before := cached.lastLoadTime
+	config := cache.options.load
+	config.result = result
+	entries, err := cached.get(context.Background(), newSource(cache.fs, cache.options.openPath(path)), maxAge, config)
+	logLoad(cache.options.logger, "dir", path, before, cached.lastLoadTime, err)
 	if err != nil {
-		delete(cache.dirs, path)
+		delete(cache.dirs, key)
 	}
 	return entries, err
 }
 
 // GetFileEntry gets the `CachedFile` for the path if one exists.
 func (cache *FsCache[T]) GetFileEntry(path string) (entry *CachedFile[T], ok bool) {
-	entry, ok = cache.files[cleanPath(path)]
+	entry, ok = cache.files[cache.options.key(cache.normalize(path))]
 	return
 }
 
 // GetFile returns the parsed content of a file, which may be cached.
 func (cache *FsCache[T]) GetFile(file string) (T, error) {
-	return cache.GetFileWithMaxAge(file, cache.MaxAge)
+	return cache.getFile(file, cache.MaxAge, cache.options.load, nil)
 }
 
 // GetFileWithMaxAge returns the parsed content of a file, with the specified maximum age.
 func (cache *FsCache[T]) GetFileWithMaxAge(file string, maxAge time.Duration) (T, error) {
-	path := cleanPath(file)
-	cached, ok := cache.files[path]
+	config := cache.options.load
+	config.adaptiveTTL = AdaptiveTTL{}
+	return cache.getFile(file, maxAge, config, nil)
+}
+
+// getFile returns the parsed content of a file, with the specified maximum age, loading it with
+// `config`. If `parser` isn't nil, the file is parsed with it, instead of the cache's parser.
+func (cache *FsCache[T]) getFile(file string, maxAge time.Duration, config loadConfig, parser fileParser[T]) (T, error) {
+	if err := cache.options.checkPath(file); err != nil {
+		var zero T
+		return zero, err
+	}
+	path := cache.normalize(file)
+	if err := checkNormalized(file, path); err != nil {
+		var zero T
+		return zero, err
+	}
+	if err := cache.notExist.check(path); err != nil {
+		var zero T
+		return zero, err
+	}
+	if err := cache.breakers.check(path); err != nil {
+		var zero T
+		return zero, err
+	}
+	key := cache.options.key(path)
+	cached, ok := cache.files[key]
 	if !ok {
 		cached = &CachedFile[T]{}
-		cache.files[path] = cached
+		cache.files[key] = cached
 	}
-	content, err := cached.Get(opener(cache.fs, path), cache.parser, maxAge)
-	if err != nil {
-		delete(cache.files, path)
+	cached.lastUsed = time.Now()
+	before := cached.lastLoadTime
+	if parser == nil {
+		parser = cache.parserFor(path)
+	}
+	content, err := cached.get(context.Background(), newSource(cache.fs, cache.options.openPath(path)), cache.options.withSecondLevel(key, parser), maxAge, config)
+	logLoad(cache.options.logger, "file", path, before, cached.lastLoadTime, err)
+	cache.breakers.record(path, err)
+	cache.notExist.record(path, err)
+	if (err != nil && cached.notExistErr == nil) || (err == nil && cache.options.tooLarge(path, cached.lastSize)) {
+		delete(cache.files, key)
+	} else if !ok {
+		cache.evictFiles(key)
 	}
 	return content, err
 }
 
 // GetDirEntry gets the `ConcurrentCachedDir` for the path if one exists.
 func (cache *ConcurrentFsCache[T]) GetDirEntry(path string) (entry *ConcurrentCachedDir, ok bool) {
-	cache.dirsLock.RLock()
-	defer cache.dirsLock.RUnlock()
-	entry, ok = cache.dirs[cleanPath(path)]
+	path = cache.options.key(cache.normalize(path))
+	shard := cache.shard(path)
+	shard.dirsLock.RLock()
+	defer shard.dirsLock.RUnlock()
+	entry, ok = shard.dirs[path]
 	return
 }
 
-// GetDir gets the entries of a directory, which may be cached.
+// GetDir gets the entries of a directory, which may be cached. The entries are copied into a new
+// slice, which the caller may modify, see `GetDirShared`.
 func (cache *ConcurrentFsCache[T]) GetDir(dir string) ([]fs.DirEntry, error) {
-	return cache.getDir(dir, 0, false)
+	entries, err := cache.getDir(context.Background(), dir, 0, false, nil)
+	return copyEntries(entries), err
 }
 
-// GetDirWithMaxAge gets the entries of a directory, with the specified maximum age.
+// GetDirWithMaxAge gets the entries of a directory, with the specified maximum age. The entries
+// are copied into a new slice, which the caller may modify.
 func (cache *ConcurrentFsCache[T]) GetDirWithMaxAge(dir string, maxAge time.Duration) ([]fs.DirEntry, error) {
-	return cache.getDir(dir, maxAge, true)
+	entries, err := cache.getDir(context.Background(), dir, maxAge, true, nil)
+	return copyEntries(entries), err
+}
+
+// GetDirShared is `GetDir`, but returns the cached slice of entries itself, rather than a copy, so
+// it doesn't allocate. The slice is shared with every other caller, across goroutines, so it
+// mustn't be modified.
+func (cache *ConcurrentFsCache[T]) GetDirShared(dir string) ([]fs.DirEntry, error) {
+	return cache.getDir(context.Background(), dir, 0, false, nil)
+}
+
+// copyEntries returns a copy of `entries`, or nil if there are none.
+func copyEntries(entries []fs.DirEntry) []fs.DirEntry {
+	if entries == nil {
+		return nil
+	}
+	return append(make([]fs.DirEntry, 0, len(entries)), entries...)
 }
 
 // getDir gets the entries of a directory. The maximum age is `maxAge` if `useMaxAge` or
-// `cache.maxAge` otherwise.
-func (cache *ConcurrentFsCache[T]) getDir(dir string, maxAge time.Duration, useMaxAge bool) ([]fs.DirEntry, error) {
-	path := cleanPath(dir)
+// `cache.maxAge` otherwise. A load gives up once `ctx` is done, and how the entries were got is
+// recorded in `result`, if it isn't nil.
+func (cache *ConcurrentFsCache[T]) getDir(ctx context.Context, dir string, maxAge time.Duration, useMaxAge bool, result *GetResult) ([]fs.DirEntry, error) {
+	if err := cache.options.checkPath(dir); err != nil {
+		return nil, err
+	}
+	path := cache.normalize(dir)
+	if err := checkNormalized(dir, path); err != nil {
+		return nil, err
+	}
 
-	// Read the existing cache entry (if it exists) and the maxAge
-	cache.dirsLock.RLock()
-	cached, ok := cache.dirs[path]
+	// Read the existing cache entry (if it exists), the maxAge and the filesystem
+	key := cache.options.key(path)
+	shard := cache.shard(key)
+	shard.dirsLock.RLock()
+	cached, ok := shard.dirs[key]
+	shard.dirsLock.RUnlock()
+	settings := cache.loadSettings()
 	if !useMaxAge {
-		maxAge = cache.maxAge
+		maxAge = settings.maxAge
 	}
-	cache.dirsLock.RUnlock()
 
 	// Create a new entry if one didn't exist, we'll insert this later, if the load is successful.
 	if !ok {
@@ -242,13 +644,23 @@ func (cache *ConcurrentFsCache[T]) getDir(dir string, maxAge time.Duration, useM
 	}
 
 	// Get the content from the entry!
-	entries, err := cached.Get(opener(cache.fs, path), maxAge)
+	var before time.Time
+	if cache.options.logger != nil {
+		_, before, _ = cached.Cached()
+	}
+	config := cache.options.load
+	config.result = result
+	entries, err := cached.get(ctx, newSource(settings.fs, cache.options.openPath(path)), maxAge, config)
+	if cache.options.logger != nil {
+		_, after, _ := cached.Cached()
+		logLoad(cache.options.logger, "dir", path, before, after, err)
+	}
 
 	// Insert the new entry if required
 	if !ok && err == nil {
-		cache.dirsLock.Lock()
-		cache.dirs[path] = cached
-		cache.dirsLock.Unlock()
+		shard.dirsLock.Lock()
+		shard.dirs[key] = cached
+		shard.dirsLock.Unlock()
 	}
 
 	return entries, err
@@ -256,48 +668,105 @@ func (cache *ConcurrentFsCache[T]) getDir(dir string, maxAge time.Duration, useM
 
 // GetFileEntry gets the `CachedFile` for the path if one exists.
 func (cache *ConcurrentFsCache[T]) GetFileEntry(path string) (entry *ConcurrentCachedFile[T], ok bool) {
-	cache.filesLock.RLock()
-	defer cache.filesLock.RUnlock()
-	entry, ok = cache.files[cleanPath(path)]
+	path = cache.options.key(cache.normalize(path))
+	shard := cache.shard(path)
+	shard.filesLock.RLock()
+	defer shard.filesLock.RUnlock()
+	entry, ok = shard.files[path]
 	return
 }
 
 // GetFile returns the parsed content of a file, which may be cached.
 func (cache *ConcurrentFsCache[T]) GetFile(file string) (T, error) {
-	return cache.getFile(file, 0, false)
+	return cache.getFile(context.Background(), file, 0, false, nil, nil)
 }
 
 // GetFileWithMaxAge returns the parsed content of a file, which may be cached.
 func (cache *ConcurrentFsCache[T]) GetFileWithMaxAge(file string, maxAge time.Duration) (T, error) {
-	return cache.getFile(file, maxAge, true)
+	return cache.getFile(context.Background(), file, maxAge, true, nil, nil)
 }
 
 // getFile gets the entries of a directory. The maximum age is `maxAge` if `useMaxAge` or
-// `cache.maxAge` otherwise.
-func (cache *ConcurrentFsCache[T]) getFile(file string, maxAge time.Duration, useMaxAge bool) (T, error) {
-	path := cleanPath(file)
+// `cache.maxAge` otherwise. `ctx` is passed to the parser, and how the file was got is recorded in
+// `result`, if it isn't nil. If `parser` isn't nil, the file is parsed with it, instead of the
+// cache's parser.
+func (cache *ConcurrentFsCache[T]) getFile(ctx context.Context, file string, maxAge time.Duration, useMaxAge bool, result *GetResult, parser fileParser[T]) (T, error) {
+	if err := cache.options.checkPath(file); err != nil {
+		var zero T
+		return zero, err
+	}
+	path := cache.normalize(file)
+	if err := checkNormalized(file, path); err != nil {
+		var zero T
+		return zero, err
+	}
 
-	// Read the existing cache entry (if it exists) and the maxAge
-	cache.filesLock.RLock()
-	cached, ok := cache.files[path]
+	// Read the existing cache entry (if it exists), the maxAge and the filesystem
+	key := cache.options.key(path)
+	shard := cache.shard(key)
+	shard.filesLock.RLock()
+	cached, ok := shard.files[key]
+	shard.filesLock.RUnlock()
+	settings := cache.loadSettings()
 	if !useMaxAge {
-		maxAge = cache.maxAge
+		maxAge = settings.maxAge
+	}
+	config := cache.options.load
+	config.result = result
+	if useMaxAge {
+		config.adaptiveTTL = AdaptiveTTL{}
 	}
-	cache.filesLock.RUnlock()
 
 	// Create a new entry if one didn't exist, we'll insert this later, if the load is successful.
 	if !ok {
 		cached = &ConcurrentCachedFile[T]{}
 	}
+	atomic.StoreInt64(&cached.lastUsed, time.Now().UnixNano())
 
-	// Get the content from the entry!
-	content, err := cached.Get(opener(cache.fs, path), cache.parser, maxAge)
+	// Don't touch the filesystem if the file is known not to exist, or the circuit is open.
+	if err := cache.notExist.check(path); err != nil {
+		var zero T
+		return zero, err
+	}
+	if err := cache.breakers.check(path); err != nil {
+		content, _ := cached.lastLoaded()
+		return content, err
+	}
 
-	// Insert the new entry if required
-	if !ok && err == nil {
-		cache.filesLock.Lock()
-		cache.files[path] = cached
-		cache.filesLock.Unlock()
+	// Get the content from the entry!
+	var before time.Time
+	events := cache.events.enabled()
+	if cache.options.logger != nil || events {
+		_, before, _ = cached.Cached()
+	}
+	if parser == nil {
+		parser = cache.parserFor(path, settings)
+	}
+	content, err := cached.get(ctx, newSource(settings.fs, cache.options.openPath(path)), cache.options.withSecondLevel(key, parser), maxAge, config)
+	if cache.options.logger != nil || events {
+		_, after, _ := cached.Cached()
+		logLoad(cache.options.logger, "file", path, before, after, err)
+		if events {
+			cache.events.load(path, before, after, err)
+		}
+	}
+	cache.breakers.record(path, err)
+	cache.notExist.record(path, err)
+
+	// Insert the new entry if required, or remove it if the file is too large to be cached.
+	if err == nil && cache.options.tooLarge(path, cached.Size()) {
+		if ok {
+			shard.filesLock.Lock()
+			if shard.files[key] == cached {
+				delete(shard.files, key)
+			}
+			shard.filesLock.Unlock()
+		}
+	} else if !ok && (err == nil || cached.notExistCached()) {
+		shard.filesLock.Lock()
+		shard.files[key] = cached
+		shard.filesLock.Unlock()
+		cache.notifyEvicted(cache.evictFiles(key))
 	}
 
 	return content, err
@@ -308,9 +777,12 @@ func (cache *FsCache[T]) ClearDirs() {
 	cache.dirs = make(map[string]*CachedDir, 4)
 }
 
-// ClearFile from the cache.
+// ClearFile from the cache, including files inside archives.
 func (cache *FsCache[T]) ClearFiles() {
+	cache.breakers.clear()
+	cache.notExist.clear()
 	cache.files = make(map[string]*CachedFile[T], 16)
+	cache.archives = make(map[string]*CachedFile[*archive[T]])
 }
 
 // Clear the cache.
@@ -321,16 +793,27 @@ func (cache *FsCache[T]) Clear() {
 
 // ClearDirs from the cache.
 func (cache *ConcurrentFsCache[T]) ClearDirs() {
-	cache.dirsLock.Lock()
-	defer cache.dirsLock.Unlock()
-	cache.dirs = make(map[string]*ConcurrentCachedDir, 4)
+	for i := range cache.shards {
+		shard := &cache.shards[i]
+		shard.dirsLock.Lock()
+		shard.dirs = make(map[string]*ConcurrentCachedDir, 4)
+		shard.dirsLock.Unlock()
+	}
 }
 
-// ClearFiles from the cache.
+// ClearFiles from the cache, including files inside archives.
 func (cache *ConcurrentFsCache[T]) ClearFiles() {
-	cache.filesLock.Lock()
-	defer cache.filesLock.Unlock()
-	cache.files = make(map[string]*ConcurrentCachedFile[T], 16)
+	cache.breakers.clear()
+	cache.notExist.clear()
+	for i := range cache.shards {
+		shard := &cache.shards[i]
+		shard.filesLock.Lock()
+		shard.files = make(map[string]*ConcurrentCachedFile[T], 16)
+		shard.filesLock.Unlock()
+	}
+	cache.archivesLock.Lock()
+	cache.archives = make(map[string]*ConcurrentCachedFile[*concurrentArchive[T]])
+	cache.archivesLock.Unlock()
 }
 
 // Clear the cache.
@@ -364,139 +847,730 @@ func (f *ConcurrentCachedFile[T]) Cached() (T, time.Time, bool) {
 // Cached returns the cached content, the time it was cached, and a boolean, which is true only if
 // the cache entry has been loaded.
 func (f *CachedFile[T]) Cached() (T, time.Time, bool) {
-	return f.content, f.lastLoadTime, !f.lastLoadTime.IsZero()
+	content, _ := f.value(source{})
+	return content, f.lastLoadTime, !f.lastLoadTime.IsZero()
+}
+
+// Size returns the size of the directory when it was last loaded.
+func (f *ConcurrentCachedDir) Size() int64 {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return f.cachedDir.Size()
+}
+
+// Size returns the size of the directory when it was last loaded.
+func (f *CachedDir) Size() int64 {
+	return f.lastSize
+}
+
+// ModTime returns the modtime of the directory when it was last loaded.
+func (f *ConcurrentCachedDir) ModTime() time.Time {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return f.cachedDir.ModTime()
+}
+
+// ModTime returns the modtime of the directory when it was last loaded.
+func (f *CachedDir) ModTime() time.Time {
+	return f.lastModTime
+}
+
+// Size returns the size of the file when it was last loaded.
+func (f *ConcurrentCachedFile[T]) Size() int64 {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return f.cachedFile.Size()
+}
+
+// Size returns the size of the file when it was last loaded.
+func (f *CachedFile[T]) Size() int64 {
+	return f.lastSize
+}
+
+// ModTime returns the modtime of the file when it was last loaded.
+func (f *ConcurrentCachedFile[T]) ModTime() time.Time {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return f.cachedFile.ModTime()
+}
+
+// ModTime returns the modtime of the file when it was last loaded.
+func (f *CachedFile[T]) ModTime() time.Time {
+	return f.lastModTime
 }
 
 // Get the directory entries, the results may be cached upto the specified `maxAge`.
 //
 // `open` should open the underlying file is required, this will be once or not at all.
 func (f *ConcurrentCachedDir) Get(open func() (fs.File, error), maxAge time.Duration) ([]fs.DirEntry, error) {
+	return f.get(context.Background(), source{open: open}, maxAge, loadConfig{})
+}
+
+// get is `Get`, loading with `config`, and giving up on a load once `ctx` is done.
+func (f *ConcurrentCachedDir) get(ctx context.Context, src source, maxAge time.Duration, config loadConfig) ([]fs.DirEntry, error) {
 	// Ideally, return only with a read lock!
-	entries, cachedAt, ok := f.Cached()
-	if ok && time.Since(cachedAt) < maxAge {
-		return entries, nil
+	f.lock.RLock()
+	if f.cachedDir.fresh(time.Now(), maxAge) {
+		defer f.lock.RUnlock()
+		atomic.AddUint64(&f.cachedDir.hits, 1)
+		config.result.hit(f.cachedDir.lastLoadTime)
+		return f.cachedDir.entries, nil
 	}
+	f.lock.RUnlock()
+
+	// Otherwise we call the underlying get method with a write lock. Failed loads are retried here,
+	// rather than by the underlying get, so that the lock isn't held between attempts.
+	retry := config.retry
+	config.retry = retryConfig{}
+	return withRetry(ctx, retry, func() ([]fs.DirEntry, error) {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		entries, err := f.cachedDir.get(ctx, src, maxAge, config)
+		if err == nil {
+			f.last.Store(entries)
+		}
+		return entries, err
+	})
+}
 
-	// Otherwise we call the underlying get method with a write lock.
-	f.lock.Lock()
-	defer f.lock.Unlock()
-	return f.cachedDir.Get(open, maxAge)
+// lastLoaded returns the entries that were last successfully loaded, and whether there are any,
+// without waiting for an in-progress load.
+func (f *ConcurrentCachedDir) lastLoaded() ([]fs.DirEntry, bool) {
+	last, ok := f.last.Load().([]fs.DirEntry)
+	return last, ok
 }
 
 // Get the directory entries, the results may be cached upto the specified `maxAge`.
 //
 // `open` should open the underlying file is required, this will be once or not at all.
 func (f *CachedDir) Get(open func() (fs.File, error), maxAge time.Duration) ([]fs.DirEntry, error) {
+	return f.get(context.Background(), source{open: open}, maxAge, loadConfig{})
+}
+
+// get is `Get`, loading with `config`, and giving up on a load once `ctx` is done.
+func (f *CachedDir) get(ctx context.Context, src source, maxAge time.Duration, config loadConfig) ([]fs.DirEntry, error) {
 	loaded := !f.lastLoadTime.IsZero()
 	loadTime := time.Now()
 
 	// Always use the cached result if it's not too old.
-	if loaded && loadTime.Sub(f.lastLoadTime) < maxAge {
+	if f.fresh(loadTime, maxAge) {
+		atomic.AddUint64(&f.hits, 1)
+		config.result.hit(f.lastLoadTime)
 		return f.entries, nil
 	}
 
-	// Otherwise, get the stats to check if this cache entry is still valid.
-	file, err := open()
+	// Otherwise, load the directory, which may only check that this cache entry is still valid.
+	lastToken := f.lastToken
+	load, err := withRetry(ctx, config.retry, func() (dirLoad, error) {
+		load, err := withTimeout(ctx, config.timeout, func() (dirLoad, error) {
+			return loadDir(src, loaded, lastToken, config.dirSort)
+		})
+		if err == ErrLoadTimeout {
+			err = src.wrapErr("load", err)
+		}
+		missed(config, src.path, err)
+		return load, err
+	})
 	if err != nil {
+		config.result.cachedAt(f.lastLoadTime)
 		return f.entries, err
 	}
+	f.lastLoadTime = loadTime
+	f.stale = false
+	f.ttlOffset = config.ttlOffset()
+	config.result.cachedAt(loadTime)
+	if !load.unchanged {
+		f.entries = load.entries
+		f.names = nil
+		f.lastSize = load.size
+		f.lastModTime = load.modTime
+		f.lastMode = load.mode
+		f.lastToken = load.token
+	}
+	if config.dirInfos {
+		f.dirEntryInfos()
+	}
+	return f.entries, nil
+}
+
+// dirLoad is the result of `loadDir`.
+type dirLoad struct {
+	size    int64
+	modTime time.Time
+	mode    fs.FileMode
+	// token is the token of the directory stamped by `DefaultValidator`.
+	token any
+	// unchanged is true if `DefaultValidator` says the directory is the same as the cache entry, in
+	// which case it wasn't read.
+	unchanged bool
+	entries   []fs.DirEntry
+}
+
+// loadDir opens and stats a directory, and reads its entries unless the cache entry is `loaded` and
+// `DefaultValidator` says it's the same as when `lastToken` was stamped. The entries are sorted in
+// `order`. If the opened directory doesn't implement `fs.ReadDirFile`, it's read with
+// `src.readDir`, or fails with `ErrReadDirUnsupported` if that isn't set.
+//
+// It doesn't modify any cache entry, so it's safe to abandon a call which is taking too long.
+func loadDir(src source, loaded bool, lastToken any, order DirSortOrder) (dirLoad, error) {
+	file, err := src.open()
+	if err != nil {
+		return dirLoad{}, src.wrapErr("open", err)
+	}
 	defer file.Close()
 	stats, err := file.Stat()
 	if err != nil {
-		return f.entries, err
+		return dirLoad{}, src.wrapErr("stat", err)
+	}
+	if !stats.IsDir() {
+		return dirLoad{}, src.wrapErr("load", ErrNotADirectory)
+	}
+	load := dirLoad{
+		size:    stats.Size(),
+		modTime: stats.ModTime(),
+		mode:    stats.Mode(),
 	}
-	size := stats.Size()
-	modTime := stats.ModTime()
 
 	// Use the cached result if the mod time and size haven't changed
-	if loaded && size == f.lastSize && modTime == f.lastModTime {
-		f.lastLoadTime = loadTime
-		return f.entries, nil
+	load.token, err = DefaultValidator.Stamp(stats, file)
+	if err != nil {
+		return dirLoad{}, src.wrapErr("validate", err)
+	}
+	if loaded && DefaultValidator.Same(lastToken, load.token) {
+		load.unchanged = true
+		return load, nil
 	}
 
-	// Actually read the file
-	dir, ok := file.(fs.ReadDirFile)
-	if !ok {
-		// TODO
-		panic("directory doesn't implement ReadDirFile")
+	// Actually read the directory
+	if dir, ok := file.(fs.ReadDirFile); ok {
+		load.entries, err = dir.ReadDir(0)
+	} else if src.readDir != nil {
+		load.entries, err = src.readDir()
+	} else {
+		err = ErrReadDirUnsupported
 	}
-	entries, err := dir.ReadDir(0)
 	if err != nil {
-		return f.entries, err
+		return load, src.wrapErr("readdir", err)
 	}
-	f.lastLoadTime = loadTime
-	f.entries = entries
-	f.lastSize = size
-	f.lastModTime = modTime
-	return f.entries, nil
+	sortDirEntries(load.entries, order)
+	return load, nil
 }
 
 // Get the parsed file content, the results may be cached upto the specified `maxAge`.
 //
 // `open` should open the underlying file is required, this will be once or not at all.
 func (f *ConcurrentCachedFile[T]) Get(open func() (fs.File, error), parser Parser[T], maxAge time.Duration) (T, error) {
+	return f.get(context.Background(), source{open: open}, parser.fileParser(), maxAge, loadConfig{})
+}
+
+// GetCtx is like `Get`, but takes a `ParserCtx`, which is passed `ctx`.
+func (f *ConcurrentCachedFile[T]) GetCtx(ctx context.Context, open func() (fs.File, error), parser ParserCtx[T], maxAge time.Duration) (T, error) {
+	return f.get(ctx, source{open: open}, parser.fileParser(), maxAge, loadConfig{})
+}
+
+// get is `GetCtx`, loading with `config`.
+func (f *ConcurrentCachedFile[T]) get(ctx context.Context, src source, parser fileParser[T], maxAge time.Duration, config loadConfig) (T, error) {
 	// Ideally, return only with a read lock!
-	content, cachedAt, ok := f.Cached()
-	if ok && time.Since(cachedAt) < maxAge {
-		return content, nil
+	f.lock.RLock()
+	cachedAt := f.cachedFile.lastLoadTime
+	if !f.cachedFile.stale && !cachedAt.IsZero() && time.Since(cachedAt) < f.cachedFile.maxAge(maxAge, config) {
+		defer f.lock.RUnlock()
+		atomic.AddUint64(&f.cachedFile.hits, 1)
+		config.result.hit(cachedAt)
+		return f.cachedFile.value(src)
+	}
+	if err := f.cachedFile.notExist(time.Now(), config); err != nil {
+		f.lock.RUnlock()
+		var zero T
+		return zero, err
 	}
+	f.lock.RUnlock()
+
+	// Otherwise we call the underlying get method with a write lock. Failed loads are retried here,
+	// rather than by the underlying get, so that the lock isn't held between attempts, and another
+	// load of the file in between is used rather than loading it again.
+	retry := config.retry
+	config.retry = retryConfig{}
+	return withRetry(ctx, retry, func() (T, error) {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		content, err := f.cachedFile.get(ctx, src, parser, maxAge, config)
+		if err == nil {
+			if f.cachedFile.compressed != nil {
+				f.last.Store(&lastContent[T]{compressed: f.cachedFile.compressed})
+			} else {
+				f.last.Store(&lastContent[T]{content: content})
+			}
+		}
+		return content, err
+	})
+}
 
-	// Otherwise we call the underlying get method with a write lock.
-	f.lock.Lock()
-	defer f.lock.Unlock()
-	return f.cachedFile.Get(open, parser, maxAge)
+// lastContent is the content stored in `ConcurrentCachedFile.last`, which is compressed if
+// `WithCompression` is used.
+type lastContent[T any] struct {
+	content    T
+	compressed []byte
+}
+
+// lastLoaded returns the content that was last successfully loaded, and whether there is any,
+// without waiting for an in-progress load.
+func (f *ConcurrentCachedFile[T]) lastLoaded() (T, bool) {
+	last, ok := f.last.Load().(*lastContent[T])
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	if last.compressed != nil {
+		content, err := decompress[T](last.compressed)
+		return content, err == nil
+	}
+	return last.content, true
 }
 
 // Get the parsed file content, the results may be cached upto the specified `maxAge`.
 //
 // `open` should open the underlying file is required, this will be once or not at all.
 func (f *CachedFile[T]) Get(open func() (fs.File, error), parser Parser[T], maxAge time.Duration) (T, error) {
+	return f.get(context.Background(), source{open: open}, parser.fileParser(), maxAge, loadConfig{})
+}
+
+// GetCtx is like `Get`, but takes a `ParserCtx`, which is passed `ctx`.
+func (f *CachedFile[T]) GetCtx(ctx context.Context, open func() (fs.File, error), parser ParserCtx[T], maxAge time.Duration) (T, error) {
+	return f.get(ctx, source{open: open}, parser.fileParser(), maxAge, loadConfig{})
+}
+
+// get is `GetCtx`, loading with `config`.
+func (f *CachedFile[T]) get(ctx context.Context, src source, parser fileParser[T], maxAge time.Duration, config loadConfig) (T, error) {
 	loaded := !f.lastLoadTime.IsZero()
 	loadTime := time.Now()
+	adaptive := config.adaptiveTTL.enabled()
+	maxAge = f.maxAge(maxAge, config)
 
 	// Always use the cached result if it's not too old.
-	if loadTime.Sub(f.lastLoadTime) < maxAge {
+	if !f.stale && (loadTime.Sub(f.lastLoadTime) < maxAge || f.immutable(config)) {
+		atomic.AddUint64(&f.hits, 1)
+		config.result.hit(f.lastLoadTime)
+		return f.value(src)
+	}
+	if err := f.notExist(loadTime, config); err != nil {
+		var zero T
+		return zero, err
+	}
+	if config.result != nil {
+		defer func() {
+			config.result.cachedAt(f.lastLoadTime)
+		}()
+	}
+
+	// Otherwise, load the file, which may only check that this cache entry is still valid.
+	last := fileVersion{f.lastSize, f.lastModTime, f.lastToken, f.lastLoadTime}
+	load, err := withRetry(ctx, config.retry, func() (fileLoad[T], error) {
+		load, err := withTimeout(ctx, config.timeout, func() (fileLoad[T], error) {
+			return loadFile(ctx, src, parser, loaded, last, config)
+		})
+		if err == ErrLoadTimeout {
+			err = src.wrapErr("load", err)
+		}
+		missed(config, src.path, err)
+		return load, err
+	})
+	config.result.parsed(load.parseStart, load.parseDuration)
+	if err != nil && loaded && config.serveStaleOnStatError && load.statFailed && errors.Is(err, fs.ErrNotExist) {
+		// The file was removed between opening and stat-ing it, so the cached content is served,
+		// without revalidating the entry, so the next get tries again.
+		return f.value(src)
+	}
+	same := err == nil && loaded && !load.unchanged && f.sameContent(src, load.content, config)
+	var compressed []byte
+	if err == nil && !load.unchanged && !same && config.compress {
+		compressed, err = compress(load.content)
+		err = src.wrapErr("compress", err)
+	}
+	if err != nil {
+		content, _ := f.value(src)
+		if config.negativeTTL > 0 && errors.Is(err, fs.ErrNotExist) {
+			// Replace the entry with one which remembers that the file doesn't exist.
+			*f = CachedFile[T]{lastUsed: f.lastUsed, parses: f.parses, hits: f.hits, notExistAt: loadTime, notExistErr: err}
+		}
+		return content, err
+	}
+	f.notExistErr = nil
+	f.lastLoadTime = loadTime
+	f.stale = false
+	f.ttlOffset = config.ttlOffset()
+	if adaptive {
+		if loaded {
+			f.ttl = config.adaptiveTTL.next(f.ttl, !load.unchanged && !same)
+		} else {
+			f.ttl = config.adaptiveTTL.clamp(maxAge)
+		}
+	}
+	if load.unchanged {
+		return f.value(src)
+	}
+	f.lastSize = load.size
+	f.lastModTime = load.modTime
+	f.lastMode = load.mode
+	f.lastToken = load.token
+	f.sum = load.sum
+	if same {
+		if config.logger != nil {
+			config.logger.unchanged(src.path)
+		}
+		return f.value(src)
+	}
+	if compressed != nil {
+		var zero T
+		f.content = zero
+	} else {
+		f.content = load.content
+	}
+	f.compressed = compressed
+	f.parses++
+	return load.content, nil
+}
+
+// value returns the content of the entry, decompressing it if it was compressed by
+// `WithCompression`.
+func (f *CachedFile[T]) value(src source) (T, error) {
+	if f.compressed == nil {
 		return f.content, nil
 	}
+	content, err := decompress[T](f.compressed)
+	return content, src.wrapErr("decompress", err)
+}
+
+// maxAge returns the maximum age of the entry, which is `maxAge`, offset by `WithTTLJitter`, unless
+// the entry has an adaptive maximum age and `config` enables it.
+func (f *CachedFile[T]) maxAge(maxAge time.Duration, config loadConfig) time.Duration {
+	if config.adaptiveTTL.enabled() && !f.lastLoadTime.IsZero() {
+		return f.ttl
+	}
+	return withTTLOffset(maxAge, f.ttlOffset)
+}
+
+// fileLoad is the result of `loadFile`.
+type fileLoad[T any] struct {
+	size    int64
+	modTime time.Time
+	mode    fs.FileMode
+	// token is the token of the file stamped by the `Validator` it's revalidated by.
+	token any
+	// sum is the SHA-256 of the content of the file, if it was parsed and `WithSHA256` is used.
+	sum [32]byte
+	// parseStart is when the parser was called, if it was, and parseDuration is how long it took.
+	parseStart    time.Time
+	parseDuration time.Duration
+	// unchanged is true if the `Validator` said the file was the same as the cache entry, in which
+	// case it wasn't parsed.
+	unchanged bool
+	// statFailed is true if the file couldn't be stat-ed, or, with `fs.StatFS`, was stat-ed, but
+	// then couldn't be opened.
+	statFailed bool
+	content    T
+}
 
-	// Otherwise, get the stats to check if this cache entry is still valid.
-	file, err := open()
+// fileVersion identifies the version of a file in a cache entry, to tell whether it's changed.
+type fileVersion struct {
+	size    int64
+	modTime time.Time
+	token   any
+	// stamped is the time the entry was last loaded or revalidated, at or before which the file
+	// was stat-ed.
+	stamped time.Time
+}
+
+// loadFile opens and stats a file, and parses it unless the cache entry is `loaded` and the
+// `Validator` which `config` revalidates the file by says it's the same as when `last` was stamped.
+// Racily clean files, see `WithRacyModTimeWindow`, and files with a zero modtime with
+// `ZeroModTimeAlwaysReparse`, are always parsed, unless `config` sets a `Validator`.
+//
+// It doesn't modify any cache entry, so it's safe to abandon a call which is taking too long.
+func loadFile[T any](ctx context.Context, src source, parser fileParser[T], loaded bool, last fileVersion, config loadConfig) (load fileLoad[T], err error) {
+	file, err := src.open()
 	if err != nil {
-		return f.content, err
+		// With `fs.StatFS`, opening only stats the file.
+		return fileLoad[T]{statFailed: src.stats}, src.wrapErr("open", err)
 	}
 	defer file.Close()
+	if f, ok := file.(*statFile); ok {
+		defer func() {
+			// The file was stat-ed, but then couldn't be opened when it was read, so it was removed in
+			// between, which isn't an error of the parser.
+			if err != nil && f.openErr != nil {
+				load, err = fileLoad[T]{statFailed: true}, src.wrapErr("open", f.openErr)
+			}
+		}()
+	}
 	stats, err := file.Stat()
 	if err != nil {
-		return f.content, err
+		return fileLoad[T]{statFailed: true}, src.wrapErr("stat", err)
+	}
+	if stats.IsDir() {
+		return fileLoad[T]{}, src.wrapErr("load", ErrIsADirectory)
+	}
+	load = fileLoad[T]{
+		size:    stats.Size(),
+		modTime: stats.ModTime(),
+		mode:    stats.Mode(),
+	}
+	if config.sizeLimit > 0 && load.size > config.sizeLimit {
+		return fileLoad[T]{}, src.wrapErr("load", &FileTooLargeError{Size: load.size, Limit: config.sizeLimit})
+	}
+	zeroModTime := load.modTime.IsZero()
+	validator := config.fileValidator(zeroModTime)
+	if _, ok := validator.(contentHashValidator); ok {
+		if file, err = readable(file, config); err != nil {
+			return fileLoad[T]{statFailed: true}, src.wrapErr("open", err)
+		}
+		return loadHashedFile(ctx, src, parser, file, stats, load, loaded && load.size == last.size, last.token, config)
 	}
-	size := stats.Size()
-	modTime := stats.ModTime()
 
-	// Use the cached result if the mod time and size haven't changed
-	if loaded && size == f.lastSize && modTime == f.lastModTime {
-		f.lastLoadTime = loadTime
-		return f.content, nil
+	// Use the cached result if the validator says the file hasn't changed.
+	load.token, err = validator.Stamp(stats, file)
+	if err != nil {
+		return fileLoad[T]{}, src.wrapErr("validate", err)
+	}
+	reparse := config.validator == nil && ((zeroModTime && config.zeroModTime == ZeroModTimeAlwaysReparse) || last.racy(config.racyWindow))
+	if loaded && !reparse && validator.Same(last.token, load.token) {
+		load.unchanged = true
+		return load, nil
 	}
 
 	// Actually read the file
-	content, err := parser(file)
-	if err != nil {
-		return f.content, err
+	if file, err = readable(file, config); err != nil {
+		return fileLoad[T]{statFailed: true}, src.wrapErr("open", err)
+	}
+	err = parseSummed(ctx, src.path, parser, file, stats, &load, config)
+	return load, src.wrapErr("parse", err)
+}
+
+// readable returns the file to parse the content of `file` from, which, if `file` is a `*statFile`,
+// is the file returned by its `load` method.
+func readable(file fs.File, config loadConfig) (fs.File, error) {
+	if f, ok := file.(*statFile); ok {
+		return f.load(config.streamingReads)
+	}
+	return file, nil
+}
+
+var (
+	// ErrNotADirectory is returned, in an `*fs.PathError`, when getting a directory whose path is a
+	// file. No entry is cached for it.
+	ErrNotADirectory = errors.New("parsecache: not a directory")
+	// ErrIsADirectory is returned, in an `*fs.PathError`, when getting a file whose path is a
+	// directory. No entry is cached for it.
+	ErrIsADirectory = errors.New("parsecache: is a directory")
+)
+
+// ErrReadDirUnsupported is returned, in an `*fs.PathError`, when a directory can't be read because
+// the opened directory doesn't implement `fs.ReadDirFile`, and its filesystem doesn't implement
+// `fs.ReadDirFS`.
+var ErrReadDirUnsupported = errors.New("parsecache: directory doesn't implement fs.ReadDirFile")
+
+// ParserPanicError is the error returned when a parser panics.
+type ParserPanicError struct {
+	// Path is the cleaned path of the file being parsed, it may be empty if it isn't known.
+	Path string
+	// Value is the value the parser panicked with.
+	Value any
+	// Stack is the stack trace of the panic.
+	Stack []byte
+}
+
+func (err *ParserPanicError) Error() string {
+	return fmt.Sprintf("parsecache: parser panicked for %q: %v\n%s", err.Path, err.Value, err.Stack)
+}
+
+// parse calls `parser`, converting a panic into a `*ParserPanicError`.
+func parse[T any](ctx context.Context, path string, parser fileParser[T], f fs.File, info fs.FileInfo) (content T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			var zero T
+			content = zero
+			err = &ParserPanicError{
+				Path:  path,
+				Value: r,
+				Stack: debug.Stack(),
+			}
+		}
+	}()
+	return parser(ctx, f, info)
+}
+
+// withTimeout calls `load`, failing with `ErrLoadTimeout` if it doesn't return within `timeout`,
+// unless `timeout` isn't positive, or with `ctx.Err()` if `ctx` is done first. If either happens,
+// `load` is left to complete in its own goroutine and its result is discarded.
+func withTimeout[V any](ctx context.Context, timeout time.Duration, load func() (V, error)) (V, error) {
+	if timeout <= 0 && ctx.Done() == nil {
+		return load()
+	}
+	if err := ctx.Err(); err != nil {
+		var zero V
+		return zero, err
+	}
+
+	type result struct {
+		value V
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := load()
+		done <- result{value, err}
+	}()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-expired:
+		var zero V
+		return zero, ErrLoadTimeout
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
 	}
-	f.lastLoadTime = loadTime
-	f.content = content
-	f.lastSize = size
-	f.lastModTime = modTime
-	return f.content, nil
 }
 
 // Parser parses content into the type `T`.
 type Parser[T any] func(io.Reader) (T, error)
 
-// JsonParser[T] is a value of type Parser[T] which parses a file as JSON.
+// ParserCtx parses content into the type `T`, like `Parser`, but also takes a context, which is done
+// once the caller is no longer waiting for the result.
+type ParserCtx[T any] func(context.Context, io.Reader) (T, error)
+
+// PathParser parses a file into the type `T`, like `Parser`, but is also given the cleaned path of
+// the file in the cache, for example to choose a format by extension or to resolve relative paths.
+type PathParser[T any] func(path string, f fs.File) (T, error)
+
+// InfoParser parses a file into the type `T`, like `Parser`, but is also given the file's info. This
+// is the same info used to validate the cache entry, so a parser can, for example, size a buffer or
+// refuse a file which is too large without stat-ing the file again.
+type InfoParser[T any] func(f fs.File, info fs.FileInfo) (T, error)
+
+// withContext returns a `ParserCtx` which ignores the context and calls `parser`.
+func (parser Parser[T]) withContext() ParserCtx[T] {
+	return func(_ context.Context, f io.Reader) (T, error) {
+		return parser(f)
+	}
+}
+
+// fileParser is the form every type of parser is converted to internally, to parse an opened file.
+// `info` is the result of stat-ing `f`.
+type fileParser[T any] func(ctx context.Context, f fs.File, info fs.FileInfo) (T, error)
+
+// fileParser returns a `fileParser` which calls `parser`.
+func (parser Parser[T]) fileParser() fileParser[T] {
+	return func(_ context.Context, f fs.File, _ fs.FileInfo) (T, error) {
+		return parser(f)
+	}
+}
+
+// fileParser returns a `fileParser` which calls `parser`.
+func (parser ParserCtx[T]) fileParser() fileParser[T] {
+	return func(ctx context.Context, f fs.File, _ fs.FileInfo) (T, error) {
+		return parser(ctx, f)
+	}
+}
+
+// fileParser returns a `fileParser` which calls `parser` with `path`.
+func (parser PathParser[T]) fileParser(path string) fileParser[T] {
+	return func(_ context.Context, f fs.File, _ fs.FileInfo) (T, error) {
+		return parser(path, f)
+	}
+}
+
+// fileParser returns a `fileParser` which calls `parser`.
+func (parser InfoParser[T]) fileParser() fileParser[T] {
+	return func(_ context.Context, f fs.File, info fs.FileInfo) (T, error) {
+		return parser(f, info)
+	}
+}
+
+// parserFor returns the parser for the file at the cleaned `path`.
+func (cache *FsCache[T]) parserFor(path string) fileParser[T] {
+	if parser, ok := cache.options.extensionParser(path); ok {
+		return parser
+	}
+	if cache.options.parserFor != nil {
+		return cache.options.parserFor(path)
+	}
+	return cache.parser.fileParser()
+}
+
+// parserFor returns the parser for the file at the cleaned `path`, with the cache's `settings`.
+func (cache *ConcurrentFsCache[T]) parserFor(path string, settings *cacheSettings[T]) fileParser[T] {
+	if parser, ok := cache.options.extensionParser(path); ok {
+		return parser
+	}
+	if cache.options.parserFor != nil {
+		return cache.options.parserFor(path)
+	}
+	return settings.parser.fileParser()
+}
+
+// JsonParser[T] is a value of type Parser[T] which parses a file as JSON. It's an error for the file
+// to contain anything other than whitespace after the JSON value.
 func JsonParser[T any](f io.Reader) (T, error) {
-	decoder := json.NewDecoder(f)
-	var parsed T
-	err := decoder.Decode(&parsed)
-	return parsed, err
+	return JsonParserWith[T](JsonOptions{})(f)
+}
+
+// JsonParserStrict[T] is a value of type Parser[T] which parses a file as JSON, like `JsonParser`,
+// but fails if an object has a key without a matching field in the destination struct.
+func JsonParserStrict[T any](f io.Reader) (T, error) {
+	return JsonParserWith[T](JsonOptions{DisallowUnknownFields: true})(f)
+}
+
+// JsonParserUseNumber[T] is a value of type Parser[T] which parses a file as JSON, like
+// `JsonParser`, but decodes numbers into an `interface{}` as a `json.Number` instead of a float64.
+func JsonParserUseNumber[T any](f io.Reader) (T, error) {
+	return JsonParserWith[T](JsonOptions{UseNumber: true})(f)
+}
+
+// JsonOptions configures the parser returned by `JsonParserWith`.
+type JsonOptions struct {
+	// DisallowUnknownFields makes it an error for an object to have a key without a matching field
+	// in the destination struct.
+	DisallowUnknownFields bool
+	// UseNumber decodes numbers into an `interface{}` as a `json.Number` instead of a float64.
+	UseNumber bool
+}
+
+// errJsonTrailingData is returned by the JSON parsers when there's more data after the JSON value.
+var errJsonTrailingData = errors.New("parsecache: json: unexpected data after the top-level value")
+
+// JsonParserWith returns a `Parser` which parses a file as JSON, with the given options. It's an
+// error for the file to contain anything other than whitespace after the JSON value.
+func JsonParserWith[T any](opts JsonOptions) Parser[T] {
+	return func(f io.Reader) (T, error) {
+		decoder := json.NewDecoder(f)
+		if opts.DisallowUnknownFields {
+			decoder.DisallowUnknownFields()
+		}
+		if opts.UseNumber {
+			decoder.UseNumber()
+		}
+		var parsed T
+		if err := decoder.Decode(&parsed); err != nil {
+			return parsed, err
+		}
+		_, err := decoder.Token()
+		var syntaxErr *json.SyntaxError
+		switch {
+		case err == io.EOF:
+			return parsed, nil
+		case err == nil || errors.As(err, &syntaxErr):
+			return parsed, errJsonTrailingData
+		default:
+			// The file couldn't be read to check for trailing data.
+			return parsed, err
+		}
+	}
 }
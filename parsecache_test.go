@@ -4,6 +4,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 )
@@ -17,6 +18,7 @@ type testFileStructure struct {
 type testInterface interface {
 	GetFile(string) (testFileStructure, error)
 	GetDir(string) ([]fs.DirEntry, error)
+	GetFileWithMaxAge(string, time.Duration) (testFileStructure, error)
 }
 
 func cacheTests(t *testing.T, cache testInterface, maxAge time.Duration, dir string) {
@@ -29,6 +31,9 @@ func cacheTests(t *testing.T, cache testInterface, maxAge time.Duration, dir str
     "Number": 600,
     "Float": 0.9
 }`), 0660)
+	// Backdate b, so it isn't racily clean when its content is changed without its modtime below.
+	oldModTime := time.Now().Add(-time.Hour)
+	os.Chtimes(filepath.Join(dir, "b.json"), oldModTime, oldModTime)
 	time.Sleep(time.Second / 10)
 
 	a, err := cache.GetFile("a.json")
@@ -314,3 +319,35 @@ func TestParseCacheConcurrent(t *testing.T) {
 	cache := NewFsCache(os.DirFS(dir), JsonParser[testFileStructure], maxAge)
 	cacheTests(t, &cache, maxAge, dir)
 }
+
+func TestGetMaxAge(t *testing.T) {
+	cache := NewConcurrentFsCache(os.DirFS("."), JsonParser[testFileStructure], time.Minute)
+	if maxAge := cache.GetMaxAge(); maxAge != time.Minute {
+		t.Errorf("incorrect initial maximum age: %v", maxAge)
+	}
+	cache.SetMaxAge(time.Hour)
+	if maxAge := cache.GetMaxAge(); maxAge != time.Hour {
+		t.Errorf("incorrect maximum age after SetMaxAge: %v", maxAge)
+	}
+}
+
+// countingFS wraps a filesystem and counts the number of times files are opened.
+type countingFS struct {
+	fs    fs.FS
+	lock  sync.Mutex
+	opens int
+}
+
+func (c *countingFS) Open(name string) (fs.File, error) {
+	c.lock.Lock()
+	c.opens++
+	c.lock.Unlock()
+	return c.fs.Open(name)
+}
+
+// Opens returns the number of times a file has been opened.
+func (c *countingFS) Opens() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.opens
+}
@@ -0,0 +1,72 @@
+// Package parsecachetest provides a parsecache cache with helpers for inspecting and arranging its
+// state in tests.
+package parsecachetest
+
+import (
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/JOT85/parsecache"
+)
+
+// TestFsCache is a `parsecache.ConcurrentFsCache` with helpers for tests.
+type TestFsCache[T any] struct {
+	*parsecache.ConcurrentFsCache[T]
+}
+
+// NewTestFsCache returns a new `TestFsCache` on top of the `fsys` filesystem, with the same
+// arguments as `parsecache.NewConcurrentFsCache`.
+func NewTestFsCache[T any](fsys fs.FS, parser parsecache.Parser[T], maxAge time.Duration, opts ...parsecache.Option[T]) *TestFsCache[T] {
+	return &TestFsCache[T]{parsecache.NewConcurrentFsCache(fsys, parser, maxAge, opts...)}
+}
+
+// InjectFile stores `entry` as the cache entry for the file at `path`, replacing any existing
+// entry. Entries can be created with `parsecache.NewCachedFile`.
+func (cache *TestFsCache[T]) InjectFile(path string, entry parsecache.CachedFile[T]) {
+	cache.SetFileEntry(path, entry)
+}
+
+// EntryCount returns the number of files cached, not including files inside archives.
+func (cache *TestFsCache[T]) EntryCount() int {
+	return int(cache.Stats().Files)
+}
+
+// AssertHit gets the file at `path`, and fails the test if it wasn't served from memory, because it
+// wasn't cached, it was loaded or revalidated, or it failed to load.
+func (cache *TestFsCache[T]) AssertHit(t testing.TB, path string) {
+	t.Helper()
+	if !cache.hit(t, path) {
+		t.Errorf("parsecachetest: %s wasn't a cache hit", path)
+	}
+}
+
+// AssertMiss gets the file at `path`, and fails the test if it was served from memory, rather than
+// being loaded or revalidated.
+func (cache *TestFsCache[T]) AssertMiss(t testing.TB, path string) {
+	t.Helper()
+	if cache.hit(t, path) {
+		t.Errorf("parsecachetest: %s was a cache hit", path)
+	}
+}
+
+// hit gets the file at `path` and returns whether it was served from memory.
+func (cache *TestFsCache[T]) hit(t testing.TB, path string) bool {
+	t.Helper()
+	before, ok := cache.GetFileEntry(path)
+	var cachedAt time.Time
+	if ok {
+		_, cachedAt, ok = before.Cached()
+	}
+	_, err := cache.GetFile(path)
+	if err != nil {
+		t.Logf("parsecachetest: getting %s failed: %v", path, err)
+		return false
+	}
+	after, afterOk := cache.GetFileEntry(path)
+	if !ok || !afterOk || after != before {
+		return false
+	}
+	_, afterCachedAt, _ := after.Cached()
+	return afterCachedAt.Equal(cachedAt)
+}
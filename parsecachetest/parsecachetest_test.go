@@ -0,0 +1,65 @@
+package parsecachetest
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/JOT85/parsecache"
+)
+
+type testFileStructure struct {
+	Hello string
+}
+
+// recordingTB records the failures of a test, instead of failing it.
+type recordingTB struct {
+	testing.TB
+	failures int
+}
+
+func (r *recordingTB) Errorf(format string, args ...interface{}) {
+	r.failures++
+}
+
+func TestTestFsCache(t *testing.T) {
+	modTime := time.Now().Add(-time.Hour)
+	filesystem := fstest.MapFS{
+		"a.json": &fstest.MapFile{Data: []byte(`{"Hello": "world!"}`), ModTime: modTime},
+		"b.json": &fstest.MapFile{Data: []byte(`{"Hello": "b"}`), ModTime: modTime},
+	}
+	cache := NewTestFsCache(filesystem, parsecache.JsonParser[testFileStructure], time.Minute)
+	cache.AssertMiss(t, "a.json")
+	cache.AssertHit(t, "a.json")
+	if cache.EntryCount() != 1 {
+		t.Errorf("expected 1 entry, got %d", cache.EntryCount())
+	}
+
+	// An injected entry is served from memory.
+	size := int64(len(filesystem["b.json"].Data))
+	cache.InjectFile("b.json", parsecache.NewCachedFile(testFileStructure{Hello: "injected"}, size, modTime, time.Now()))
+	cache.AssertHit(t, "b.json")
+	b, err := cache.GetFile("b.json")
+	if err != nil || b.Hello != "injected" {
+		t.Errorf("injected entry not returned: %+v, %v", b, err)
+	}
+	if cache.EntryCount() != 2 {
+		t.Errorf("expected 2 entries, got %d", cache.EntryCount())
+	}
+
+	// An expired injected entry is revalidated, and kept, since the size and modtime match.
+	cache.InjectFile("b.json", parsecache.NewCachedFile(testFileStructure{Hello: "injected"}, size, modTime, time.Now().Add(-2*time.Minute)))
+	cache.AssertMiss(t, "b.json")
+	b, _ = cache.GetFile("b.json")
+	if b.Hello != "injected" {
+		t.Errorf("injected entry not kept after revalidation: %+v", b)
+	}
+
+	// The assertions fail the test when they don't hold.
+	recorder := &recordingTB{TB: t}
+	cache.AssertMiss(recorder, "a.json")
+	cache.AssertHit(recorder, "missing.json")
+	if recorder.failures != 2 {
+		t.Errorf("expected 2 failures, got %d", recorder.failures)
+	}
+}
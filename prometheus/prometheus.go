@@ -0,0 +1,133 @@
+// Package prometheus provides an adapter which records Prometheus metrics for a parsecache cache.
+//
+// It's a separate module so that the main parsecache module doesn't depend on the Prometheus
+// client.
+package prometheus
+
+import (
+	"io/fs"
+
+	"github.com/JOT85/parsecache"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// resultCache is implemented by the caches of parsecache, such as `*parsecache.ConcurrentFsCache`
+// and `parsecache.SubCache`, and is used to record cache hits, misses and parse durations.
+type resultCache[T any] interface {
+	GetFileResult(file string) (T, parsecache.GetResult, error)
+	GetDirResult(dir string) ([]fs.DirEntry, parsecache.GetResult, error)
+}
+
+// statsCache is implemented by `*parsecache.FsCache` and `*parsecache.ConcurrentFsCache`, and is
+// used to record evictions and entries.
+type statsCache interface {
+	Stats() parsecache.Stats
+}
+
+// instrumentedCache is the `parsecache.Cache` returned by `NewInstrumentedCache`.
+type instrumentedCache[T any] struct {
+	inner parsecache.Cache[T]
+	// results is `inner`, if it implements `resultCache`, or nil.
+	results resultCache[T]
+
+	hits          prom.Counter
+	misses        prom.Counter
+	parseDuration prom.Histogram
+}
+
+// NewInstrumentedCache returns a `parsecache.Cache` which wraps `inner`, recording metrics in
+// `registerer`, with the given namespace:
+//
+//   - cache_hits_total, the number of files and directories returned from memory,
+//   - cache_misses_total, the number of files and directories which were loaded or revalidated,
+//   - cache_evictions_total, the number of files evicted because of `parsecache.WithMaxEntries`,
+//   - cache_parse_duration_seconds, a histogram of the time taken by the parser for each file which
+//     was parsed,
+//   - cache_entries, the number of cached files and directories.
+//
+// The hits, misses and durations require `inner` to be one of the caches of parsecache, which
+// report how each get was served, for any other cache every call is recorded as a miss, and no
+// durations are recorded. The evictions and entries require `inner` to be a `*parsecache.FsCache`
+// or `*parsecache.ConcurrentFsCache`. The wrapper is only safe for concurrent use if `inner` is.
+//
+// It panics if the metrics can't be registered, for example because they're already registered.
+func NewInstrumentedCache[T any](inner parsecache.Cache[T], registerer prom.Registerer, namespace string) parsecache.Cache[T] {
+	cache := &instrumentedCache[T]{
+		inner: inner,
+		hits: prom.NewCounter(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_hits_total",
+			Help:      "The number of files and directories returned from memory.",
+		}),
+		misses: prom.NewCounter(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_misses_total",
+			Help:      "The number of files and directories which were loaded or revalidated.",
+		}),
+		parseDuration: prom.NewHistogram(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "cache_parse_duration_seconds",
+			Help:      "The time taken to parse files which were parsed.",
+			Buckets:   prom.DefBuckets,
+		}),
+	}
+	registerer.MustRegister(cache.hits, cache.misses, cache.parseDuration)
+
+	cache.results, _ = inner.(resultCache[T])
+	if stats, ok := inner.(statsCache); ok {
+		registerer.MustRegister(
+			prom.NewCounterFunc(prom.CounterOpts{
+				Namespace: namespace,
+				Name:      "cache_evictions_total",
+				Help:      "The number of files evicted to keep within the maximum number of entries.",
+			}, func() float64 {
+				return float64(stats.Stats().Evictions)
+			}),
+			prom.NewGaugeFunc(prom.GaugeOpts{
+				Namespace: namespace,
+				Name:      "cache_entries",
+				Help:      "The number of cached files and directories.",
+			}, func() float64 {
+				s := stats.Stats()
+				return float64(s.Files + s.Dirs)
+			}),
+		)
+	}
+	return cache
+}
+
+// record records a hit or a miss, and the parse duration, if there was a parse, from `result`.
+func (cache *instrumentedCache[T]) record(result parsecache.GetResult) {
+	if result.Hit {
+		cache.hits.Inc()
+	} else {
+		cache.misses.Inc()
+	}
+	if result.Parsed {
+		cache.parseDuration.Observe(result.ParseDuration.Seconds())
+	}
+}
+
+func (cache *instrumentedCache[T]) GetFile(file string) (T, error) {
+	if cache.results == nil {
+		cache.misses.Inc()
+		return cache.inner.GetFile(file)
+	}
+	content, result, err := cache.results.GetFileResult(file)
+	cache.record(result)
+	return content, err
+}
+
+func (cache *instrumentedCache[T]) GetDir(dir string) ([]fs.DirEntry, error) {
+	if cache.results == nil {
+		cache.misses.Inc()
+		return cache.inner.GetDir(dir)
+	}
+	entries, result, err := cache.results.GetDirResult(dir)
+	cache.record(result)
+	return entries, err
+}
+
+func (cache *instrumentedCache[T]) Clear() {
+	cache.inner.Clear()
+}
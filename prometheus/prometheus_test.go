@@ -0,0 +1,91 @@
+package prometheus
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/JOT85/parsecache"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type testFileStructure struct {
+	Hello string
+}
+
+func TestInstrumentedCache(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"a.json": &fstest.MapFile{Data: []byte(`{"Hello": "a"}`)},
+		"b.json": &fstest.MapFile{Data: []byte(`{"Hello": "b"}`)},
+	}
+	registry := prom.NewRegistry()
+	inner := parsecache.NewConcurrentFsCache(filesystem, parsecache.JsonParser[testFileStructure], time.Minute, parsecache.WithMaxEntries[testFileStructure](1))
+	cache := NewInstrumentedCache[testFileStructure](inner, registry, "test")
+
+	for _, file := range []string{"a.json", "a.json", "b.json", "missing.json"} {
+		cache.GetFile(file)
+	}
+	cache.GetDir("/")
+	cache.GetDir("/")
+
+	count := func(name string) float64 {
+		families, err := registry.Gather()
+		if err != nil {
+			panic(err)
+		}
+		for _, family := range families {
+			if family.GetName() != name {
+				continue
+			}
+			metric := family.GetMetric()[0]
+			switch {
+			case metric.Counter != nil:
+				return metric.Counter.GetValue()
+			case metric.Gauge != nil:
+				return metric.Gauge.GetValue()
+			case metric.Histogram != nil:
+				return float64(metric.Histogram.GetSampleCount())
+			}
+		}
+		t.Fatalf("metric %s not registered", name)
+		return 0
+	}
+	for name, expected := range map[string]float64{
+		"test_cache_hits_total":             2,
+		"test_cache_misses_total":           4,
+		"test_cache_evictions_total":        1,
+		"test_cache_parse_duration_seconds": 2,
+		"test_cache_entries":                2,
+	} {
+		if actual := count(name); actual != expected {
+			t.Errorf("%s is %v, expected %v", name, actual, expected)
+		}
+	}
+
+	if n := testutil.CollectAndCount(registry); n != 5 {
+		t.Errorf("%d metrics registered, expected 5", n)
+	}
+}
+
+func TestInstrumentedSubCache(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"sub/a.json": &fstest.MapFile{Data: []byte(`{"Hello": "a"}`)},
+	}
+	registry := prom.NewRegistry()
+	inner := parsecache.NewConcurrentFsCache(filesystem, parsecache.JsonParser[testFileStructure], time.Minute)
+	cache := NewInstrumentedCache[testFileStructure](inner.Sub("sub"), registry, "test")
+
+	cache.GetFile("a.json")
+	cache.GetFile("a.json")
+
+	if hits := testutil.ToFloat64(cache.(*instrumentedCache[testFileStructure]).hits); hits != 1 {
+		t.Errorf("%v hits, expected 1", hits)
+	}
+	if misses := testutil.ToFloat64(cache.(*instrumentedCache[testFileStructure]).misses); misses != 1 {
+		t.Errorf("%v misses, expected 1", misses)
+	}
+	if n := testutil.CollectAndCount(registry); n != 3 {
+		t.Errorf("%d metrics registered, expected 3", n)
+	}
+}
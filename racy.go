@@ -0,0 +1,27 @@
+package parsecache
+
+import "time"
+
+// DefaultRacyModTimeWindow is the window used unless `WithRacyModTimeWindow` is given. It covers
+// filesystems which store modtimes to the second, and FAT, which stores them to 2 seconds.
+const DefaultRacyModTimeWindow = 2 * time.Second
+
+// WithRacyModTimeWindow sets how close to the time a file was stat-ed its modtime must be for the
+// file to be "racily clean", in which case it's parsed again when it's next revalidated, even if its
+// size and modtime haven't changed. Filesystems store modtimes with a limited granularity, so a file
+// which is rewritten, with the same size, shortly after it was stat-ed may keep the same modtime, and
+// otherwise would never be seen to have changed. This is how git's index handles the same problem.
+//
+// The default is `DefaultRacyModTimeWindow`, and a window of 0 disables it. It only applies to files
+// revalidated by their size and modtime, not with `WithContentHash` or `WithValidator`.
+func WithRacyModTimeWindow[T any](window time.Duration) Option[T] {
+	return func(o *options[T]) {
+		o.load.racyWindow = window
+	}
+}
+
+// racy returns true if the modtime of `version` is within `window` of the time it was stat-ed, so
+// a matching size and modtime don't show that the file hasn't changed.
+func (version fileVersion) racy(window time.Duration) bool {
+	return window > 0 && version.stamped.Sub(version.modTime) < window
+}
@@ -0,0 +1,70 @@
+package parsecache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRacyModTime(t *testing.T) {
+	maxAge := time.Second / 20
+	for _, test := range []struct {
+		name     string
+		opts     []Option[testFileStructure]
+		backdate bool
+		// number is the number expected once the file has been rewritten with the same size and
+		// modtime, and revalidated.
+		number uint16
+	}{
+		{"default", nil, false, 2},
+		{"disabled", []Option[testFileStructure]{WithRacyModTimeWindow[testFileStructure](0)}, false, 1},
+		{"backdated", nil, true, 1},
+	} {
+		for _, concurrent := range []bool{false, true} {
+			dir, err := os.MkdirTemp("", "parsecache-test-racy-*")
+			if err != nil {
+				panic(err)
+			}
+			defer os.RemoveAll(dir)
+			path := filepath.Join(dir, "a.json")
+			if err := os.WriteFile(path, []byte(`{"Number": 1}`), 0660); err != nil {
+				panic(err)
+			}
+			if test.backdate {
+				modTime := time.Now().Add(-time.Hour)
+				if err := os.Chtimes(path, modTime, modTime); err != nil {
+					panic(err)
+				}
+			}
+			stats, err := os.Stat(path)
+			if err != nil {
+				panic(err)
+			}
+
+			var cache testInterface
+			if concurrent {
+				cache = NewConcurrentFsCache(os.DirFS(dir), JsonParser[testFileStructure], maxAge, test.opts...)
+			} else {
+				fsCache := NewFsCache(os.DirFS(dir), JsonParser[testFileStructure], maxAge, test.opts...)
+				cache = &fsCache
+			}
+			if _, err := cache.GetFile("a.json"); err != nil {
+				panic(err)
+			}
+
+			// Rewrite the file within the granularity of a coarse filesystem.
+			if err := os.WriteFile(path, []byte(`{"Number": 2}`), 0660); err != nil {
+				panic(err)
+			}
+			if err := os.Chtimes(path, stats.ModTime(), stats.ModTime()); err != nil {
+				panic(err)
+			}
+			time.Sleep(maxAge)
+			a, err := cache.GetFile("a.json")
+			if err != nil || a.Number != test.number {
+				t.Errorf("%s, concurrent=%v: got %+v, %v", test.name, concurrent, a, err)
+			}
+		}
+	}
+}
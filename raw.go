@@ -0,0 +1,82 @@
+package parsecache
+
+import (
+	"bufio"
+	"io"
+	"io/fs"
+	"math"
+)
+
+// BytesParser is a `Parser` which reads the entire content of a file.
+//
+// When `f` is an `fs.File` (as it is when used by a cache), its size is used to allocate the
+// content up front. When the cache's filesystem implements `fs.StatFS` and `fs.ReadFileFS`, the
+// content read with `ReadFile` is returned without being copied.
+func BytesParser(f io.Reader) ([]byte, error) {
+	if content, ok := readAll(f); ok {
+		return content, nil
+	}
+
+	size := 512
+	if file, ok := f.(fs.File); ok {
+		if info, err := file.Stat(); err == nil && info.Size() > 0 && info.Size() < math.MaxInt32 {
+			// Allocate one more byte than required so that the final read sees io.EOF without
+			// growing the content.
+			size = int(info.Size()) + 1
+		}
+	}
+
+	// This is `io.ReadAll`, with the initial capacity set.
+	content := make([]byte, 0, size)
+	for {
+		n, err := f.Read(content[len(content):cap(content)])
+		content = content[:len(content)+n]
+		if err == io.EOF {
+			return content, nil
+		}
+		if err != nil {
+			return content, err
+		}
+		if len(content) == cap(content) {
+			content = append(content, 0)[:len(content)]
+		}
+	}
+}
+
+// StringParser is a `Parser` which reads the entire content of a file as a string.
+func StringParser(f io.Reader) (string, error) {
+	content, err := BytesParser(f)
+	return string(content), err
+}
+
+// LinesParser is a `Parser` which splits a file into lines, without their line endings ("\n" or
+// "\r\n"). A final line without a line ending is included, but there is no empty line after a
+// trailing line ending.
+//
+// Lines longer than `bufio.MaxScanTokenSize` fail with `bufio.ErrTooLong`, use
+// `LinesParserWithMaxLength` to allow longer lines.
+func LinesParser(f io.Reader) ([]string, error) {
+	return LinesParserWithMaxLength(bufio.MaxScanTokenSize)(f)
+}
+
+// LinesParserWithMaxLength returns a `Parser` which splits a file into lines, like `LinesParser`,
+// but fails with `bufio.ErrTooLong` only for lines longer than `maxLength` bytes.
+func LinesParserWithMaxLength(maxLength int) Parser[[]string] {
+	return func(f io.Reader) ([]string, error) {
+		scanner := bufio.NewScanner(f)
+		initial := 4096
+		if maxLength < initial {
+			initial = maxLength
+		}
+		// The buffer must also hold the line ending.
+		scanner.Buffer(make([]byte, 0, initial), maxLength+2)
+		var lines []string
+		for scanner.Scan() {
+			if len(scanner.Bytes()) > maxLength {
+				return lines, bufio.ErrTooLong
+			}
+			lines = append(lines, scanner.Text())
+		}
+		return lines, scanner.Err()
+	}
+}
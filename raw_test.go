@@ -0,0 +1,101 @@
+package parsecache
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestBytesParser(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"empty.txt": &fstest.MapFile{},
+		"a.txt":     &fstest.MapFile{Data: []byte("hello\nworld")},
+	}
+	bytesCache := NewFsCache(filesystem, BytesParser, time.Minute)
+	stringCache := NewConcurrentFsCache(filesystem, StringParser, time.Minute)
+
+	content, err := bytesCache.GetFile("empty.txt")
+	if err != nil {
+		panic(err)
+	}
+	if len(content) != 0 {
+		t.Error("empty.txt not read correctly")
+	}
+	content, err = bytesCache.GetFile("a.txt")
+	if err != nil {
+		panic(err)
+	}
+	if string(content) != "hello\nworld" {
+		t.Error("a.txt not read correctly")
+	}
+	if cap(content) != len(content)+1 {
+		t.Errorf("a.txt content not allocated up front, capacity %d", cap(content))
+	}
+
+	s, err := stringCache.GetFile("a.txt")
+	if err != nil {
+		panic(err)
+	}
+	if s != "hello\nworld" {
+		t.Error("a.txt not read correctly as a string")
+	}
+	s, err = stringCache.GetFile("empty.txt")
+	if err != nil {
+		panic(err)
+	}
+	if s != "" {
+		t.Error("empty.txt not read correctly as a string")
+	}
+}
+
+func TestLinesParser(t *testing.T) {
+	long := strings.Repeat("x", bufio.MaxScanTokenSize+1)
+	filesystem := fstest.MapFS{
+		"empty.txt":    &fstest.MapFile{},
+		"trailing.txt": &fstest.MapFile{Data: []byte("a\r\nb\n\nc\n")},
+		"no-end.txt":   &fstest.MapFile{Data: []byte("a\nb")},
+		"long.txt":     &fstest.MapFile{Data: []byte("a\n" + long + "\nb\n")},
+	}
+	cache := NewFsCache(filesystem, LinesParser, time.Minute)
+
+	for file, expected := range map[string][]string{
+		"empty.txt":    nil,
+		"trailing.txt": {"a", "b", "", "c"},
+		"no-end.txt":   {"a", "b"},
+	} {
+		lines, err := cache.GetFile(file)
+		if err != nil {
+			panic(err)
+		}
+		if !equalNames(lines, expected) {
+			t.Errorf("%s not split correctly: %q", file, lines)
+		}
+	}
+
+	_, err := cache.GetFile("long.txt")
+	if !errors.Is(err, bufio.ErrTooLong) {
+		t.Errorf("long.txt didn't fail with bufio.ErrTooLong: %v", err)
+	}
+
+	longCache := NewFsCache(filesystem, LinesParserWithMaxLength(len(long)), time.Minute)
+	lines, err := longCache.GetFile("long.txt")
+	if err != nil {
+		panic(err)
+	}
+	if !equalNames(lines, []string{"a", long, "b"}) {
+		t.Error("long.txt not split correctly with a larger maximum length")
+	}
+
+	shortCache := NewFsCache(filesystem, LinesParserWithMaxLength(1), time.Minute)
+	lines, err = shortCache.GetFile("trailing.txt")
+	if err != nil {
+		t.Errorf("lines of the maximum length failed: %v", err)
+	}
+	_, err = shortCache.GetFile("long.txt")
+	if !errors.Is(err, bufio.ErrTooLong) {
+		t.Errorf("long.txt didn't fail with bufio.ErrTooLong: %v", err)
+	}
+}
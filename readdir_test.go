@@ -0,0 +1,66 @@
+package parsecache
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// minimalFS is an `fs.FS` which only implements `Open`, and whose files only implement `fs.File`,
+// so its directories can't be read.
+type minimalFS struct {
+	fs fstest.MapFS
+}
+
+// minimalFile hides every method of a file other than those of `fs.File`.
+type minimalFile struct {
+	fs.File
+}
+
+func (m minimalFS) Open(name string) (fs.File, error) {
+	f, err := m.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return minimalFile{f}, nil
+}
+
+// readDirMinimalFS is a `minimalFS` which implements `fs.ReadDirFS`.
+type readDirMinimalFS struct {
+	minimalFS
+}
+
+func (m readDirMinimalFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return m.fs.ReadDir(name)
+}
+
+func TestReadDirUnsupported(t *testing.T) {
+	mapFS := fstest.MapFS{
+		"dir/a.txt": &fstest.MapFile{},
+	}
+	for _, concurrent := range []bool{false, true} {
+		for _, filesystem := range []fs.FS{minimalFS{mapFS}, readDirMinimalFS{minimalFS{mapFS}}} {
+			_, readDirFS := filesystem.(fs.ReadDirFS)
+			var cache testInterface
+			if concurrent {
+				cache = NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+			} else {
+				c := NewFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+				cache = &c
+			}
+			entries, err := cache.GetDir("dir")
+			if readDirFS {
+				if err != nil || len(entries) != 1 {
+					t.Errorf("concurrent=%v: dir not read with ReadDirFS: %d entries, %v", concurrent, len(entries), err)
+				}
+				continue
+			}
+			var pathErr *fs.PathError
+			if !errors.Is(err, ErrReadDirUnsupported) || !errors.As(err, &pathErr) || pathErr.Op != "parsecache.readdir" {
+				t.Errorf("concurrent=%v: unreadable dir didn't fail correctly: %v", concurrent, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package parsecache
+
+// GetFileOrRefresh returns the parsed content of a file, which may be cached, like `GetFile`, and
+// whether it was refreshed, which is true only if the file was parsed, because it either wasn't
+// cached or had changed, rather than being returned from memory or revalidated unchanged.
+func (cache *FsCache[T]) GetFileOrRefresh(file string) (T, bool, error) {
+	key := cache.options.key(cache.normalize(file))
+	entry, ok := cache.files[key]
+	var before uint64
+	if ok {
+		before = entry.parses
+	}
+	content, err := cache.GetFile(file)
+	if err != nil {
+		return content, false, err
+	}
+	after, ok := cache.files[key]
+	if !ok {
+		// The file was parsed, but not kept in the cache, such as because it's larger than the
+		// limit set by `WithMaxCachedFileSize`, so it was refreshed.
+		return content, true, nil
+	}
+	return content, after != entry || after.parses != before, nil
+}
+
+// GetFileOrRefresh returns the parsed content of a file, which may be cached, like `GetFile`, and
+// whether it was refreshed, which is true only if the file was parsed, because it either wasn't
+// cached or had changed, rather than being returned from memory or revalidated unchanged.
+//
+// If the file is being refreshed by a concurrent call, either or both of the calls may report that
+// they refreshed it. Callers acting on a refresh, such as by reconfiguring a server, should ensure
+// that doing so twice is harmless.
+func (cache *ConcurrentFsCache[T]) GetFileOrRefresh(file string) (T, bool, error) {
+	entry, ok := cache.GetFileEntry(file)
+	var before uint64
+	if ok {
+		before = entry.Version()
+	}
+	content, err := cache.GetFile(file)
+	if err != nil {
+		return content, false, err
+	}
+	after, ok := cache.GetFileEntry(file)
+	if !ok {
+		// The cache was cleared, or the file evicted, after it was loaded, so it isn't known whether
+		// it was refreshed, and it's assumed that it was.
+		return content, true, nil
+	}
+	return content, after != entry || after.Version() != before, nil
+}
+
+// Version returns the version of the entry's content, which is 0 until the file is first parsed,
+// and increases by one each time the file is parsed into the entry, but not when the entry is
+// revalidated without the file changing. Comparing versions tells whether the content has changed,
+// without comparing the content itself.
+func (f *CachedFile[T]) Version() uint64 {
+	return f.parses
+}
+
+// Version returns the version of the entry's content, like `CachedFile.Version`.
+func (f *ConcurrentCachedFile[T]) Version() uint64 {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return f.cachedFile.Version()
+}
@@ -0,0 +1,102 @@
+package parsecache
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+type testRefreshInterface interface {
+	GetFileOrRefresh(string) (testFileStructure, bool, error)
+}
+
+func refreshTests(t *testing.T, cache testRefreshInterface, mapFS fstest.MapFS, maxAge time.Duration) {
+	get := func(expected bool, step string) {
+		_, refreshed, err := cache.GetFileOrRefresh("a.json")
+		if err != nil {
+			panic(err)
+		}
+		if refreshed != expected {
+			t.Errorf("%s: refreshed is %v, expected %v", step, refreshed, expected)
+		}
+	}
+	get(true, "first load")
+	get(false, "cache hit")
+	time.Sleep(maxAge)
+	get(false, "revalidated unchanged")
+	mapFS["a.json"] = &fstest.MapFile{Data: []byte(`{"Hello": "changed"}`), ModTime: time.Now()}
+	get(false, "changed within maxAge")
+	time.Sleep(maxAge)
+	get(true, "changed")
+	get(false, "cache hit after change")
+
+	_, refreshed, err := cache.GetFileOrRefresh("missing.json")
+	if err == nil || refreshed {
+		t.Error("missing.json refreshed")
+	}
+}
+
+func TestGetFileOrRefresh(t *testing.T) {
+	maxAge := time.Second / 20
+	for _, concurrent := range []bool{false, true} {
+		mapFS := fstest.MapFS{
+			"a.json": &fstest.MapFile{Data: []byte(`{"Hello": "world"}`)},
+		}
+		if concurrent {
+			refreshTests(t, NewConcurrentFsCache(mapFS, JsonParser[testFileStructure], maxAge), mapFS, maxAge)
+		} else {
+			cache := NewFsCache(mapFS, JsonParser[testFileStructure], maxAge)
+			refreshTests(t, &cache, mapFS, maxAge)
+		}
+	}
+}
+
+func TestVersion(t *testing.T) {
+	modTime := time.Now().Add(-time.Hour)
+	mapFS := fstest.MapFS{
+		"a.json": &fstest.MapFile{Data: []byte(`{"Hello": "world"}`), ModTime: modTime},
+	}
+	cache := NewConcurrentFsCache(mapFS, JsonParser[testFileStructure], 0)
+	version := func() uint64 {
+		if _, err := cache.GetFile("a.json"); err != nil {
+			panic(err)
+		}
+		entry, _ := cache.GetFileEntry("a.json")
+		return entry.Version()
+	}
+
+	if v := version(); v != 1 {
+		t.Errorf("version after the first parse is %d, expected 1", v)
+	}
+	if v := version(); v != 1 {
+		t.Errorf("version after revalidating unchanged is %d, expected 1", v)
+	}
+	mapFS["a.json"] = &fstest.MapFile{Data: []byte(`{"Hello": "changed"}`), ModTime: modTime.Add(time.Minute)}
+	if v := version(); v != 2 {
+		t.Errorf("version after a change is %d, expected 2", v)
+	}
+	if v := (&CachedFile[testFileStructure]{}).Version(); v != 0 {
+		t.Errorf("version of an unloaded entry is %d, expected 0", v)
+	}
+}
+
+func TestGetFileOrRefreshNotCached(t *testing.T) {
+	mapFS := fstest.MapFS{
+		"a.json": &fstest.MapFile{Data: []byte(`{"Hello": "world"}`)},
+	}
+	for _, concurrent := range []bool{false, true} {
+		var cache testRefreshInterface
+		if concurrent {
+			cache = NewConcurrentFsCache(mapFS, JsonParser[testFileStructure], 0, WithMaxCachedFileSize[testFileStructure](5))
+		} else {
+			c := NewFsCache(mapFS, JsonParser[testFileStructure], 0, WithMaxCachedFileSize[testFileStructure](5))
+			cache = &c
+		}
+		for i := 0; i < 2; i++ {
+			content, refreshed, err := cache.GetFileOrRefresh("a.json")
+			if err != nil || !refreshed || content.Hello != "world" {
+				t.Errorf("concurrent=%v: file larger than the max size not refreshed: %+v, %v, %v", concurrent, content, refreshed, err)
+			}
+		}
+	}
+}
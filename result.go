@@ -0,0 +1,81 @@
+package parsecache
+
+import (
+	"context"
+	"io/fs"
+	"time"
+)
+
+// GetResult describes how a single get from a cache was served, as reported by `GetFileResult` and
+// `GetDirResult`, for example to record metrics or traces of the cache.
+type GetResult struct {
+	// Hit is true if the content was returned from memory, without touching the filesystem.
+	Hit bool
+	// Parsed is true if the parser was called, rather than the cached content being returned or
+	// revalidated as unchanged. It's never true for directories.
+	Parsed bool
+	// ParseStart is when the parser was called, if `Parsed` is true.
+	ParseStart time.Time
+	// ParseDuration is how long the parser took, if `Parsed` is true.
+	ParseDuration time.Duration
+	// CachedAt is the time the entry which was returned was last loaded or revalidated, or the zero
+	// time if nothing was cached.
+	CachedAt time.Time
+}
+
+// hit records that the get was served from memory, from an entry loaded at `cachedAt`. It does
+// nothing if `r` is nil.
+func (r *GetResult) hit(cachedAt time.Time) {
+	if r != nil {
+		r.Hit = true
+		r.CachedAt = cachedAt
+	}
+}
+
+// parsed records the parse of a file which started at `start`, if it isn't the zero time, and took
+// `duration`. It does nothing if `r` is nil.
+func (r *GetResult) parsed(start time.Time, duration time.Duration) {
+	if r != nil && !start.IsZero() {
+		r.Parsed = true
+		r.ParseStart = start
+		r.ParseDuration = duration
+	}
+}
+
+// cachedAt records that the entry which was returned was loaded at `cachedAt`. It does nothing if
+// `r` is nil.
+func (r *GetResult) cachedAt(cachedAt time.Time) {
+	if r != nil {
+		r.CachedAt = cachedAt
+	}
+}
+
+// GetFileResult is `GetFile`, but also returns how the file was got.
+func (cache *FsCache[T]) GetFileResult(file string) (T, GetResult, error) {
+	var result GetResult
+	config := cache.options.load
+	config.result = &result
+	content, err := cache.getFile(file, cache.MaxAge, config, nil)
+	return content, result, err
+}
+
+// GetDirResult is `GetDir`, but also returns how the directory was got.
+func (cache *FsCache[T]) GetDirResult(dir string) ([]fs.DirEntry, GetResult, error) {
+	var result GetResult
+	entries, err := cache.getDir(dir, cache.MaxAge, &result)
+	return copyEntries(entries), result, err
+}
+
+// GetFileResult is `GetFile`, but also returns how the file was got.
+func (cache *ConcurrentFsCache[T]) GetFileResult(file string) (T, GetResult, error) {
+	var result GetResult
+	content, err := cache.getFile(context.Background(), file, 0, false, &result, nil)
+	return content, result, err
+}
+
+// GetDirResult is `GetDir`, but also returns how the directory was got.
+func (cache *ConcurrentFsCache[T]) GetDirResult(dir string) ([]fs.DirEntry, GetResult, error) {
+	var result GetResult
+	entries, err := cache.getDir(context.Background(), dir, 0, false, &result)
+	return copyEntries(entries), result, err
+}
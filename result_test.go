@@ -0,0 +1,65 @@
+package parsecache
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// resultCache is implemented by the caches which report how each get was served.
+type resultCache interface {
+	GetFileResult(file string) (testFileStructure, GetResult, error)
+	GetDirResult(dir string) ([]fs.DirEntry, GetResult, error)
+}
+
+func TestGetResult(t *testing.T) {
+	modTime := time.Now().Add(-time.Hour)
+	for _, name := range []string{"FsCache", "ConcurrentFsCache", "SubCache", "TwoLevelCache"} {
+		filesystem := fstest.MapFS{
+			"sub/a.json": &fstest.MapFile{Data: []byte(`{"Hello": "a"}`), ModTime: modTime},
+		}
+		var cache resultCache
+		switch name {
+		case "FsCache":
+			c := NewFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+			cache = &c
+		case "ConcurrentFsCache":
+			cache = NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+		case "SubCache":
+			c := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+			cache = c.Sub("/")
+		case "TwoLevelCache":
+			cache = NewTwoLevelCache(filesystem, JsonParser[testFileStructure], time.Minute, 10, t.TempDir())
+		}
+
+		before := time.Now()
+		a, result, err := cache.GetFileResult("sub/a.json")
+		if err != nil || a.Hello != "a" {
+			t.Errorf("%s: sub/a.json not parsed: %+v, %v", name, a, err)
+		}
+		if result.Hit || !result.Parsed || result.ParseStart.Before(before) || result.ParseDuration < 0 || result.CachedAt.Before(before) {
+			t.Errorf("%s: incorrect result of the first get: %+v", name, result)
+		}
+		cachedAt := result.CachedAt
+
+		_, result, err = cache.GetFileResult("sub/a.json")
+		if err != nil || !result.Hit || result.Parsed || !result.CachedAt.Equal(cachedAt) {
+			t.Errorf("%s: incorrect result of a hit: %+v, %v", name, result, err)
+		}
+
+		_, result, err = cache.GetFileResult("sub/missing.json")
+		if err == nil || result.Hit || result.Parsed || !result.CachedAt.IsZero() {
+			t.Errorf("%s: incorrect result of a missing file: %+v, %v", name, result, err)
+		}
+
+		entries, result, err := cache.GetDirResult("sub")
+		if err != nil || len(entries) != 1 || result.Hit || result.Parsed || result.CachedAt.Before(before) {
+			t.Errorf("%s: incorrect result of the first get of sub: %+v, %v", name, result, err)
+		}
+		_, result, err = cache.GetDirResult("sub")
+		if err != nil || !result.Hit || result.Parsed {
+			t.Errorf("%s: incorrect result of a hit of sub: %+v, %v", name, result, err)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package parsecache
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"time"
+)
+
+// retryConfig configures how failed loads are retried.
+type retryConfig struct {
+	// maxAttempts is the maximum number of attempts of a single load, including the first.
+	maxAttempts int
+	// initialDelay is the delay before the first retry, which doubles after each retry.
+	initialDelay time.Duration
+}
+
+// WithRetry retries a load of a file or directory which fails, for example because the file
+// couldn't be opened or parsed, making up to `maxAttempts` attempts in total. The first retry is
+// after `initialDelay`, and the delay doubles after each retry. Loads which fail because the file
+// doesn't exist aren't retried. If `maxAttempts` is less than 2, loads are never retried.
+//
+// This is useful on filesystems, such as network shares, which fail occasionally. The retries are
+// part of a single load, so, as with `WithLoadTimeout`, each attempt is timed separately, and a
+// circuit breaker counts all the attempts as one failure. When the load is for `GetFileCtx`, no
+// more attempts are made once its context is done. Each failed attempt is passed to the hook set by
+// `WithOnMiss`.
+//
+// A `ConcurrentFsCache` doesn't lock the entry while it waits between attempts, so other gets of
+// the entry aren't blocked, and if one of them loads it in the meantime, that's used instead of
+// making another attempt.
+func WithRetry[T any](maxAttempts int, initialDelay time.Duration) Option[T] {
+	return func(o *options[T]) {
+		o.load.retry = retryConfig{
+			maxAttempts:  maxAttempts,
+			initialDelay: initialDelay,
+		}
+	}
+}
+
+// withRetry calls `load` until it succeeds, fails with an error which shouldn't be retried, or has
+// been called `config.maxAttempts` times, waiting between attempts as configured. It stops early,
+// failing with `ctx.Err()`, if `ctx` is done.
+func withRetry[V any](ctx context.Context, config retryConfig, load func() (V, error)) (V, error) {
+	delay := config.initialDelay
+	for attempt := 1; ; attempt++ {
+		value, err := load()
+		if err == nil || attempt >= config.maxAttempts || errors.Is(err, fs.ErrNotExist) {
+			return value, err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return value, ctx.Err()
+		}
+		delay *= 2
+	}
+}
@@ -0,0 +1,163 @@
+package parsecache
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// flakyFS wraps a filesystem and fails the first `failures` opens.
+type flakyFS struct {
+	fs       fs.FS
+	lock     sync.Mutex
+	failures int
+	opens    int
+}
+
+var errFlaky = errors.New("flaky filesystem")
+
+func (f *flakyFS) Open(name string) (fs.File, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.opens++
+	if f.opens <= f.failures {
+		return nil, errFlaky
+	}
+	return f.fs.Open(name)
+}
+
+// Opens returns the number of times a file has been opened.
+func (f *flakyFS) Opens() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.opens
+}
+
+func retryTests(t *testing.T, cache testInterface, filesystem *flakyFS) {
+	a, err := cache.GetFile("a.json")
+	if err != nil {
+		t.Fatalf("a.json not loaded after retrying: %v", err)
+	}
+	if a.Hello != "world" {
+		t.Error("a.json not parsed correctly")
+	}
+	if filesystem.Opens() != 3 {
+		t.Errorf("a.json opened %d times, expected 3", filesystem.Opens())
+	}
+
+	// Missing files aren't retried.
+	_, err = cache.GetFile("missing.json")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Error("missing.json didn't fail with ErrNotExist")
+	}
+	if filesystem.Opens() != 4 {
+		t.Error("missing.json was retried")
+	}
+
+	// Give up after maxAttempts.
+	filesystem.lock.Lock()
+	filesystem.failures = filesystem.opens + 5
+	filesystem.lock.Unlock()
+	_, err = cache.GetFile("b.json")
+	if !errors.Is(err, errFlaky) {
+		t.Errorf("b.json didn't fail with the filesystem error: %v", err)
+	}
+	if filesystem.Opens() != 7 {
+		t.Errorf("b.json opened %d times, expected 3 more", filesystem.Opens()-4)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	retry := WithRetry[testFileStructure](3, time.Millisecond)
+	for _, concurrent := range []bool{false, true} {
+		filesystem := &flakyFS{
+			fs: fstest.MapFS{
+				"a.json": &fstest.MapFile{Data: []byte(`{"Hello": "world"}`)},
+				"b.json": &fstest.MapFile{Data: []byte(`{"Hello": "world"}`)},
+			},
+			failures: 2,
+		}
+		if concurrent {
+			cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute, retry)
+			retryTests(t, cache, filesystem)
+		} else {
+			cache := NewFsCache(filesystem, JsonParser[testFileStructure], time.Minute, retry)
+			retryTests(t, &cache, filesystem)
+		}
+	}
+}
+
+func TestRetryCtx(t *testing.T) {
+	filesystem := &flakyFS{
+		fs: fstest.MapFS{
+			"a.json": &fstest.MapFile{Data: []byte(`{"Hello": "world"}`)},
+		},
+		failures: 100,
+	}
+	cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute, WithRetry[testFileStructure](100, time.Second/20))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second/10)
+	defer cancel()
+	_, err := cache.GetFileCtx(ctx, "a.json")
+	if err != context.DeadlineExceeded {
+		t.Errorf("GetFileCtx didn't fail with the context's error: %v", err)
+	}
+
+	// The retry loop stops once the context is done.
+	time.Sleep(time.Second / 5)
+	opens := filesystem.Opens()
+	time.Sleep(time.Second / 2)
+	if filesystem.Opens() != opens {
+		t.Error("retries continued after the context was done")
+	}
+	if opens > 3 {
+		t.Errorf("a.json opened %d times before the context was done", opens)
+	}
+}
+
+func TestRetryUnlocked(t *testing.T) {
+	filesystem := &flakyFS{
+		fs: fstest.MapFS{
+			"a.json": &fstest.MapFile{Data: []byte(`{"Hello": "world"}`)},
+		},
+	}
+	maxAge := time.Second / 2
+	delay := time.Second / 4
+	cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], maxAge, WithRetry[testFileStructure](2, delay))
+	if _, err := cache.GetFile("a.json"); err != nil {
+		panic(err)
+	}
+
+	// The next open fails, so the next get waits before retrying, without holding the lock.
+	time.Sleep(maxAge)
+	filesystem.lock.Lock()
+	filesystem.failures = filesystem.opens + 1
+	filesystem.lock.Unlock()
+	done := make(chan error)
+	go func() {
+		_, err := cache.GetFile("a.json")
+		done <- err
+	}()
+	time.Sleep(delay / 4)
+
+	start := time.Now()
+	a, err := cache.GetFile("a.json")
+	if err != nil || a.Hello != "world" {
+		t.Errorf("a.json not loaded while another get was waiting to retry: %+v, %v", a, err)
+	}
+	if elapsed := time.Since(start); elapsed > delay/2 {
+		t.Errorf("get blocked for %v by another get waiting to retry", elapsed)
+	}
+
+	// The waiting get uses the entry which was loaded in the meantime.
+	if err := <-done; err != nil {
+		t.Errorf("retried get failed: %v", err)
+	}
+	if opens := filesystem.Opens(); opens != 3 {
+		t.Errorf("a.json opened %d times, expected 3", opens)
+	}
+}
@@ -0,0 +1,71 @@
+package parsecache
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestSetFS(t *testing.T) {
+	maxAge := time.Second / 20
+	modTime := time.Now().Add(-time.Hour)
+	oldFS := fstest.MapFS{
+		"a.json":    &fstest.MapFile{Data: []byte(`{"Hello": "old"}`), ModTime: modTime},
+		"dir":       &fstest.MapFile{Mode: fs.ModeDir, ModTime: modTime},
+		"dir/b.txt": &fstest.MapFile{ModTime: modTime},
+	}
+	newFS := fstest.MapFS{
+		"a.json":    &fstest.MapFile{Data: []byte(`{"Hello": "new"}`), ModTime: time.Now()},
+		"dir":       &fstest.MapFile{Mode: fs.ModeDir, ModTime: time.Now()},
+		"dir/b.txt": &fstest.MapFile{ModTime: modTime},
+		"dir/c.txt": &fstest.MapFile{ModTime: modTime},
+	}
+	cache := NewConcurrentFsCache(oldFS, JsonParser[testFileStructure], maxAge)
+	a, err := cache.GetFile("a.json")
+	if err != nil {
+		panic(err)
+	}
+	entries, err := cache.GetDir("dir")
+	if err != nil {
+		panic(err)
+	}
+	if a.Hello != "old" || len(entries) != 1 {
+		t.Error("old filesystem not read correctly")
+	}
+
+	cache.SetFS(newFS)
+
+	// Entries are kept until they reach their maximum age.
+	a, err = cache.GetFile("a.json")
+	if err != nil {
+		panic(err)
+	}
+	if a.Hello != "old" {
+		t.Error("entry not kept after SetFS")
+	}
+
+	time.Sleep(maxAge)
+	a, err = cache.GetFile("a.json")
+	if err != nil {
+		panic(err)
+	}
+	entries, err = cache.GetDir("dir")
+	if err != nil {
+		panic(err)
+	}
+	if a.Hello != "new" || len(entries) != 2 {
+		t.Error("entries not revalidated against the new filesystem")
+	}
+
+	// Clear discards the entries immediately.
+	cache.SetFS(oldFS)
+	cache.Clear()
+	a, err = cache.GetFile("a.json")
+	if err != nil {
+		panic(err)
+	}
+	if a.Hello != "old" {
+		t.Error("entry not reloaded from the new filesystem after Clear")
+	}
+}
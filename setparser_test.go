@@ -0,0 +1,90 @@
+package parsecache
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+type testSetParserInterface interface {
+	testInterface
+	SetParser(Parser[testFileStructure])
+	Parser() Parser[testFileStructure]
+}
+
+// parsedHello returns the `Hello` field parsed by the parser of `cache` from a JSON object.
+func parsedHello(cache testSetParserInterface) string {
+	parsed, err := cache.Parser()(strings.NewReader(`{"Hello": "parser"}`))
+	if err != nil {
+		panic(err)
+	}
+	return parsed.Hello
+}
+
+func setParserTests(t *testing.T, cache testSetParserInterface, mapFS fstest.MapFS, maxAge time.Duration) {
+	a, err := cache.GetFile("a.json")
+	if err != nil {
+		panic(err)
+	}
+	if a.Hello != "old" {
+		t.Error("a.json not parsed correctly")
+	}
+	if parsedHello(cache) != "parser" {
+		t.Error("Parser didn't return the initial parser")
+	}
+
+	cache.SetParser(func(f io.Reader) (testFileStructure, error) {
+		parsed, err := JsonParser[testFileStructure](f)
+		parsed.Hello = strings.ToUpper(parsed.Hello)
+		return parsed, err
+	})
+	if parsedHello(cache) != "PARSER" {
+		t.Error("Parser didn't return the parser set by SetParser")
+	}
+
+	// Existing entries keep their content.
+	a, err = cache.GetFile("a.json")
+	if err != nil {
+		panic(err)
+	}
+	if a.Hello != "old" {
+		t.Error("entry not kept after SetParser")
+	}
+
+	// New entries, and changed files, use the new parser.
+	b, err := cache.GetFile("b.json")
+	if err != nil {
+		panic(err)
+	}
+	if b.Hello != "B" {
+		t.Error("new parser not used for b.json")
+	}
+	mapFS["a.json"] = &fstest.MapFile{Data: []byte(`{"Hello": "new"}`), ModTime: time.Now()}
+	time.Sleep(maxAge)
+	a, err = cache.GetFile("a.json")
+	if err != nil {
+		panic(err)
+	}
+	if a.Hello != "NEW" {
+		t.Error("new parser not used for changed a.json")
+	}
+}
+
+func TestSetParser(t *testing.T) {
+	maxAge := time.Second / 20
+	for _, concurrent := range []bool{false, true} {
+		mapFS := fstest.MapFS{
+			"a.json": &fstest.MapFile{Data: []byte(`{"Hello": "old"}`)},
+			"b.json": &fstest.MapFile{Data: []byte(`{"Hello": "b"}`)},
+		}
+		if concurrent {
+			cache := NewConcurrentFsCache(mapFS, JsonParser[testFileStructure], maxAge)
+			setParserTests(t, cache, mapFS, maxAge)
+		} else {
+			cache := NewFsCache(mapFS, JsonParser[testFileStructure], maxAge)
+			setParserTests(t, &cache, mapFS, maxAge)
+		}
+	}
+}
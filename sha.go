@@ -0,0 +1,62 @@
+package parsecache
+
+import (
+	"context"
+	"crypto/sha256"
+	"io"
+	"io/fs"
+	"time"
+)
+
+// WithSHA256 keeps the SHA-256 of the content of each file as of when it was last parsed, which is
+// returned by `CachedFile.Hash`, for example to check which version of a file the cached content
+// was parsed from. The hash is computed as the file is parsed, and the rest of the file is read
+// once the parser returns, so that the hash is of the whole file. Parsers are given a file which
+// only implements `fs.File`, so they can't seek it.
+func WithSHA256[T any]() Option[T] {
+	return func(o *options[T]) {
+		o.load.sha256 = true
+	}
+}
+
+// Hash returns the SHA-256 of the content of the file as of when it was last parsed, if `WithSHA256`
+// is used, or the zero value otherwise.
+func (f *CachedFile[T]) Hash() [32]byte {
+	return f.sum
+}
+
+// Hash returns the SHA-256 of the content of the file, like `CachedFile.Hash`.
+func (f *ConcurrentCachedFile[T]) Hash() [32]byte {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return f.cachedFile.Hash()
+}
+
+// parseSummed is `parse`, setting the content, and the time the parse started and how long it took,
+// of `load`. If `config` enables `WithSHA256`, it also sets the SHA-256 of the whole of `f`.
+// Successful parses are passed to the hook set by `WithOnParse`.
+func parseSummed[T any](ctx context.Context, path string, parser fileParser[T], f fs.File, info fs.FileInfo, load *fileLoad[T], config loadConfig) error {
+	if !config.sha256 {
+		load.parseStart = time.Now()
+		content, err := parse(ctx, path, parser, f, info)
+		load.content, load.parseDuration = content, time.Since(load.parseStart)
+		if err == nil {
+			parsed(config, path, content, load.parseDuration)
+		}
+		return err
+	}
+	h := sha256.New()
+	r := io.TeeReader(f, h)
+	load.parseStart = time.Now()
+	content, err := parse(ctx, path, parser, readerFile{f, r}, info)
+	load.content, load.parseDuration = content, time.Since(load.parseStart)
+	if err != nil {
+		return err
+	}
+	parsed(config, path, content, load.parseDuration)
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return err
+	}
+	h.Sum(load.sum[:0])
+	return nil
+}
@@ -0,0 +1,67 @@
+package parsecache
+
+import (
+	"crypto/sha256"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestSHA256(t *testing.T) {
+	maxAge := time.Second / 20
+	modTime := time.Now().Add(-time.Hour)
+	// The trailing whitespace isn't read by the JSON parser, but is included in the hash.
+	data := []byte(`{"Number": 1}` + "\n\n")
+	for _, opts := range [][]Option[testFileStructure]{
+		{WithSHA256[testFileStructure]()},
+		{WithSHA256[testFileStructure](), WithContentHash[testFileStructure]()},
+	} {
+		for _, concurrent := range []bool{false, true} {
+			mapFS := fstest.MapFS{
+				"a.json": &fstest.MapFile{Data: data, ModTime: modTime},
+			}
+			var hash func() [32]byte
+			var cache testInterface
+			if concurrent {
+				c := NewConcurrentFsCache(mapFS, JsonParser[testFileStructure], maxAge, opts...)
+				cache = c
+				hash = func() [32]byte {
+					entry, _ := c.GetFileEntry("a.json")
+					return entry.Hash()
+				}
+			} else {
+				c := NewFsCache(mapFS, JsonParser[testFileStructure], maxAge, opts...)
+				cache = &c
+				hash = func() [32]byte {
+					entry, _ := c.GetFileEntry("a.json")
+					return entry.Hash()
+				}
+			}
+			if _, err := cache.GetFile("a.json"); err != nil {
+				panic(err)
+			}
+			if hash() != sha256.Sum256(data) {
+				t.Errorf("concurrent=%v, %d options: wrong hash", concurrent, len(opts))
+			}
+
+			changed := []byte(`{"Number": 22}`)
+			mapFS["a.json"] = &fstest.MapFile{Data: changed, ModTime: time.Now()}
+			time.Sleep(maxAge)
+			a, err := cache.GetFile("a.json")
+			if err != nil || a.Number != 22 {
+				t.Errorf("concurrent=%v, %d options: changed file not parsed: %+v, %v", concurrent, len(opts), a, err)
+			}
+			if hash() != sha256.Sum256(changed) {
+				t.Errorf("concurrent=%v, %d options: hash not updated", concurrent, len(opts))
+			}
+		}
+	}
+
+	// Without the option, the hash isn't kept.
+	cache := NewFsCache(fstest.MapFS{"a.json": &fstest.MapFile{Data: data}}, JsonParser[testFileStructure], maxAge)
+	cache.GetFile("a.json")
+	entry, _ := cache.GetFileEntry("a.json")
+	if entry.Hash() != [32]byte{} {
+		t.Error("hash kept without WithSHA256")
+	}
+}
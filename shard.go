@@ -0,0 +1,86 @@
+package parsecache
+
+import (
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// WithShards splits the cached files and directories of a `ConcurrentFsCache` into `n` shards,
+// each with its own locks, so gets of different paths rarely wait for each other's locks. Paths are
+// assigned to shards by their FNV-1a hash. If `n` is less than 2 (the default), there's a single
+// shard. It has no effect on an `FsCache`.
+//
+// Operations on the whole cache, such as `Clear`, `Stats`, `Entries` and evicting files because of
+// `WithMaxEntries`, lock every shard in turn.
+func WithShards[T any](n int) Option[T] {
+	return func(o *options[T]) {
+		o.shards = n
+	}
+}
+
+// cacheShard holds the entries of a `ConcurrentFsCache` for the paths assigned to it.
+type cacheShard[T any] struct {
+	// dirs is the map of cleanedPath -> cachedDir
+	dirs     map[string]*ConcurrentCachedDir
+	dirsLock sync.RWMutex
+
+	// files is the map of cleanedPath -> cachedFile
+	files     map[string]*ConcurrentCachedFile[T]
+	filesLock sync.RWMutex
+}
+
+// cacheSettings is the part of the configuration of a `ConcurrentFsCache` which can be changed
+// after it's created. It's replaced, rather than modified, so it can be read without a lock.
+type cacheSettings[T any] struct {
+	// fs is the underlying filesystem, it is assumed that this is safe for concurrent use.
+	fs fs.FS
+	// parser is the function used to parse a file.
+	parser ParserCtx[T]
+	// maxAge is the maximum allowed age of a cache entry.
+	maxAge time.Duration
+}
+
+// newShards returns the shards of a cache with the `n` shards set by `WithShards`.
+func newShards[T any](n int) []cacheShard[T] {
+	if n < 1 {
+		n = 1
+	}
+	return make([]cacheShard[T], n)
+}
+
+// shard returns the shard of the cleaned `path`.
+func (cache *ConcurrentFsCache[T]) shard(path string) *cacheShard[T] {
+	if len(cache.shards) == 1 {
+		return &cache.shards[0]
+	}
+	return &cache.shards[fnv32a(path)%uint32(len(cache.shards))]
+}
+
+// fnv32a returns the 32-bit FNV-1a hash of `s`, without the allocation of `hash/fnv`.
+func fnv32a(s string) uint32 {
+	const (
+		offset = 2166136261
+		prime  = 16777619
+	)
+	h := uint32(offset)
+	for i := 0; i < len(s); i++ {
+		h ^= uint32(s[i])
+		h *= prime
+	}
+	return h
+}
+
+// loadSettings returns the current settings of the cache.
+func (cache *ConcurrentFsCache[T]) loadSettings() *cacheSettings[T] {
+	return cache.settings.Load().(*cacheSettings[T])
+}
+
+// updateSettings replaces the settings of the cache with a copy modified by `update`.
+func (cache *ConcurrentFsCache[T]) updateSettings(update func(*cacheSettings[T])) {
+	cache.settingsLock.Lock()
+	defer cache.settingsLock.Unlock()
+	settings := *cache.loadSettings()
+	update(&settings)
+	cache.settings.Store(&settings)
+}
@@ -0,0 +1,85 @@
+package parsecache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestShards(t *testing.T) {
+	filesystem := fstest.MapFS{}
+	for i := 0; i < 32; i++ {
+		filesystem[fmt.Sprintf("dir%d/%d.json", i%4, i)] = &fstest.MapFile{Data: []byte(fmt.Sprintf(`{"Number": %d}`, i))}
+	}
+	cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute, WithShards[testFileStructure](8))
+	if len(cache.shards) != 8 {
+		t.Fatalf("expected 8 shards, got %d", len(cache.shards))
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 32; i++ {
+				content, err := cache.GetFile(fmt.Sprintf("dir%d/%d.json", i%4, i))
+				if err != nil {
+					t.Error(err)
+				} else if content.Number != uint16(i) {
+					t.Errorf("incorrect content of %d.json: %+v", i, content)
+				}
+				if _, err := cache.GetDir(fmt.Sprintf("dir%d", i%4)); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	used := 0
+	for i := range cache.shards {
+		if len(cache.shards[i].files) > 0 {
+			used++
+		}
+	}
+	if used < 2 {
+		t.Errorf("files in only %d of the shards", used)
+	}
+	if stats := cache.Stats(); stats.Files != 32 || stats.Dirs != 4 {
+		t.Errorf("incorrect stats: %+v", stats)
+	}
+	if entries := cache.Entries(); len(entries) != 36 {
+		t.Errorf("expected 36 entries, got %d", len(entries))
+	}
+	if _, ok := cache.GetFileEntry("dir1/5.json"); !ok {
+		t.Error("dir1/5.json not cached")
+	}
+
+	cache.ClearFiles()
+	if stats := cache.Stats(); stats.Files != 0 || stats.Dirs != 4 {
+		t.Errorf("incorrect stats after clearing files: %+v", stats)
+	}
+}
+
+func TestShardsMaxEntries(t *testing.T) {
+	filesystem := fstest.MapFS{}
+	for i := 0; i < 16; i++ {
+		filesystem[fmt.Sprintf("%d.json", i)] = &fstest.MapFile{Data: []byte(`{}`)}
+	}
+	cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute, WithShards[testFileStructure](4), WithMaxEntries[testFileStructure](5))
+	for i := 0; i < 16; i++ {
+		if _, err := cache.GetFile(fmt.Sprintf("%d.json", i)); err != nil {
+			panic(err)
+		}
+	}
+	if stats := cache.Stats(); stats.Files != 5 || stats.Evictions != 11 {
+		t.Errorf("incorrect stats: %+v", stats)
+	}
+	for i := 11; i < 16; i++ {
+		if _, ok := cache.GetFileEntry(fmt.Sprintf("%d.json", i)); !ok {
+			t.Errorf("%d.json, which was recently used, was evicted", i)
+		}
+	}
+}
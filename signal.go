@@ -0,0 +1,28 @@
+package parsecache
+
+import (
+	"os"
+	"os/signal"
+)
+
+// ListenForInvalidationSignal clears the cache whenever the process receives `sig` (for example,
+// `syscall.SIGUSR1`), so that operators can flush the cache without restarting the process.
+//
+// The returned function stops listening for the signal, like `signal.Stop`. It may be called more
+// than once. `Close` stops listening too.
+func (cache *ConcurrentFsCache[T]) ListenForInvalidationSignal(sig os.Signal) func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, sig)
+	return cache.background.start(func(done <-chan struct{}) {
+		for {
+			select {
+			case <-signals:
+				cache.Clear()
+			case <-done:
+				return
+			}
+		}
+	}, func() {
+		signal.Stop(signals)
+	})
+}
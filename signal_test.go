@@ -0,0 +1,102 @@
+//go:build unix
+
+package parsecache
+
+import (
+	"syscall"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// waitForEntry waits up to a second for `file` to be cached, or not, and returns whether it is.
+func waitForEntry(cache *ConcurrentFsCache[testFileStructure], file string, cached bool) bool {
+	deadline := time.Now().Add(time.Second)
+	for {
+		_, ok := cache.GetFileEntry(file)
+		if ok == cached || time.Now().After(deadline) {
+			return ok
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestListenForInvalidationSignal(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"a.json": &fstest.MapFile{Data: []byte(`{"Hello": "world"}`)},
+	}
+	cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+	stop := cache.ListenForInvalidationSignal(syscall.SIGUSR1)
+
+	_, err := cache.GetFile("a.json")
+	if err != nil {
+		panic(err)
+	}
+	err = syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	if err != nil {
+		panic(err)
+	}
+	if waitForEntry(cache, "a.json", false) {
+		t.Error("cache not cleared by the signal")
+	}
+
+	stop()
+	stop()
+	_, err = cache.GetFile("a.json")
+	if err != nil {
+		panic(err)
+	}
+
+	// Keep the signal from killing the test process once the handler has stopped.
+	keep := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+	_, err = keep.GetFile("a.json")
+	if err != nil {
+		panic(err)
+	}
+	defer keep.ListenForInvalidationSignal(syscall.SIGUSR1)()
+	err = syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	if err != nil {
+		panic(err)
+	}
+	if waitForEntry(keep, "a.json", false) {
+		t.Error("cache not cleared by the signal")
+	}
+	if _, ok := cache.GetFileEntry("a.json"); !ok {
+		t.Error("cache cleared after the handler was stopped")
+	}
+}
+
+func TestCloseStopsInvalidationSignal(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"a.json": &fstest.MapFile{Data: []byte(`{"Hello": "world"}`)},
+	}
+	cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+	stop := cache.ListenForInvalidationSignal(syscall.SIGUSR1)
+	if err := cache.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	stop()
+	_, err := cache.GetFile("a.json")
+	if err != nil {
+		panic(err)
+	}
+
+	// Keep the signal from killing the test process once the handler has stopped.
+	keep := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+	defer keep.Close()
+	_, err = keep.GetFile("a.json")
+	if err != nil {
+		panic(err)
+	}
+	keep.ListenForInvalidationSignal(syscall.SIGUSR1)
+	err = syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	if err != nil {
+		panic(err)
+	}
+	if waitForEntry(keep, "a.json", false) {
+		t.Error("cache not cleared by the signal")
+	}
+	if _, ok := cache.GetFileEntry("a.json"); !ok {
+		t.Error("cache cleared after it was closed")
+	}
+}
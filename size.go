@@ -0,0 +1,67 @@
+package parsecache
+
+import (
+	"errors"
+	"fmt"
+)
+
+// WithMaxCachedFileSize stops files larger than `maxSize` bytes from being cached. Such a file is
+// still opened and parsed on every access, and its content is returned, but it isn't kept in
+// memory, so a single huge file can't evict everything else or use up memory. A file which was
+// cached and grows beyond the limit is removed from the cache the next time it's loaded. If a
+// logger is set by `WithLogger`, each such file is logged.
+//
+// Directories and files inside archives aren't limited. Use `WithFileSizeLimit` to make large files
+// an error instead, so they aren't parsed at all.
+func WithMaxCachedFileSize[T any](maxSize int64) Option[T] {
+	return func(o *options[T]) {
+		o.maxCachedFileSize = maxSize
+	}
+}
+
+// ErrFileTooLarge is returned, wrapped in a `*FileTooLargeError`, when a file is larger than the
+// limit set by `WithFileSizeLimit`.
+var ErrFileTooLarge = errors.New("parsecache: file too large")
+
+// FileTooLargeError is the error returned, wrapped in an `*fs.PathError` with the path of the file,
+// when a file is larger than the limit set by `WithFileSizeLimit`. It unwraps to `ErrFileTooLarge`.
+type FileTooLargeError struct {
+	// Size is the size of the file.
+	Size int64
+	// Limit is the size limit.
+	Limit int64
+}
+
+func (err *FileTooLargeError) Error() string {
+	return fmt.Sprintf("%v: %d bytes is over the limit of %d bytes", ErrFileTooLarge, err.Size, err.Limit)
+}
+
+func (err *FileTooLargeError) Unwrap() error {
+	return ErrFileTooLarge
+}
+
+// WithFileSizeLimit stops files larger than `limit` bytes from being parsed. Once such a file has
+// been stat-ed, getting it fails with a `*FileTooLargeError` without calling the parser, so a huge
+// file left in place of a small one can't use up memory or time. As with any other failed load, the
+// previously cached content (if there is any) is returned alongside the error. The limit applies to
+// archives read by `GetArchiveFile`, and the files inside them, too.
+//
+// Unlike `WithMaxCachedFileSize`, which still parses large files but doesn't cache them, this makes
+// large files an error.
+func WithFileSizeLimit[T any](limit int64) Option[T] {
+	return func(o *options[T]) {
+		o.load.sizeLimit = limit
+	}
+}
+
+// tooLarge returns true, and logs it, if the file at `path`, of `size` bytes, is larger than the
+// limit set by `WithMaxCachedFileSize`.
+func (o *options[T]) tooLarge(path string, size int64) bool {
+	if o.maxCachedFileSize <= 0 || size <= o.maxCachedFileSize {
+		return false
+	}
+	if o.logger != nil {
+		o.logger.notCached(path, size)
+	}
+	return true
+}
@@ -0,0 +1,134 @@
+package parsecache
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// notCachedLogger is a `cacheLogger` which records the files which weren't cached.
+type notCachedLogger struct {
+	notCachedPaths []string
+}
+
+func (l *notCachedLogger) hit(kind, path string, age time.Duration)                  {}
+func (l *notCachedLogger) miss(kind, path string, age time.Duration)                 {}
+func (l *notCachedLogger) loadError(kind, path string, age time.Duration, err error) {}
+func (l *notCachedLogger) evicted(path string)                                       {}
+func (l *notCachedLogger) unchanged(path string)                                     {}
+func (l *notCachedLogger) notCached(path string, size int64) {
+	l.notCachedPaths = append(l.notCachedPaths, path)
+}
+
+func maxCachedFileSizeTests(t *testing.T, cache testInterface, filesystem *countingFS, mapFS fstest.MapFS) {
+	for i := 0; i < 2; i++ {
+		small, err := cache.GetFile("small.json")
+		if err != nil {
+			panic(err)
+		}
+		big, err := cache.GetFile("big.json")
+		if err != nil {
+			panic(err)
+		}
+		if small.Hello != "small" || big.Hello != "big, but not too big to parse" {
+			t.Error("files not parsed correctly")
+		}
+	}
+	if filesystem.Opens() != 3 {
+		t.Errorf("big.json cached or small.json not cached, %d opens", filesystem.Opens())
+	}
+
+	// A cached file which grows is removed.
+	mapFS["small.json"] = &fstest.MapFile{Data: []byte(`{"Hello": "not so small any more"}`), ModTime: time.Now()}
+	time.Sleep(time.Second / 20)
+	for i := 0; i < 2; i++ {
+		small, err := cache.GetFile("small.json")
+		if err != nil {
+			panic(err)
+		}
+		if small.Hello != "not so small any more" {
+			t.Error("small.json not parsed correctly after growing")
+		}
+	}
+	if filesystem.Opens() != 5 {
+		t.Errorf("small.json cached after growing, %d opens", filesystem.Opens())
+	}
+}
+
+func TestWithMaxCachedFileSize(t *testing.T) {
+	for _, concurrent := range []bool{false, true} {
+		mapFS := fstest.MapFS{
+			"small.json": &fstest.MapFile{Data: []byte(`{"Hello": "small"}`)},
+			"big.json":   &fstest.MapFile{Data: []byte(`{"Hello": "big, but not too big to parse"}`)},
+		}
+		filesystem := &countingFS{fs: mapFS}
+		logger := &notCachedLogger{}
+		opts := []Option[testFileStructure]{
+			WithMaxCachedFileSize[testFileStructure](20),
+			WithLogger[testFileStructure](logger),
+		}
+		if concurrent {
+			cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Second/20, opts...)
+			maxCachedFileSizeTests(t, cache, filesystem, mapFS)
+		} else {
+			cache := NewFsCache(filesystem, JsonParser[testFileStructure], time.Second/20, opts...)
+			maxCachedFileSizeTests(t, &cache, filesystem, mapFS)
+		}
+		if !equalNames(logger.notCachedPaths, []string{"/big.json", "/big.json", "/small.json", "/small.json"}) {
+			t.Errorf("files too large to cache not logged: %v", logger.notCachedPaths)
+		}
+	}
+}
+
+func fileSizeLimitTests(t *testing.T, cache testInterface, mapFS fstest.MapFS) {
+	small, err := cache.GetFile("small.json")
+	if err != nil || small.Hello != "small" {
+		t.Errorf("small.json not parsed correctly: %v", err)
+	}
+
+	_, err = cache.GetFile("big.json")
+	var tooLarge *FileTooLargeError
+	if !errors.Is(err, ErrFileTooLarge) || !errors.As(err, &tooLarge) || tooLarge.Size != 42 || tooLarge.Limit != 20 {
+		t.Errorf("big.json didn't fail with a FileTooLargeError: %v", err)
+	}
+	var pathErr *fs.PathError
+	if !errors.As(err, &pathErr) || pathErr.Path != "/big.json" {
+		t.Errorf("big.json error doesn't include the path: %v", err)
+	}
+
+	// The previously cached content is returned alongside the error.
+	mapFS["small.json"] = &fstest.MapFile{Data: []byte(`{"Hello": "not so small any more"}`), ModTime: time.Now()}
+	time.Sleep(time.Second / 20)
+	small, err = cache.GetFile("small.json")
+	if !errors.Is(err, ErrFileTooLarge) || small.Hello != "small" {
+		t.Errorf("small.json didn't fail with the previous content after growing: %+v, %v", small, err)
+	}
+}
+
+func TestWithFileSizeLimit(t *testing.T) {
+	for _, concurrent := range []bool{false, true} {
+		mapFS := fstest.MapFS{
+			"small.json": &fstest.MapFile{Data: []byte(`{"Hello": "small"}`)},
+			"big.json":   &fstest.MapFile{Data: []byte(`{"Hello": "big, but not too big to parse"}`)},
+		}
+		// The parser must not be called for files over the limit.
+		parser := func(f io.Reader) (testFileStructure, error) {
+			parsed, err := JsonParser[testFileStructure](f)
+			if parsed.Hello != "small" {
+				t.Errorf("parser called for %q", parsed.Hello)
+			}
+			return parsed, err
+		}
+		opt := WithFileSizeLimit[testFileStructure](20)
+		if concurrent {
+			cache := NewConcurrentFsCache(mapFS, parser, time.Second/20, opt)
+			fileSizeLimitTests(t, cache, mapFS)
+		} else {
+			cache := NewFsCache(mapFS, parser, time.Second/20, opt)
+			fileSizeLimitTests(t, &cache, mapFS)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+//go:build go1.21
+
+package parsecache
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// NewSlogLogger returns a logger, for `WithLogger`, which logs the cache's activity to `logger`:
+// hits at the debug level, loads and revalidations (misses), evictions and files too large to
+// cache at the info level, and failed loads at the warning level. Files parsed again without their
+// content changing, see `WithChangeDetector`, are logged at the debug level. The records include
+// the "path" and the "age" of the entry, and, for failures, the "error". If `logger` is nil, it
+// returns nil, which logs nothing.
+func NewSlogLogger(logger *slog.Logger) cacheLogger {
+	if logger == nil {
+		return nil
+	}
+	return slogLogger{logger}
+}
+
+// slogLogger is the `cacheLogger` returned by `NewSlogLogger`.
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (l slogLogger) hit(kind, path string, age time.Duration) {
+	l.logger.LogAttrs(context.Background(), slog.LevelDebug, "parsecache: "+kind+" hit", slog.String("path", path), slog.Duration("age", age))
+}
+
+func (l slogLogger) miss(kind, path string, age time.Duration) {
+	l.logger.LogAttrs(context.Background(), slog.LevelInfo, "parsecache: "+kind+" miss", slog.String("path", path), slog.Duration("age", age))
+}
+
+func (l slogLogger) loadError(kind, path string, age time.Duration, err error) {
+	l.logger.LogAttrs(context.Background(), slog.LevelWarn, "parsecache: "+kind+" load failed", slog.String("path", path), slog.Duration("age", age), slog.Any("error", err))
+}
+
+func (l slogLogger) evicted(path string) {
+	l.logger.LogAttrs(context.Background(), slog.LevelInfo, "parsecache: file evicted", slog.String("path", path))
+}
+
+func (l slogLogger) unchanged(path string) {
+	l.logger.LogAttrs(context.Background(), slog.LevelDebug, "parsecache: file content unchanged", slog.String("path", path))
+}
+
+func (l slogLogger) notCached(path string, size int64) {
+	l.logger.LogAttrs(context.Background(), slog.LevelInfo, "parsecache: file too large to cache", slog.String("path", path), slog.Int64("size", size))
+}
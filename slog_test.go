@@ -0,0 +1,69 @@
+//go:build go1.21
+
+package parsecache
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func loggerTests(t *testing.T, cache testInterface, logs *bytes.Buffer) {
+	cache.GetFile("a.json")
+	cache.GetFile("a.json")
+	cache.GetFile("b.json")
+	cache.GetFile("bad.json")
+	cache.GetDir("/")
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	expected := []string{
+		`level=INFO msg="parsecache: file miss" path=/a.json age=0s`,
+		`level=DEBUG msg="parsecache: file hit" path=/a.json age=`,
+		`level=INFO msg="parsecache: file miss" path=/b.json age=0s`,
+		`level=INFO msg="parsecache: file evicted" path=/a.json`,
+		`level=WARN msg="parsecache: file load failed" path=/bad.json age=0s error="parsecache.parse /bad.json: `,
+		`level=INFO msg="parsecache: dir miss" path=/ age=0s`,
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("incorrect log records:\n%s", logs.String())
+	}
+	for i := range lines {
+		if !strings.HasPrefix(lines[i], expected[i]) {
+			t.Errorf("incorrect log record %q, expected %q", lines[i], expected[i])
+		}
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"a.json":   &fstest.MapFile{Data: []byte(`{"Hello": "a"}`)},
+		"b.json":   &fstest.MapFile{Data: []byte(`{"Hello": "b"}`)},
+		"bad.json": &fstest.MapFile{Data: []byte(`{"Hello": nope}`)},
+	}
+	for _, concurrent := range []bool{false, true} {
+		var logs bytes.Buffer
+		logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		}))
+		opts := []Option[testFileStructure]{WithLogger[testFileStructure](NewSlogLogger(logger)), WithMaxEntries[testFileStructure](1)}
+		if concurrent {
+			loggerTests(t, NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute, opts...), &logs)
+		} else {
+			cache := NewFsCache(filesystem, JsonParser[testFileStructure], time.Minute, opts...)
+			loggerTests(t, &cache, &logs)
+		}
+	}
+
+	// A nil logger is a no-op.
+	cache := NewFsCache(filesystem, JsonParser[testFileStructure], time.Minute, WithLogger[testFileStructure](NewSlogLogger(nil)))
+	cache.GetFile("a.json")
+}
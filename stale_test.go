@@ -0,0 +1,203 @@
+package parsecache
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// statFailFS wraps a filesystem, and makes opened files fail to be stat-ed with `fs.ErrNotExist`
+// once `removed` is set, as if they were removed after being opened.
+type statFailFS struct {
+	fs      fs.FS
+	lock    sync.Mutex
+	removed bool
+}
+
+func (s *statFailFS) Open(name string) (fs.File, error) {
+	f, err := s.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.removed {
+		return statFailFile{f}, nil
+	}
+	return f, nil
+}
+
+func (s *statFailFS) setRemoved(removed bool) {
+	s.lock.Lock()
+	s.removed = removed
+	s.lock.Unlock()
+}
+
+type statFailFile struct {
+	fs.File
+}
+
+func (f statFailFile) Stat() (fs.FileInfo, error) {
+	return nil, fs.ErrNotExist
+}
+
+func serveStaleTests(t *testing.T, cache testInterface, filesystem *statFailFS, serveStale bool, maxAge time.Duration) {
+	a, err := cache.GetFile("a.json")
+	if err != nil {
+		panic(err)
+	}
+	if a.Hello != "world!" {
+		t.Error("a.json not parsed correctly")
+	}
+
+	filesystem.setRemoved(true)
+	time.Sleep(maxAge)
+	a, err = cache.GetFile("a.json")
+	if serveStale {
+		if err != nil || a.Hello != "world!" {
+			t.Errorf("stale content not served on stat error: %v", err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("stat error not returned: %v", err)
+	}
+
+	// Files which weren't cached still fail.
+	_, err = cache.GetFile("b.json")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("stat error not returned for uncached file: %v", err)
+	}
+
+	// Once the file can be stat-ed again, it's revalidated as normal.
+	filesystem.setRemoved(false)
+	a, err = cache.GetFile("a.json")
+	if err != nil || a.Hello != "world!" {
+		t.Errorf("a.json not loaded correctly after stat error: %v", err)
+	}
+}
+
+func TestServeStaleOnStatError(t *testing.T) {
+	maxAge := time.Second / 20
+	for _, concurrent := range []bool{false, true} {
+		for _, serveStale := range []bool{false, true} {
+			filesystem := &statFailFS{fs: fstest.MapFS{
+				"a.json": &fstest.MapFile{Data: []byte(`{"Hello": "world!"}`)},
+				"b.json": &fstest.MapFile{Data: []byte(`{"Hello": "b"}`)},
+			}}
+			opt := WithServeStaleOnStatError[testFileStructure](serveStale)
+			if concurrent {
+				cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], maxAge, opt)
+				serveStaleTests(t, cache, filesystem, serveStale, maxAge)
+			} else {
+				cache := NewFsCache(filesystem, JsonParser[testFileStructure], maxAge, opt)
+				serveStaleTests(t, &cache, filesystem, serveStale, maxAge)
+			}
+		}
+	}
+}
+
+// openFailFS wraps a filesystem implementing `fs.StatFS`, and makes files fail to be opened with
+// `fs.ErrNotExist` once `removed` is set, while they can still be stat-ed, as if they were removed
+// after being stat-ed.
+type openFailFS struct {
+	fs      fs.StatFS
+	lock    sync.Mutex
+	removed bool
+}
+
+func (o *openFailFS) Open(name string) (fs.File, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if o.removed {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return o.fs.Open(name)
+}
+
+func (o *openFailFS) Stat(name string) (fs.FileInfo, error) {
+	return o.fs.Stat(name)
+}
+
+func (o *openFailFS) setRemoved(removed bool) {
+	o.lock.Lock()
+	o.removed = removed
+	o.lock.Unlock()
+}
+
+func TestServeStaleOnStatErrorStatFS(t *testing.T) {
+	maxAge := time.Second / 20
+	modTime := time.Now().Add(-time.Hour)
+	for _, dirFS := range []bool{false, true} {
+		for _, serveStale := range []bool{false, true} {
+			// write writes a.json, and remove removes it, in the underlying filesystem.
+			var filesystem fs.StatFS
+			var write func(content string, modTime time.Time)
+			var remove func()
+			if dirFS {
+				dir := t.TempDir()
+				filesystem = os.DirFS(dir).(fs.StatFS)
+				write = func(content string, modTime time.Time) {
+					path := filepath.Join(dir, "a.json")
+					if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+						panic(err)
+					}
+					if err := os.Chtimes(path, modTime, modTime); err != nil {
+						panic(err)
+					}
+				}
+				remove = func() {
+					if err := os.Remove(filepath.Join(dir, "a.json")); err != nil {
+						panic(err)
+					}
+				}
+			} else {
+				mapFS := fstest.MapFS{}
+				filesystem = mapFS
+				write = func(content string, modTime time.Time) {
+					mapFS["a.json"] = &fstest.MapFile{Data: []byte(content), ModTime: modTime}
+				}
+				remove = func() {
+					delete(mapFS, "a.json")
+				}
+			}
+			openFail := &openFailFS{fs: filesystem}
+			cache := NewConcurrentFsCache(openFail, JsonParser[testFileStructure], maxAge, WithServeStaleOnStatError[testFileStructure](serveStale))
+			check := func(step string) {
+				a, err := cache.GetFile("a.json")
+				if serveStale {
+					if err != nil || a.Hello != "world!" {
+						t.Errorf("dirFS=%v: %s: stale content not served: %+v, %v", dirFS, step, a, err)
+					}
+				} else if !errors.Is(err, fs.ErrNotExist) || !os.IsNotExist(err) {
+					t.Errorf("dirFS=%v: %s: not-exist error not returned: %v", dirFS, step, err)
+				}
+			}
+
+			write(`{"Hello": "world!"}`, modTime)
+			if a, err := cache.GetFile("a.json"); err != nil || a.Hello != "world!" {
+				t.Fatalf("dirFS=%v: a.json not loaded: %+v, %v", dirFS, a, err)
+			}
+
+			// The file changes, but is removed between being stat-ed and opened.
+			write(`{"Hello": "changed"}`, modTime.Add(time.Minute))
+			openFail.setRemoved(true)
+			time.Sleep(maxAge)
+			check("removed after stat")
+			openFail.setRemoved(false)
+
+			// The file is removed, so it fails to be stat-ed.
+			remove()
+			check("removed before stat")
+
+			// Once it's back, it's loaded as normal.
+			write(`{"Hello": "back"}`, modTime.Add(2*time.Minute))
+			if a, err := cache.GetFile("a.json"); err != nil || a.Hello != "back" {
+				t.Errorf("dirFS=%v: a.json not loaded after it was restored: %+v, %v", dirFS, a, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package parsecache
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of the state of a cache, for monitoring.
+type Stats struct {
+	// Files is the number of cached files, not including files inside archives.
+	Files int
+	// Dirs is the number of cached directories.
+	Dirs int
+	// Evictions is the total number of files evicted because of `WithMaxEntries`.
+	Evictions uint64
+}
+
+// Stats returns a snapshot of the state of the cache.
+func (cache *FsCache[T]) Stats() Stats {
+	return Stats{
+		Files:     len(cache.files),
+		Dirs:      len(cache.dirs),
+		Evictions: cache.evictions,
+	}
+}
+
+// Stats returns a snapshot of the state of the cache.
+func (cache *ConcurrentFsCache[T]) Stats() Stats {
+	var files, dirs int
+	for i := range cache.shards {
+		shard := &cache.shards[i]
+		shard.filesLock.RLock()
+		files += len(shard.files)
+		shard.filesLock.RUnlock()
+		shard.dirsLock.RLock()
+		dirs += len(shard.dirs)
+		shard.dirsLock.RUnlock()
+	}
+	return Stats{
+		Files:     files,
+		Dirs:      dirs,
+		Evictions: atomic.LoadUint64(&cache.evictions),
+	}
+}
+
+// FileCachedAt returns the time the file was last loaded or revalidated, and whether it's cached.
+// Comparing this before and after `GetFile` shows whether the content came from memory.
+func (cache *FsCache[T]) FileCachedAt(file string) (time.Time, bool) {
+	entry, ok := cache.GetFileEntry(file)
+	if !ok {
+		return time.Time{}, false
+	}
+	_, cachedAt, ok := entry.Cached()
+	return cachedAt, ok
+}
+
+// FileCachedAt returns the time the file was last loaded or revalidated, and whether it's cached.
+// Comparing this before and after `GetFile` shows whether the content came from memory.
+func (cache *ConcurrentFsCache[T]) FileCachedAt(file string) (time.Time, bool) {
+	entry, ok := cache.GetFileEntry(file)
+	if !ok {
+		return time.Time{}, false
+	}
+	_, cachedAt, ok := entry.Cached()
+	return cachedAt, ok
+}
+
+// DirCachedAt returns the time the directory was last loaded or revalidated, and whether it's
+// cached.
+func (cache *FsCache[T]) DirCachedAt(dir string) (time.Time, bool) {
+	entry, ok := cache.GetDirEntry(dir)
+	if !ok {
+		return time.Time{}, false
+	}
+	_, cachedAt, ok := entry.Cached()
+	return cachedAt, ok
+}
+
+// DirCachedAt returns the time the directory was last loaded or revalidated, and whether it's
+// cached.
+func (cache *ConcurrentFsCache[T]) DirCachedAt(dir string) (time.Time, bool) {
+	entry, ok := cache.GetDirEntry(dir)
+	if !ok {
+		return time.Time{}, false
+	}
+	_, cachedAt, ok := entry.Cached()
+	return cachedAt, ok
+}
+
+// EntryInfo describes a cached file or directory.
+type EntryInfo struct {
+	// Path is the cleaned path of the entry.
+	Path string
+	// Dir is true for a directory, and false for a file.
+	Dir bool
+	// CachedAt is the time the entry was last loaded or revalidated.
+	CachedAt time.Time
+	// Size is the size of the file or directory when it was last loaded.
+	Size int64
+	// ModTime is the modtime of the file or directory when it was last loaded.
+	ModTime time.Time
+	// Hits is the number of times the entry was returned from memory, without being loaded or
+	// revalidated.
+	Hits uint64
+}
+
+// entryInfo returns the `EntryInfo` of the file entry `f`, at `path`.
+func (f *CachedFile[T]) entryInfo(path string) EntryInfo {
+	return EntryInfo{Path: path, CachedAt: f.lastLoadTime, Size: f.lastSize, ModTime: f.lastModTime, Hits: atomic.LoadUint64(&f.hits)}
+}
+
+// entryInfo returns the `EntryInfo` of the directory entry `d`, at `path`.
+func (d *CachedDir) entryInfo(path string) EntryInfo {
+	return EntryInfo{Path: path, Dir: true, CachedAt: d.lastLoadTime, Size: d.lastSize, ModTime: d.lastModTime, Hits: atomic.LoadUint64(&d.hits)}
+}
+
+// sortEntries sorts `entries` by path, with directories before files with the same path.
+func sortEntries(entries []EntryInfo) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Path != entries[j].Path {
+			return entries[i].Path < entries[j].Path
+		}
+		return entries[i].Dir && !entries[j].Dir
+	})
+}
+
+// Entries returns a description of each cached file and directory, sorted by path, not including
+// files inside archives.
+func (cache *FsCache[T]) Entries() []EntryInfo {
+	entries := make([]EntryInfo, 0, len(cache.dirs)+len(cache.files))
+	for path, entry := range cache.dirs {
+		entries = append(entries, entry.entryInfo(path))
+	}
+	for path, entry := range cache.files {
+		if entry.notExistErr != nil {
+			continue
+		}
+		entries = append(entries, entry.entryInfo(path))
+	}
+	sortEntries(entries)
+	return entries
+}
+
+// Entries returns a description of each cached file and directory, sorted by path, not including
+// files inside archives.
+func (cache *ConcurrentFsCache[T]) Entries() []EntryInfo {
+	dirs := make(map[string]*ConcurrentCachedDir)
+	files := make(map[string]*ConcurrentCachedFile[T])
+	for i := range cache.shards {
+		shard := &cache.shards[i]
+		shard.dirsLock.RLock()
+		for path, entry := range shard.dirs {
+			dirs[path] = entry
+		}
+		shard.dirsLock.RUnlock()
+		shard.filesLock.RLock()
+		for path, entry := range shard.files {
+			files[path] = entry
+		}
+		shard.filesLock.RUnlock()
+	}
+
+	// The cache's locks aren't held while reading the entries, since reading an entry waits for any
+	// load of it in progress.
+	entries := make([]EntryInfo, 0, len(dirs)+len(files))
+	for path, entry := range dirs {
+		entry.lock.RLock()
+		entries = append(entries, entry.cachedDir.entryInfo(path))
+		entry.lock.RUnlock()
+	}
+	for path, entry := range files {
+		entry.lock.RLock()
+		if entry.cachedFile.notExistErr == nil {
+			entries = append(entries, entry.cachedFile.entryInfo(path))
+		}
+		entry.lock.RUnlock()
+	}
+	sortEntries(entries)
+	return entries
+}
@@ -0,0 +1,71 @@
+package parsecache
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"a.json": &fstest.MapFile{Data: []byte(`{"Hello": "a"}`)},
+		"b.json": &fstest.MapFile{Data: []byte(`{"Hello": "b"}`)},
+		"c.json": &fstest.MapFile{Data: []byte(`{"Hello": "c"}`)},
+	}
+	cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute, WithMaxEntries[testFileStructure](2))
+
+	if _, ok := cache.FileCachedAt("a.json"); ok {
+		t.Error("a.json cached before it was loaded")
+	}
+	for _, file := range []string{"a.json", "b.json", "c.json"} {
+		_, err := cache.GetFile(file)
+		if err != nil {
+			panic(err)
+		}
+	}
+	_, err := cache.GetDir("/")
+	if err != nil {
+		panic(err)
+	}
+
+	stats := cache.Stats()
+	if stats.Files != 2 || stats.Dirs != 1 || stats.Evictions != 1 {
+		t.Errorf("incorrect stats: %+v", stats)
+	}
+	cachedAt, ok := cache.FileCachedAt("c.json")
+	if !ok || time.Since(cachedAt) > time.Second {
+		t.Error("c.json cached time not returned")
+	}
+	_, err = cache.GetFile("c.json")
+	if err != nil {
+		panic(err)
+	}
+	if again, _ := cache.FileCachedAt("c.json"); !again.Equal(cachedAt) {
+		t.Error("c.json cached time changed when it was read from memory")
+	}
+	if _, ok := cache.DirCachedAt("/"); !ok {
+		t.Error("/ cached time not returned")
+	}
+
+	entries := cache.Entries()
+	if len(entries) != 3 || entries[0].Path != "/" || !entries[0].Dir || entries[1].Path != "/b.json" || entries[2].Path != "/c.json" {
+		t.Errorf("incorrect entries: %+v", entries)
+	}
+	if entries[1].Size != 14 || entries[1].Dir {
+		t.Errorf("incorrect entry for /b.json: %+v", entries[1])
+	}
+	if entries[0].Hits != 0 || entries[1].Hits != 0 || entries[2].Hits != 1 {
+		t.Errorf("incorrect hits: %+v", entries)
+	}
+
+	fsCache := NewFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+	for i := 0; i < 3; i++ {
+		_, err = fsCache.GetFile("a.json")
+		if err != nil {
+			panic(err)
+		}
+	}
+	if entries := fsCache.Entries(); len(entries) != 1 || entries[0].Hits != 2 {
+		t.Errorf("incorrect hits for an FsCache: %+v", entries)
+	}
+}
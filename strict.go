@@ -0,0 +1,51 @@
+package parsecache
+
+import (
+	"io/fs"
+	"strings"
+)
+
+// WithStrictPaths rejects the paths given to `GetFile`, `GetDir` and `GetArchiveFile` (and their
+// variants), and to the views returned by `Sub`, rather than cleaning them, unless they're valid
+// `io/fs` paths, see `fs.ValidPath`, after removing a single leading slash. So paths containing "."
+// (other than the root, ".") or ".." elements, empty elements or a trailing slash, the empty path,
+// and paths starting with a Windows drive letter or a backslash are rejected, with an
+// `*fs.PathError` wrapping `fs.ErrInvalid`, whose path is the rejected path. "/" is the root, like
+// ".".
+//
+// It's intended for caches given user-supplied paths, which should fail, rather than being resolved
+// to another file, if they try to escape their directory.
+func WithStrictPaths[T any]() Option[T] {
+	return func(o *options[T]) {
+		o.strictPaths = true
+	}
+}
+
+// checkPath returns an error if `path` isn't valid and `WithStrictPaths` is used.
+func (o *options[T]) checkPath(path string) error {
+	if !o.strictPaths || validStrictPath(path) {
+		return nil
+	}
+	return &fs.PathError{Op: "parsecache.open", Path: path, Err: fs.ErrInvalid}
+}
+
+// validStrictPath returns true if `path` is valid for `WithStrictPaths`.
+func validStrictPath(path string) bool {
+	if path == "/" {
+		return true
+	}
+	name := strings.TrimPrefix(path, "/")
+	if strings.HasPrefix(name, `\`) || hasDriveLetter(name) {
+		return false
+	}
+	return fs.ValidPath(name)
+}
+
+// hasDriveLetter returns true if `path` starts with a Windows drive letter, such as "C:".
+func hasDriveLetter(path string) bool {
+	if len(path) < 2 || path[1] != ':' {
+		return false
+	}
+	c := path[0]
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
@@ -0,0 +1,134 @@
+package parsecache
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestStrictPaths(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"a.json":     &fstest.MapFile{Data: []byte(`{"Hello": "a"}`)},
+		"dir/b.json": &fstest.MapFile{Data: []byte(`{"Hello": "b"}`)},
+	}
+	for _, concurrent := range []bool{false, true} {
+		var cache testInterface
+		opts := []Option[testFileStructure]{WithStrictPaths[testFileStructure]()}
+		if concurrent {
+			cache = NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute, opts...)
+		} else {
+			c := NewFsCache(filesystem, JsonParser[testFileStructure], time.Minute, opts...)
+			cache = &c
+		}
+
+		for _, file := range []string{"a.json", "/a.json", "dir/b.json", "/dir/b.json"} {
+			if _, err := cache.GetFile(file); err != nil {
+				t.Errorf("%q rejected: %v", file, err)
+			}
+		}
+		for _, dir := range []string{".", "/", "dir", "/dir"} {
+			if _, err := cache.GetDir(dir); err != nil {
+				t.Errorf("%q rejected: %v", dir, err)
+			}
+		}
+
+		for _, file := range []string{
+			"", "..", "../a.json", "dir/../a.json", "/../a.json", "./a.json", "dir//b.json", "//a.json",
+			"dir/", `C:\a.json`, "C:/a.json", `\\server\a.json`,
+		} {
+			_, err := cache.GetFile(file)
+			var pathErr *fs.PathError
+			if !errors.Is(err, fs.ErrInvalid) || !errors.As(err, &pathErr) || pathErr.Path != file {
+				t.Errorf("%q not rejected with fs.ErrInvalid: %v", file, err)
+			}
+		}
+		for _, dir := range []string{"", "..", "./dir", "dir/.."} {
+			if _, err := cache.GetDir(dir); !errors.Is(err, fs.ErrInvalid) {
+				t.Errorf("directory %q not rejected with fs.ErrInvalid: %v", dir, err)
+			}
+		}
+	}
+}
+
+func TestLenientPaths(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"a.json": &fstest.MapFile{Data: []byte(`{"Hello": "a"}`)},
+	}
+	cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+	for _, file := range []string{"../a.json", "dir/../a.json", "./a.json", "//a.json"} {
+		if _, err := cache.GetFile(file); err != nil {
+			t.Errorf("%q not cleaned: %v", file, err)
+		}
+	}
+	if _, err := cache.GetDir(""); err != nil {
+		t.Errorf("empty path not treated as the root: %v", err)
+	}
+}
+
+func TestStrictPathsSub(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"a.json":     &fstest.MapFile{Data: []byte(`{"Hello": "a"}`)},
+		"dir/b.json": &fstest.MapFile{Data: []byte(`{"Hello": "b"}`)},
+	}
+	for _, concurrent := range []bool{false, true} {
+		var sub SubCache[testFileStructure]
+		opts := []Option[testFileStructure]{WithStrictPaths[testFileStructure]()}
+		if concurrent {
+			sub = NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute, opts...).Sub("dir")
+		} else {
+			c := NewFsCache(filesystem, JsonParser[testFileStructure], time.Minute, opts...)
+			sub = c.Sub("dir")
+		}
+
+		if b, err := sub.GetFile("b.json"); err != nil || b.Hello != "b" {
+			t.Errorf("concurrent=%v: b.json not loaded: %+v, %v", concurrent, b, err)
+		}
+		for _, file := range []string{"../a.json", "x/../b.json", "./b.json", ""} {
+			_, err := sub.GetFile(file)
+			var pathErr *fs.PathError
+			if !errors.Is(err, fs.ErrInvalid) || !errors.As(err, &pathErr) || pathErr.Path != file {
+				t.Errorf("concurrent=%v: %q not rejected with fs.ErrInvalid: %v", concurrent, file, err)
+			}
+		}
+		if _, err := sub.GetDir(".."); !errors.Is(err, fs.ErrInvalid) {
+			t.Errorf("concurrent=%v: directory \"..\" not rejected with fs.ErrInvalid: %v", concurrent, err)
+		}
+	}
+}
+
+func TestStrictPathsArchive(t *testing.T) {
+	dir, err := os.MkdirTemp("", "parsecache-test-strict-archive-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	writeTestZip(filepath.Join(dir, "bundle.zip"), map[string]string{"a.json": `{"Hello": "a"}`})
+
+	for _, concurrent := range []bool{false, true} {
+		var cache testArchiveInterface
+		opts := []Option[testFileStructure]{WithStrictPaths[testFileStructure]()}
+		if concurrent {
+			cache = NewConcurrentFsCache(os.DirFS(dir), JsonParser[testFileStructure], time.Minute, opts...)
+		} else {
+			c := NewFsCache(os.DirFS(dir), JsonParser[testFileStructure], time.Minute, opts...)
+			cache = &c
+		}
+
+		if a, err := cache.GetArchiveFile("bundle.zip", "a.json"); err != nil || a.Hello != "a" {
+			t.Errorf("concurrent=%v: a.json not loaded: %+v, %v", concurrent, a, err)
+		}
+		for _, paths := range [][2]string{
+			{"x/../bundle.zip", "a.json"}, {"./bundle.zip", "a.json"},
+			{"bundle.zip", "../a.json"}, {"bundle.zip", "x/../a.json"}, {"bundle.zip", ""},
+		} {
+			_, err := cache.GetArchiveFile(paths[0], paths[1])
+			if !errors.Is(err, fs.ErrInvalid) {
+				t.Errorf("concurrent=%v: %q not rejected with fs.ErrInvalid: %v", concurrent, paths, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package parsecache
+
+import (
+	"io/fs"
+	pathpkg "path"
+	"strings"
+)
+
+// SubCache is a view of an `FsCache` or a `ConcurrentFsCache` where all paths are inside a prefix.
+// It shares the entries, locks and maximum age of the underlying cache.
+type SubCache[T any] struct {
+	// cache is the underlying cache.
+	cache subCacheParent[T]
+
+	// prefix is the cleaned prefix of all paths.
+	prefix string
+}
+
+var _ Cache[any] = SubCache[any]{}
+
+// subCacheParent is implemented by the caches underlying a `SubCache`.
+type subCacheParent[T any] interface {
+	GetFile(file string) (T, error)
+	GetDir(dir string) ([]fs.DirEntry, error)
+	GetFileResult(file string) (T, GetResult, error)
+	GetDirResult(dir string) ([]fs.DirEntry, GetResult, error)
+	// checkPath returns an error if `path` isn't valid and `WithStrictPaths` is used.
+	checkPath(path string) error
+	// clearPrefix removes the entries for `prefix` and all paths inside it from the cache.
+	clearPrefix(prefix string)
+}
+
+// Sub returns a view of the cache where all paths are inside `prefix`, so "config.json" accessed
+// through `Sub("app1")` is "/app1/config.json" in the cache, and can't collide with "config.json"
+// accessed through `Sub("app2")`. Paths are cleaned before the prefix is added, so ".." components
+// can't escape the prefix.
+//
+// The view is no more safe for concurrent use than the cache itself, so it mustn't be used
+// concurrently with the cache or other views of it.
+func (cache *FsCache[T]) Sub(prefix string) SubCache[T] {
+	return SubCache[T]{
+		cache:  cache,
+		prefix: cleanPath(prefix),
+	}
+}
+
+// Sub returns a view of the cache where all paths are inside `prefix`, so "config.json" accessed
+// through `Sub("app1")` is "/app1/config.json" in the cache, and can't collide with "config.json"
+// accessed through `Sub("app2")`. Paths are cleaned before the prefix is added, so ".." components
+// can't escape the prefix.
+func (cache *ConcurrentFsCache[T]) Sub(prefix string) SubCache[T] {
+	return SubCache[T]{
+		cache:  cache,
+		prefix: cleanPath(prefix),
+	}
+}
+
+// SubCache returns a new cache of the filesystem `fs.Sub(fs, prefix)` of `cache`, so all paths
+// in the new cache are relative to `prefix`, and, unlike `Sub`, the filesystem itself can't be
+// accessed outside of `prefix`. The new cache has the same parser, maximum age and options as
+// `cache`, but its own entries, circuit breaker state and statistics.
+func (cache *ConcurrentFsCache[T]) SubCache(prefix string) (*ConcurrentFsCache[T], error) {
+	dir := strings.TrimPrefix(cleanPath(prefix), "/")
+	if dir == "" {
+		dir = "."
+	}
+	settings := *cache.loadSettings()
+	fsys, err := fs.Sub(settings.fs, dir)
+	if err != nil {
+		return nil, err
+	}
+	settings.fs = fsys
+	sub := ConcurrentFsCache[T]{
+		options: cache.options,
+	}
+	sub.settings.Store(&settings)
+	sub.shards = newShards[T](sub.options.shards)
+	sub.breakers = newCircuitBreakers(sub.options.circuitBreaker)
+	sub.notExist = newNotExistFilter(sub.options.notExistFilter)
+	sub.Clear()
+	return &sub, nil
+}
+
+// Sub returns a view of the underlying cache where all paths are inside `prefix` inside the prefix
+// of `sub`.
+func (sub SubCache[T]) Sub(prefix string) SubCache[T] {
+	return SubCache[T]{
+		cache:  sub.cache,
+		prefix: pathpkg.Join(sub.prefix, cleanPath(prefix)),
+	}
+}
+
+// path returns the path in the underlying cache for `path` in the view. If `WithStrictPaths` is
+// used, `path` is checked before it's cleaned, so ".." components are rejected rather than being
+// resolved inside the prefix.
+func (sub SubCache[T]) path(path string) (string, error) {
+	if err := sub.cache.checkPath(path); err != nil {
+		return "", err
+	}
+	return pathpkg.Join(sub.prefix, cleanPath(path)), nil
+}
+
+// GetFile returns the parsed content of a file, which may be cached.
+func (sub SubCache[T]) GetFile(file string) (T, error) {
+	path, err := sub.path(file)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return sub.cache.GetFile(path)
+}
+
+// GetDir gets the entries of a directory, which may be cached.
+func (sub SubCache[T]) GetDir(dir string) ([]fs.DirEntry, error) {
+	path, err := sub.path(dir)
+	if err != nil {
+		return nil, err
+	}
+	return sub.cache.GetDir(path)
+}
+
+// GetFileResult is `GetFile`, but also returns how the file was got.
+func (sub SubCache[T]) GetFileResult(file string) (T, GetResult, error) {
+	path, err := sub.path(file)
+	if err != nil {
+		var zero T
+		return zero, GetResult{}, err
+	}
+	return sub.cache.GetFileResult(path)
+}
+
+// GetDirResult is `GetDir`, but also returns how the directory was got.
+func (sub SubCache[T]) GetDirResult(dir string) ([]fs.DirEntry, GetResult, error) {
+	path, err := sub.path(dir)
+	if err != nil {
+		return nil, GetResult{}, err
+	}
+	return sub.cache.GetDirResult(path)
+}
+
+// Clear the entries inside the prefix from the underlying cache.
+func (sub SubCache[T]) Clear() {
+	sub.cache.clearPrefix(sub.prefix)
+}
+
+// inPrefix returns a function which returns true if a path is `prefix` or inside it.
+func inPrefix(prefix string) func(path string) bool {
+	return func(path string) bool {
+		return path == prefix || strings.HasPrefix(path, prefix+"/") || prefix == "/"
+	}
+}
+
+// checkPath returns an error if `path` isn't valid and `WithStrictPaths` is used.
+func (cache *FsCache[T]) checkPath(path string) error {
+	return cache.options.checkPath(path)
+}
+
+// checkPath returns an error if `path` isn't valid and `WithStrictPaths` is used.
+func (cache *ConcurrentFsCache[T]) checkPath(path string) error {
+	return cache.options.checkPath(path)
+}
+
+// clearPrefix removes the entries for `prefix` and all paths inside it from the cache.
+func (cache *FsCache[T]) clearPrefix(prefix string) {
+	inside := inPrefix(cache.options.key(prefix))
+	for path := range cache.dirs {
+		if inside(path) {
+			delete(cache.dirs, path)
+		}
+	}
+	for path := range cache.files {
+		if inside(path) {
+			delete(cache.files, path)
+		}
+	}
+	for path := range cache.archives {
+		if inside(path) {
+			delete(cache.archives, path)
+		}
+	}
+}
+
+// clearPrefix removes the entries for `prefix` and all paths inside it from the cache.
+func (cache *ConcurrentFsCache[T]) clearPrefix(prefix string) {
+	inside := inPrefix(cache.options.key(prefix))
+
+	for i := range cache.shards {
+		shard := &cache.shards[i]
+		shard.dirsLock.Lock()
+		for path := range shard.dirs {
+			if inside(path) {
+				delete(shard.dirs, path)
+			}
+		}
+		shard.dirsLock.Unlock()
+
+		shard.filesLock.Lock()
+		for path := range shard.files {
+			if inside(path) {
+				delete(shard.files, path)
+			}
+		}
+		shard.filesLock.Unlock()
+	}
+
+	cache.archivesLock.Lock()
+	for path := range cache.archives {
+		if inside(path) {
+			delete(cache.archives, path)
+		}
+	}
+	cache.archivesLock.Unlock()
+}
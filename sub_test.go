@@ -0,0 +1,127 @@
+package parsecache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestSub(t *testing.T) {
+	dir, err := os.MkdirTemp("", "parsecache-test-sub-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Mkdir(filepath.Join(dir, "app1"), 0770)
+	os.Mkdir(filepath.Join(dir, "app2"), 0770)
+	os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"Hello": "root"}`), 0660)
+	os.WriteFile(filepath.Join(dir, "app1", "config.json"), []byte(`{"Hello": "app1"}`), 0660)
+	os.WriteFile(filepath.Join(dir, "app2", "config.json"), []byte(`{"Hello": "app2"}`), 0660)
+
+	for _, concurrent := range []bool{false, true} {
+		var cache interface {
+			Cache[testFileStructure]
+			Sub(string) SubCache[testFileStructure]
+		}
+		var hasFile, hasDir func(string) bool
+		if concurrent {
+			c := NewConcurrentFsCache(os.DirFS(dir), JsonParser[testFileStructure], time.Minute)
+			cache = c
+			hasFile = func(path string) bool { _, ok := c.GetFileEntry(path); return ok }
+			hasDir = func(path string) bool { _, ok := c.GetDirEntry(path); return ok }
+		} else {
+			c := NewFsCache(os.DirFS(dir), JsonParser[testFileStructure], time.Minute)
+			cache = &c
+			hasFile = func(path string) bool { _, ok := c.GetFileEntry(path); return ok }
+			hasDir = func(path string) bool { _, ok := c.GetDirEntry(path); return ok }
+		}
+		subCacheTests(t, cache, hasFile, hasDir)
+	}
+}
+
+func subCacheTests(t *testing.T, cache interface {
+	Cache[testFileStructure]
+	Sub(string) SubCache[testFileStructure]
+}, hasFile, hasDir func(string) bool) {
+	app1 := cache.Sub("app1")
+	app2 := cache.Sub("/app2/")
+	root := cache.Sub("/")
+
+	for _, test := range []struct {
+		cache    Cache[testFileStructure]
+		path     string
+		expected string
+	}{
+		{app1, "config.json", "app1"},
+		{app2, "config.json", "app2"},
+		{app1, "../config.json", "app1"},
+		{root.Sub("app2"), "../config.json", "app2"},
+		{cache, "config.json", "root"},
+	} {
+		config, err := test.cache.GetFile(test.path)
+		if err != nil {
+			panic(err)
+		}
+		if config.Hello != test.expected {
+			t.Errorf("%s not loaded correctly, got %q", test.path, config.Hello)
+		}
+	}
+	if !hasFile("/app1/config.json") {
+		t.Error("sub cache entry not stored in the underlying cache")
+	}
+	entries, err := app1.GetDir("/")
+	if err != nil {
+		panic(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "config.json" {
+		t.Error("sub cache directory not read correctly")
+	}
+
+	app1.Clear()
+	if hasFile("/app1/config.json") {
+		t.Error("sub cache Clear didn't remove its entries")
+	}
+	if hasDir("/app1") {
+		t.Error("sub cache Clear didn't remove its directories")
+	}
+	if !hasFile("/app2/config.json") {
+		t.Error("sub cache Clear removed another prefix's entries")
+	}
+	if !hasFile("/config.json") {
+		t.Error("sub cache Clear removed entries outside the prefix")
+	}
+}
+
+func TestSubCacheFS(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"config.json":      &fstest.MapFile{Data: []byte(`{"Hello": "root"}`)},
+		"app1/config.json": &fstest.MapFile{Data: []byte(`{"Hello": "app1"}`)},
+	}
+	cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+	sub, err := cache.SubCache("/app1/")
+	if err != nil {
+		panic(err)
+	}
+	for _, path := range []string{"config.json", "/config.json", "../config.json"} {
+		config, err := sub.GetFile(path)
+		if err != nil || config.Hello != "app1" {
+			t.Errorf("%s not loaded from the prefix: %+v, %v", path, config, err)
+		}
+	}
+	if _, ok := cache.GetFileEntry("/app1/config.json"); ok {
+		t.Error("sub cache entry stored in the original cache")
+	}
+	if sub.GetMaxAge() != time.Minute {
+		t.Error("sub cache maximum age not copied")
+	}
+
+	root, err := cache.SubCache("/")
+	if err != nil {
+		panic(err)
+	}
+	if config, err := root.GetFile("config.json"); err != nil || config.Hello != "root" {
+		t.Errorf("config.json not loaded from the root: %+v, %v", config, err)
+	}
+}
@@ -0,0 +1,68 @@
+package parsecache
+
+import (
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	pathpkg "path"
+	texttemplate "text/template"
+)
+
+// TextTemplateParser returns a `PathParser`, for use with `WithPathParser`, which parses a file as
+// a text template named after the file's base name. Together with the cache's change detection,
+// this reloads templates when they're edited.
+//
+// If `funcs` isn't nil, it's added to each template before parsing. If `base` isn't nil, each
+// file is parsed into a clone of it, so the template can use the templates defined in `base`, and
+// the cached templates are independent of each other and of `base`.
+func TextTemplateParser(funcs texttemplate.FuncMap, base *texttemplate.Template) PathParser[*texttemplate.Template] {
+	return func(path string, f fs.File) (*texttemplate.Template, error) {
+		content, err := io.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+		name := pathpkg.Base(path)
+		var tmpl *texttemplate.Template
+		if base != nil {
+			tmpl, err = base.Clone()
+			if err != nil {
+				return nil, err
+			}
+			tmpl = tmpl.New(name)
+		} else {
+			tmpl = texttemplate.New(name)
+		}
+		if funcs != nil {
+			tmpl.Funcs(funcs)
+		}
+		return tmpl.Parse(string(content))
+	}
+}
+
+// HtmlTemplateParser returns a `PathParser`, for use with `WithPathParser`, which parses a file as
+// an HTML template named after the file's base name, in the same way as `TextTemplateParser`.
+//
+// `base` can't have been executed, since executed HTML templates can't be cloned.
+func HtmlTemplateParser(funcs htmltemplate.FuncMap, base *htmltemplate.Template) PathParser[*htmltemplate.Template] {
+	return func(path string, f fs.File) (*htmltemplate.Template, error) {
+		content, err := io.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+		name := pathpkg.Base(path)
+		var tmpl *htmltemplate.Template
+		if base != nil {
+			tmpl, err = base.Clone()
+			if err != nil {
+				return nil, err
+			}
+			tmpl = tmpl.New(name)
+		} else {
+			tmpl = htmltemplate.New(name)
+		}
+		if funcs != nil {
+			tmpl.Funcs(funcs)
+		}
+		return tmpl.Parse(string(content))
+	}
+}
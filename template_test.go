@@ -0,0 +1,99 @@
+package parsecache
+
+import (
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	texttemplate "text/template"
+	"time"
+)
+
+func TestTextTemplateParser(t *testing.T) {
+	maxAge := time.Second / 10
+	dir, err := os.MkdirTemp("", "parsecache-test-template-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	os.WriteFile(filepath.Join(dir, "a.tmpl"), []byte(`{{template "header"}} {{upper .}}`), 0660)
+
+	base := texttemplate.Must(texttemplate.New("base").Parse(`{{define "header"}}Hello{{end}}`))
+	funcs := texttemplate.FuncMap{"upper": strings.ToUpper}
+	cache := NewFsCache[*texttemplate.Template](os.DirFS(dir), nil, maxAge, WithPathParser(TextTemplateParser(funcs, base)))
+
+	execute := func(tmpl *texttemplate.Template) string {
+		var out strings.Builder
+		err := tmpl.Execute(&out, "world")
+		if err != nil {
+			panic(err)
+		}
+		return out.String()
+	}
+
+	tmpl, err := cache.GetFile("a.tmpl")
+	if err != nil {
+		panic(err)
+	}
+	if tmpl.Name() != "a.tmpl" || execute(tmpl) != "Hello WORLD" {
+		t.Error("a.tmpl not parsed correctly")
+	}
+	if base.Lookup("a.tmpl") != nil {
+		t.Error("a.tmpl was added to the base template")
+	}
+
+	// Editing the template gives a new template after the maximum age.
+	os.WriteFile(filepath.Join(dir, "a.tmpl"), []byte(`{{template "header"}}, {{.}}!`), 0660)
+	time.Sleep(maxAge + time.Second/10)
+	edited, err := cache.GetFile("a.tmpl")
+	if err != nil {
+		panic(err)
+	}
+	if execute(edited) != "Hello, world!" {
+		t.Error("a.tmpl not reparsed after it was edited")
+	}
+	if execute(tmpl) != "Hello WORLD" {
+		t.Error("the previous template changed when a.tmpl was reparsed")
+	}
+
+	// A syntax error returns the previous template along with the error.
+	os.WriteFile(filepath.Join(dir, "a.tmpl"), []byte(`{{template "header"}} {{.`), 0660)
+	time.Sleep(maxAge + time.Second/10)
+	broken, err := cache.GetFile("a.tmpl")
+	if err == nil {
+		t.Error("a.tmpl with a syntax error parsed without an error")
+	}
+	if broken == nil || execute(broken) != "Hello, world!" {
+		t.Error("previous template not returned for the syntax error")
+	}
+}
+
+func TestHtmlTemplateParser(t *testing.T) {
+	dir, err := os.MkdirTemp("", "parsecache-test-template-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	os.WriteFile(filepath.Join(dir, "a.html"), []byte(`<p>{{.}}</p>`), 0660)
+	os.WriteFile(filepath.Join(dir, "bad.html"), []byte(`<p>{{.</p>`), 0660)
+
+	cache := NewConcurrentFsCache[*htmltemplate.Template](os.DirFS(dir), nil, time.Minute, WithPathParser(HtmlTemplateParser(nil, nil)))
+	tmpl, err := cache.GetFile("a.html")
+	if err != nil {
+		panic(err)
+	}
+	var out strings.Builder
+	err = tmpl.Execute(&out, "<b>")
+	if err != nil {
+		panic(err)
+	}
+	if tmpl.Name() != "a.html" || out.String() != "<p>&lt;b&gt;</p>" {
+		t.Errorf("a.html not parsed correctly: %s", out.String())
+	}
+
+	_, err = cache.GetFile("bad.html")
+	if err == nil {
+		t.Error("bad.html parsed without an error")
+	}
+}
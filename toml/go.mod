@@ -0,0 +1,12 @@
+module github.com/JOT85/parsecache/toml
+
+go 1.18
+
+// To build against a local checkout of github.com/JOT85/parsecache, rather than the version
+// required below, use a workspace in the repository root (which is ignored by git):
+//
+//	go work init . ./otel ./prometheus ./toml ./yaml
+
+require github.com/JOT85/parsecache v0.0.0-20261015013204-82d0fd020112
+
+require github.com/BurntSushi/toml v1.4.0
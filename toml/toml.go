@@ -0,0 +1,72 @@
+// Package toml provides parsers for using TOML files with parsecache.
+//
+// It's a separate module so that the main parsecache module doesn't depend on
+// github.com/BurntSushi/toml.
+//
+// Decode errors are returned unchanged by the parsers, so a `toml.ParseError`, with the position of
+// the error in the file, can still be retrieved with `errors.As` from the error returned by the
+// cache.
+package toml
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	burntsushi "github.com/BurntSushi/toml"
+	"github.com/JOT85/parsecache"
+)
+
+// Parser is a `parsecache.Parser` which parses a TOML file. Keys without a matching field in `T`
+// are ignored.
+func Parser[T any](f io.Reader) (T, error) {
+	var parsed T
+	_, err := burntsushi.NewDecoder(f).Decode(&parsed)
+	return parsed, err
+}
+
+// Document is a parsed TOML file, along with the keys in the file which weren't decoded.
+type Document[T any] struct {
+	// Value is the parsed content of the file.
+	Value T
+	// Undecoded is the keys which are in the file, but have no matching field in `Value`.
+	Undecoded []burntsushi.Key
+}
+
+// MetaParser is a `parsecache.Parser` which parses a TOML file, recording any keys without a
+// matching field in `T` in the `Document`.
+func MetaParser[T any](f io.Reader) (Document[T], error) {
+	var document Document[T]
+	meta, err := burntsushi.NewDecoder(f).Decode(&document.Value)
+	if err != nil {
+		return document, err
+	}
+	document.Undecoded = meta.Undecoded()
+	return document, nil
+}
+
+// UndecodedKeysError is returned by `StrictParser` for a file containing keys without a matching
+// field.
+type UndecodedKeysError struct {
+	Keys []burntsushi.Key
+}
+
+func (err *UndecodedKeysError) Error() string {
+	keys := make([]string, len(err.Keys))
+	for i, key := range err.Keys {
+		keys[i] = key.String()
+	}
+	return fmt.Sprintf("toml: undecoded keys: %s", strings.Join(keys, ", "))
+}
+
+// StrictParser returns a `parsecache.Parser` which parses a TOML file, failing with an
+// `*UndecodedKeysError` if the file contains any keys without a matching field in `T`.
+func StrictParser[T any]() parsecache.Parser[T] {
+	return func(f io.Reader) (T, error) {
+		document, err := MetaParser[T](f)
+		if err == nil && len(document.Undecoded) > 0 {
+			err = &UndecodedKeysError{Keys: document.Undecoded}
+		}
+		return document.Value, err
+	}
+}
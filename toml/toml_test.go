@@ -0,0 +1,115 @@
+package toml
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	burntsushi "github.com/BurntSushi/toml"
+	"github.com/JOT85/parsecache"
+)
+
+// duration is a `time.Duration` which is stored as a string, like "1m30s".
+type duration struct {
+	time.Duration
+}
+
+func (d *duration) UnmarshalText(text []byte) error {
+	var err error
+	d.Duration, err = time.ParseDuration(string(text))
+	return err
+}
+
+func (d duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+type testServer struct {
+	Host    string
+	Port    uint16
+	Timeout duration
+}
+
+type testFileStructure struct {
+	Name    string
+	Retries int
+	Server  testServer
+	Backoff []duration
+}
+
+func TestParser(t *testing.T) {
+	maxAge := time.Second / 10
+	dir, err := os.MkdirTemp("", "parsecache-test-toml-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	original := testFileStructure{
+		Name:    "example",
+		Retries: 3,
+		Server: testServer{
+			Host:    "localhost",
+			Port:    8080,
+			Timeout: duration{90 * time.Second},
+		},
+		Backoff: []duration{{time.Second}, {250 * time.Millisecond}},
+	}
+	var encoded bytes.Buffer
+	err = burntsushi.NewEncoder(&encoded).Encode(original)
+	if err != nil {
+		panic(err)
+	}
+	os.WriteFile(filepath.Join(dir, "a.toml"), encoded.Bytes(), 0660)
+	os.WriteFile(filepath.Join(dir, "bad.toml"), []byte("name = \"example\"\nretries = = 3\n"), 0660)
+	os.WriteFile(filepath.Join(dir, "unknown.toml"), []byte("name = \"example\"\n\n[server]\nhots = \"typo\"\n"), 0660)
+
+	cache := parsecache.NewConcurrentFsCache(os.DirFS(dir), Parser[testFileStructure], maxAge)
+	a, err := cache.GetFile("a.toml")
+	if err != nil {
+		panic(err)
+	}
+	if a.Name != original.Name || a.Retries != original.Retries || a.Server != original.Server {
+		t.Errorf("a.toml not parsed correctly: %+v", a)
+	}
+	if len(a.Backoff) != 2 || a.Backoff[0] != original.Backoff[0] || a.Backoff[1] != original.Backoff[1] {
+		t.Errorf("a.toml backoff not parsed correctly: %v", a.Backoff)
+	}
+
+	_, err = cache.GetFile("bad.toml")
+	var parseErr burntsushi.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("bad.toml didn't fail with a ParseError: %v", err)
+	}
+	if parseErr.Position.Line != 2 {
+		t.Errorf("bad.toml error on line %d, expected 2", parseErr.Position.Line)
+	}
+
+	_, err = cache.GetFile("unknown.toml")
+	if err != nil {
+		t.Error("unknown keys are an error with Parser")
+	}
+
+	meta := parsecache.NewConcurrentFsCache(os.DirFS(dir), MetaParser[testFileStructure], maxAge)
+	document, err := meta.GetFile("unknown.toml")
+	if err != nil {
+		panic(err)
+	}
+	if document.Value.Name != "example" || len(document.Undecoded) != 1 || document.Undecoded[0].String() != "server.hots" {
+		t.Errorf("unknown.toml undecoded keys not recorded correctly: %v", document.Undecoded)
+	}
+
+	strict := parsecache.NewConcurrentFsCache(os.DirFS(dir), StrictParser[testFileStructure](), maxAge)
+	_, err = strict.GetFile("unknown.toml")
+	var undecodedErr *UndecodedKeysError
+	if !errors.As(err, &undecodedErr) {
+		t.Errorf("unknown keys aren't an error with StrictParser: %v", err)
+	}
+	_, err = strict.GetFile("a.toml")
+	if err != nil {
+		t.Errorf("a.toml failed with StrictParser: %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package parsecache
+
+import (
+	"io/fs"
+	pathpkg "path"
+)
+
+// DirTree is a directory, along with its entries and the trees of its subdirectories.
+type DirTree struct {
+	// Name is the name of the directory, or "/" for the root of the filesystem.
+	Name string
+	// Path is the cleaned path of the directory.
+	Path string
+	// Entries are the entries of the directory, in the order returned by `GetDir`.
+	Entries []fs.DirEntry
+	// Children are the trees of the subdirectories, in the same order as their entries. It's nil
+	// for directories at the maximum depth.
+	Children []*DirTree
+}
+
+// GetDirTree returns the tree of the directory `root` and the directories under it, reading each
+// directory with `GetDir`, so every level of the tree is cached, and revalidated on its own. A
+// `maxDepth` of 1 reads only `root`, and if it isn't positive, the depth is unlimited.
+func (cache *FsCache[T]) GetDirTree(root string, maxDepth int) (*DirTree, error) {
+	return getDirTree(cache.GetDir, cache.normalize(root), maxDepth)
+}
+
+// GetDirTree returns the tree of the directory `root` and the directories under it, reading each
+// directory with `GetDir`, so every level of the tree is cached, and revalidated on its own. A
+// `maxDepth` of 1 reads only `root`, and if it isn't positive, the depth is unlimited.
+func (cache *ConcurrentFsCache[T]) GetDirTree(root string, maxDepth int) (*DirTree, error) {
+	return getDirTree(cache.GetDir, cache.normalize(root), maxDepth)
+}
+
+// getDirTree returns the tree under `root`, reading directories with `getDir`, to at most
+// `maxDepth` levels if it's positive. The tree is read iteratively, so very deep trees can't
+// exhaust the stack.
+func getDirTree(getDir func(string) ([]fs.DirEntry, error), root string, maxDepth int) (*DirTree, error) {
+	type pending struct {
+		tree  *DirTree
+		depth int
+	}
+	tree := &DirTree{Name: pathpkg.Base(root), Path: root}
+	stack := []pending{{tree, maxDepth}}
+	for len(stack) > 0 {
+		dir := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		entries, err := getDir(dir.tree.Path)
+		if err != nil {
+			return nil, err
+		}
+		dir.tree.Entries = entries
+		if dir.depth == 1 {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				child := &DirTree{Name: entry.Name(), Path: pathpkg.Join(dir.tree.Path, entry.Name())}
+				dir.tree.Children = append(dir.tree.Children, child)
+				stack = append(stack, pending{child, dir.depth - 1})
+			}
+		}
+	}
+	return tree, nil
+}
+
+// RecursiveGetDir returns the entries of the directory `root` and of each directory under it, keyed
+// by their cleaned paths, reading each directory with `GetDir`, so every directory is cached. A
+// `maxDepth` of 1 reads only `root`, and if it isn't positive, the depth is unlimited.
+func (cache *FsCache[T]) RecursiveGetDir(root string, maxDepth int) (map[string][]fs.DirEntry, error) {
+	return recursiveGetDir(cache.GetDir, cache.normalize(root), maxDepth)
+}
+
+// RecursiveGetDir returns the entries of the directory `root` and of each directory under it, keyed
+// by their cleaned paths, reading each directory with `GetDir`, so every directory is cached. A
+// `maxDepth` of 1 reads only `root`, and if it isn't positive, the depth is unlimited.
+func (cache *ConcurrentFsCache[T]) RecursiveGetDir(root string, maxDepth int) (map[string][]fs.DirEntry, error) {
+	return recursiveGetDir(cache.GetDir, cache.normalize(root), maxDepth)
+}
+
+// recursiveGetDir returns the entries of `root` and the directories under it, reading directories
+// with `getDir`, to at most `maxDepth` levels if it's positive. The directories are read
+// iteratively, like `getDirTree`.
+func recursiveGetDir(getDir func(string) ([]fs.DirEntry, error), root string, maxDepth int) (map[string][]fs.DirEntry, error) {
+	type pending struct {
+		path  string
+		depth int
+	}
+	dirs := make(map[string][]fs.DirEntry)
+	stack := []pending{{root, maxDepth}}
+	for len(stack) > 0 {
+		dir := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		entries, err := getDir(dir.path)
+		if err != nil {
+			return nil, err
+		}
+		dirs[dir.path] = entries
+		for _, entry := range entries {
+			if entry.IsDir() && dir.depth != 1 {
+				stack = append(stack, pending{pathpkg.Join(dir.path, entry.Name()), dir.depth - 1})
+			}
+		}
+	}
+	return dirs, nil
+}
@@ -0,0 +1,177 @@
+package parsecache
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// treeNames returns the names in the tree, in depth-first order, with "/" after directories.
+func treeNames(tree *DirTree) []string {
+	var names []string
+	children := tree.Children
+	for _, entry := range tree.Entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+			continue
+		}
+		names = append(names, entry.Name()+"/")
+		if len(children) > 0 {
+			if children[0].Name != entry.Name() {
+				panic("children not in the order of the entries")
+			}
+			names = append(names, treeNames(children[0])...)
+			children = children[1:]
+		}
+	}
+	return names
+}
+
+func equalNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestGetDirTree(t *testing.T) {
+	mapFS := fstest.MapFS{
+		"root.json":       &fstest.MapFile{},
+		"a/a.json":        &fstest.MapFile{},
+		"a/b/b.json":      &fstest.MapFile{},
+		"a/b/c/c.json":    &fstest.MapFile{},
+		"d/d.json":        &fstest.MapFile{},
+		"other/other.txt": &fstest.MapFile{},
+	}
+	filesystem := &countingFS{fs: mapFS}
+	cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+
+	tree, err := cache.GetDirTree("/", 0)
+	if err != nil {
+		panic(err)
+	}
+	expected := []string{"a/", "a.json", "b/", "b.json", "c/", "c.json", "d/", "d.json", "other/", "other.txt", "root.json"}
+	if tree.Name != "/" || tree.Path != "/" || !equalNames(treeNames(tree), expected) {
+		t.Errorf("tree not read correctly: %v", treeNames(tree))
+	}
+	opens := filesystem.Opens()
+	if opens != 6 {
+		t.Errorf("%d directories opened, expected 6", opens)
+	}
+
+	// Every level is cached.
+	_, err = cache.GetDirTree("/", 0)
+	if err != nil {
+		panic(err)
+	}
+	sub, err := cache.GetDirTree("a/b", 0)
+	if err != nil {
+		panic(err)
+	}
+	if !equalNames(treeNames(sub), []string{"b.json", "c/", "c.json"}) {
+		t.Errorf("subtree not read correctly: %v", treeNames(sub))
+	}
+	if sub.Name != "b" || sub.Path != "/a/b" || len(sub.Children) != 1 || sub.Children[0].Name != "c" || sub.Children[0].Path != "/a/b/c" {
+		t.Errorf("incorrect subtree: %+v", sub)
+	}
+	if filesystem.Opens() != opens {
+		t.Error("cached directories reopened")
+	}
+
+	_, err = cache.GetDirTree("missing", 0)
+	if err == nil {
+		t.Error("missing directory didn't return an error")
+	}
+
+	shallow := NewFsCache[testFileStructure](mapFS, JsonParser[testFileStructure], time.Minute)
+	tree, err = shallow.GetDirTree("/", 2)
+	if err != nil {
+		panic(err)
+	}
+	expected = []string{"a/", "a.json", "b/", "d/", "d.json", "other/", "other.txt", "root.json"}
+	if !equalNames(treeNames(tree), expected) {
+		t.Errorf("tree not limited to maxDepth: %v", treeNames(tree))
+	}
+}
+
+func TestGetDirTreeDeep(t *testing.T) {
+	// The tree is read iteratively, so its depth isn't limited by the stack.
+	path := strings.Repeat("d/", 1000) + "file.json"
+	cache := NewConcurrentFsCache(fstest.MapFS{path: &fstest.MapFile{}}, JsonParser[testFileStructure], time.Minute)
+	tree, err := cache.GetDirTree("/", 0)
+	if err != nil {
+		panic(err)
+	}
+	depth := 0
+	for len(tree.Children) == 1 {
+		tree = tree.Children[0]
+		depth++
+	}
+	if depth != 1000 || len(tree.Entries) != 1 || tree.Path+"/"+tree.Entries[0].Name() != "/"+path {
+		t.Errorf("deep tree not read correctly, got %d levels", depth)
+	}
+}
+
+func TestRecursiveGetDir(t *testing.T) {
+	mapFS := fstest.MapFS{
+		"root.json":    &fstest.MapFile{},
+		"a/a.json":     &fstest.MapFile{},
+		"a/b/b.json":   &fstest.MapFile{},
+		"a/b/c/c.json": &fstest.MapFile{},
+		"d/d.json":     &fstest.MapFile{},
+	}
+	for _, concurrent := range []bool{false, true} {
+		filesystem := &countingFS{fs: mapFS}
+		var recursiveGetDir func(string, int) (map[string][]fs.DirEntry, error)
+		if concurrent {
+			recursiveGetDir = NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute).RecursiveGetDir
+		} else {
+			cache := NewFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+			recursiveGetDir = cache.RecursiveGetDir
+		}
+
+		dirs, err := recursiveGetDir("/", 0)
+		if err != nil {
+			panic(err)
+		}
+		expected := map[string][]string{
+			"/":      {"a", "d", "root.json"},
+			"/a":     {"a.json", "b"},
+			"/a/b":   {"b.json", "c"},
+			"/a/b/c": {"c.json"},
+			"/d":     {"d.json"},
+		}
+		if len(dirs) != len(expected) {
+			t.Errorf("concurrent=%v: got %d directories", concurrent, len(dirs))
+		}
+		for path, names := range expected {
+			if !equalNames(entryNames(dirs[path]), names) {
+				t.Errorf("concurrent=%v: %s not read correctly: %v", concurrent, path, entryNames(dirs[path]))
+			}
+		}
+
+		// Every directory is cached.
+		opens := filesystem.Opens()
+		dirs, err = recursiveGetDir("a", 2)
+		if err != nil {
+			panic(err)
+		}
+		if len(dirs) != 2 || dirs["/a"] == nil || dirs["/a/b"] == nil {
+			t.Errorf("concurrent=%v: depth not limited: %d directories", concurrent, len(dirs))
+		}
+		if filesystem.Opens() != opens {
+			t.Errorf("concurrent=%v: cached directories reopened", concurrent)
+		}
+
+		if _, err := recursiveGetDir("missing", 0); err == nil {
+			t.Errorf("concurrent=%v: missing directory didn't return an error", concurrent)
+		}
+	}
+}
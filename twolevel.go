@@ -0,0 +1,184 @@
+package parsecache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// TwoLevelCache is a concurrency safe cache which keeps a bounded number of parsed files in memory
+// (the first level), and writes files evicted from memory to a directory on disk (the second level).
+//
+// When a file isn't in memory, or has changed, the second level is checked before the file is
+// parsed: if it holds a value for the file with the same size and modtime, that value is decoded
+// instead of parsing the file again. This is useful when parsing is much more expensive than
+// decoding, for example for large compiled artifacts.
+//
+// Values are stored on disk using encoding/gob, so `T` must be encodable with gob, typically by
+// implementing `gob.GobEncoder` and `gob.GobDecoder`.
+type TwoLevelCache[T any] struct {
+	// l1 is the in-memory cache.
+	l1 *ConcurrentFsCache[T]
+
+	// l2 is the on-disk store of evicted files.
+	l2 diskStore[T]
+}
+
+var _ Cache[any] = (*TwoLevelCache[any])(nil)
+
+// NewTwoLevelCache returns a new `TwoLevelCache` on top of the `fsys` filesystem, using `parser` to
+// parse the content of files and sets the maximum age of cache entries to `maxAge`. At most
+// `maxEntries` files are kept in memory, and evicted files are stored in the directory `dir`, which
+// must exist and should be dedicated to this cache.
+//
+// `opts` are applied to the in-memory cache, the `WithMaxEntries` option is overridden.
+func NewTwoLevelCache[T any](fsys fs.FS, parser Parser[T], maxAge time.Duration, maxEntries int, dir string, opts ...Option[T]) *TwoLevelCache[T] {
+	cache := &TwoLevelCache[T]{
+		l2: diskStore[T]{dir: dir},
+	}
+
+	// The eviction hook the caller set (if any) is wrapped, so it must be read first.
+	onEvict := newOptions(opts).onEvict
+
+	opts = append(
+		opts,
+		WithMaxEntries[T](maxEntries),
+		WithOnEvict(func(key string, entry *CachedFile[T]) {
+			// Failing to store the entry only means it will be parsed again, so errors are ignored.
+			_ = cache.l2.store(key, entry)
+			if onEvict != nil {
+				onEvict(key, entry)
+			}
+		}),
+		func(o *options[T]) {
+			o.secondLevel = func(key string, parse fileParser[T]) fileParser[T] {
+				return func(ctx context.Context, f fs.File, info fs.FileInfo) (T, error) {
+					if content, ok := cache.l2.load(key, info.Size(), info.ModTime()); ok {
+						return content, nil
+					}
+					return parse(ctx, f, info)
+				}
+			}
+		},
+	)
+	cache.l1 = NewConcurrentFsCache(fsys, parser, maxAge, opts...)
+	return cache
+}
+
+// GetFile returns the parsed content of a file, which may be cached in memory or on disk.
+func (cache *TwoLevelCache[T]) GetFile(file string) (T, error) {
+	return cache.l1.GetFile(file)
+}
+
+// GetDir gets the entries of a directory, which may be cached.
+func (cache *TwoLevelCache[T]) GetDir(dir string) ([]fs.DirEntry, error) {
+	return cache.l1.GetDir(dir)
+}
+
+// GetFileResult is `GetFile`, but also returns how the file was got. A file decoded from disk is
+// reported as parsed.
+func (cache *TwoLevelCache[T]) GetFileResult(file string) (T, GetResult, error) {
+	return cache.l1.GetFileResult(file)
+}
+
+// GetDirResult is `GetDir`, but also returns how the directory was got.
+func (cache *TwoLevelCache[T]) GetDirResult(dir string) ([]fs.DirEntry, GetResult, error) {
+	return cache.l1.GetDirResult(dir)
+}
+
+// withSecondLevel returns `parser`, wrapped by the hook set by `NewTwoLevelCache` for the file
+// with the key `key`, if there is one.
+func (o *options[T]) withSecondLevel(key string, parser fileParser[T]) fileParser[T] {
+	if o.secondLevel == nil {
+		return parser
+	}
+	return o.secondLevel(key, parser)
+}
+
+// Clear the cache, both in memory and on disk.
+func (cache *TwoLevelCache[T]) Clear() {
+	cache.l1.Clear()
+	cache.l2.clear()
+}
+
+// diskStore stores parsed files on disk, encoded with encoding/gob.
+type diskStore[T any] struct {
+	// dir is the directory the files are stored in.
+	dir string
+}
+
+// diskRecord is the content of a file in a `diskStore`.
+type diskRecord[T any] struct {
+	// Path is the key of the entry of the file the content was parsed from.
+	Path string
+	// Size is the size of the file the content was parsed from.
+	Size int64
+	// ModTime is the modtime of the file the content was parsed from.
+	ModTime time.Time
+	// Content is the parsed content.
+	Content T
+}
+
+// filename returns the name of the file in the store for the entry with the key `key`.
+func (store *diskStore[T]) filename(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(store.dir, hex.EncodeToString(sum[:])+".gob")
+}
+
+// load returns the stored content for the entry with the key `key`, only if it was parsed from a
+// file of the given size and modtime.
+func (store *diskStore[T]) load(key string, size int64, modTime time.Time) (T, bool) {
+	var record diskRecord[T]
+	f, err := os.Open(store.filename(key))
+	if err != nil {
+		return record.Content, false
+	}
+	defer f.Close()
+	err = gob.NewDecoder(f).Decode(&record)
+	if err != nil || record.Path != key || record.Size != size || !record.ModTime.Equal(modTime) {
+		var zero T
+		return zero, false
+	}
+	return record.Content, true
+}
+
+// store writes the content of `entry` to the store for the entry with the key `key`.
+func (store *diskStore[T]) store(key string, entry *CachedFile[T]) error {
+	content, _, ok := entry.Cached()
+	if !ok {
+		return nil
+	}
+
+	// Write to a temporary file and rename it, so a partially written record is never read.
+	f, err := os.CreateTemp(store.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	err = gob.NewEncoder(f).Encode(diskRecord[T]{
+		Path:    key,
+		Size:    entry.Size(),
+		ModTime: entry.ModTime(),
+		Content: content,
+	})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), store.filename(key))
+}
+
+// clear removes all of the stored files.
+func (store *diskStore[T]) clear() {
+	names, _ := filepath.Glob(filepath.Join(store.dir, "*.gob"))
+	for _, name := range names {
+		os.Remove(name)
+	}
+}
@@ -0,0 +1,190 @@
+package parsecache
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func maxEntriesTests(t *testing.T, cache testInterface, cached func(string) bool, evicted *[]string) {
+	for _, name := range []string{"a.json", "b.json", "a.json", "c.json"} {
+		_, err := cache.GetFile(name)
+		if err != nil {
+			panic(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if len(*evicted) != 1 || (*evicted)[0] != "/b.json" {
+		t.Errorf("least recently used entry not evicted: %v", *evicted)
+	}
+	if cached("b.json") {
+		t.Error("evicted entry still cached")
+	}
+	if !cached("a.json") || !cached("c.json") {
+		t.Error("entries not cached")
+	}
+}
+
+func TestMaxEntries(t *testing.T) {
+	dir, err := os.MkdirTemp("", "parsecache-test-max-entries-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"Number": 1}`), 0660)
+	os.WriteFile(filepath.Join(dir, "b.json"), []byte(`{"Number": 2}`), 0660)
+	os.WriteFile(filepath.Join(dir, "c.json"), []byte(`{"Number": 3}`), 0660)
+
+	var evicted []string
+	onEvict := WithOnEvict(func(path string, entry *CachedFile[testFileStructure]) {
+		content, _, _ := entry.Cached()
+		if content.Number == 0 {
+			t.Error("evicted entry has no content")
+		}
+		evicted = append(evicted, path)
+	})
+
+	cache := NewFsCache(os.DirFS(dir), JsonParser[testFileStructure], time.Second, WithMaxEntries[testFileStructure](2), onEvict)
+	maxEntriesTests(t, &cache, func(path string) bool {
+		_, ok := cache.GetFileEntry(path)
+		return ok
+	}, &evicted)
+
+	evicted = nil
+	concurrent := NewConcurrentFsCache(os.DirFS(dir), JsonParser[testFileStructure], time.Second, WithMaxEntries[testFileStructure](2), onEvict)
+	maxEntriesTests(t, concurrent, func(path string) bool {
+		_, ok := concurrent.GetFileEntry(path)
+		return ok
+	}, &evicted)
+}
+
+func TestTwoLevelCache(t *testing.T) {
+	dir, err := os.MkdirTemp("", "parsecache-test-two-level-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	l2Dir, err := os.MkdirTemp("", "parsecache-test-two-level-l2-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(l2Dir)
+	os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"Hello": "a"}`), 0660)
+	os.WriteFile(filepath.Join(dir, "b.json"), []byte(`{"Hello": "b"}`), 0660)
+
+	parses := 0
+	parser := func(f io.Reader) (testFileStructure, error) {
+		parses++
+		return JsonParser[testFileStructure](f)
+	}
+	cache := NewTwoLevelCache(os.DirFS(dir), parser, time.Second, 1, l2Dir)
+
+	get := func(name, expected string) {
+		value, err := cache.GetFile(name)
+		if err != nil {
+			panic(err)
+		}
+		if value.Hello != expected {
+			t.Errorf("%s not loaded correctly: %v", name, value)
+		}
+	}
+
+	get("a.json", "a")
+	get("b.json", "b")
+	if parses != 2 {
+		t.Error("files not parsed")
+	}
+	stored, _ := filepath.Glob(filepath.Join(l2Dir, "*.gob"))
+	if len(stored) != 1 {
+		t.Error("evicted file not stored on disk")
+	}
+
+	// a.json is no longer in memory, but should be loaded from disk without parsing.
+	get("a.json", "a")
+	if parses != 2 {
+		t.Error("file stored on disk was parsed again")
+	}
+
+	// b.json was evicted in turn, and a.json stored again. Once a.json has changed, the stored value
+	// should be ignored.
+	get("b.json", "b")
+	os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"Hello": "changed"}`), 0660)
+	get("a.json", "changed")
+	if parses != 3 {
+		t.Error("changed file wasn't parsed")
+	}
+
+	cache.Clear()
+	stored, _ = filepath.Glob(filepath.Join(l2Dir, "*.gob"))
+	if len(stored) != 0 {
+		t.Error("Clear didn't remove files stored on disk")
+	}
+}
+
+func TestTwoLevelCacheKeyFunc(t *testing.T) {
+	dir, err := os.MkdirTemp("", "parsecache-test-two-level-key-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	l2Dir, err := os.MkdirTemp("", "parsecache-test-two-level-key-l2-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(l2Dir)
+	os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"Hello": "a"}`), 0660)
+	os.WriteFile(filepath.Join(dir, "b.json"), []byte(`{"Hello": "b"}`), 0660)
+
+	parses := 0
+	parser := func(f io.Reader) (testFileStructure, error) {
+		parses++
+		return JsonParser[testFileStructure](f)
+	}
+	keyFunc := WithKeyFunc[testFileStructure](func(cleaned string) string {
+		return "key:" + cleaned
+	})
+	cache := NewTwoLevelCache(os.DirFS(dir), parser, time.Second, 1, l2Dir, keyFunc)
+
+	for _, name := range []string{"a.json", "b.json", "a.json"} {
+		if _, err := cache.GetFile(name); err != nil {
+			panic(err)
+		}
+	}
+	if parses != 2 {
+		t.Errorf("file stored on disk under its key was parsed again, %d parses", parses)
+	}
+}
+
+func TestTwoLevelCacheParserFor(t *testing.T) {
+	dir, err := os.MkdirTemp("", "parsecache-test-two-level-ext-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	l2Dir, err := os.MkdirTemp("", "parsecache-test-two-level-ext-l2-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(l2Dir)
+	os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"Hello": "a"}`), 0660)
+	os.WriteFile(filepath.Join(dir, "b.json"), []byte(`{"Hello": "b"}`), 0660)
+
+	// Files with a parser set by WithParserFor are loaded from disk too.
+	parses := 0
+	parser := func(f io.Reader) (testFileStructure, error) {
+		parses++
+		return JsonParser[testFileStructure](f)
+	}
+	cache := NewTwoLevelCache(os.DirFS(dir), nil, time.Second, 1, l2Dir, WithParserFor(".json", parser))
+
+	for _, name := range []string{"a.json", "b.json", "a.json"} {
+		if _, err := cache.GetFile(name); err != nil {
+			panic(err)
+		}
+	}
+	if parses != 2 {
+		t.Errorf("file with an extension's parser stored on disk was parsed again, %d parses", parses)
+	}
+}
@@ -0,0 +1,99 @@
+package parsecache
+
+import "io/fs"
+
+// UntypedCacheEntry is a cached file or directory, with the parsed content of a file as an `any`, so
+// that it can be used without knowing the type of the cache's content.
+type UntypedCacheEntry struct {
+	EntryInfo
+	// Content is the parsed content of a file, or nil for a directory. Use `ContentAs` to get it as
+	// its type.
+	Content any
+	// DirEntries are the entries of a directory, or nil for a file.
+	DirEntries []fs.DirEntry
+}
+
+// ContentAs returns the parsed content of the file of `entry` as a `T`, and whether it's a file with
+// content of that type.
+func ContentAs[T any](entry UntypedCacheEntry) (T, bool) {
+	content, ok := entry.Content.(T)
+	return content, ok && !entry.Dir
+}
+
+// UntypedCache is implemented by `*FsCache` and `*ConcurrentFsCache`, for any type of content, for
+// tools which work with any cache, such as dashboards and exporters.
+type UntypedCache interface {
+	// Entries returns a description of each cached file and directory.
+	Entries() []EntryInfo
+	// UntypedGet returns the cache entry for a path, without loading it.
+	UntypedGet(path string) (UntypedCacheEntry, bool)
+}
+
+var (
+	_ UntypedCache = (*FsCache[any])(nil)
+	_ UntypedCache = (*ConcurrentFsCache[any])(nil)
+)
+
+// UntypedGet returns the cache entry of the file or directory at `path`, and whether there's a
+// loaded entry for it, without loading it. If both a file and a directory are cached at `path`, the
+// file is returned.
+func (cache *FsCache[T]) UntypedGet(path string) (UntypedCacheEntry, bool) {
+	path = cache.normalize(path)
+	key := cache.options.key(path)
+	if f, ok := cache.files[key]; ok {
+		if entry, ok := untypedFile(path, f); ok {
+			return entry, true
+		}
+	}
+	if d, ok := cache.dirs[key]; ok {
+		return untypedDir(path, d)
+	}
+	return UntypedCacheEntry{}, false
+}
+
+// UntypedGet returns the cache entry of the file or directory at `path`, and whether there's a
+// loaded entry for it, without loading it. If both a file and a directory are cached at `path`, the
+// file is returned.
+func (cache *ConcurrentFsCache[T]) UntypedGet(path string) (UntypedCacheEntry, bool) {
+	cleaned := cache.normalize(path)
+	if f, ok := cache.GetFileEntry(path); ok {
+		f.lock.RLock()
+		entry, ok := untypedFile(cleaned, &f.cachedFile)
+		f.lock.RUnlock()
+		if ok {
+			return entry, true
+		}
+	}
+	if d, ok := cache.GetDirEntry(path); ok {
+		d.lock.RLock()
+		defer d.lock.RUnlock()
+		return untypedDir(cleaned, &d.cachedDir)
+	}
+	return UntypedCacheEntry{}, false
+}
+
+// untypedFile returns the `UntypedCacheEntry` of the file entry `f`, at `path`, and whether it's
+// loaded.
+func untypedFile[T any](path string, f *CachedFile[T]) (UntypedCacheEntry, bool) {
+	content, _, ok := f.Cached()
+	if !ok || f.notExistErr != nil {
+		return UntypedCacheEntry{}, false
+	}
+	return UntypedCacheEntry{
+		EntryInfo: f.entryInfo(path),
+		Content:   content,
+	}, true
+}
+
+// untypedDir returns the `UntypedCacheEntry` of the directory entry `d`, at `path`, and whether
+// it's loaded.
+func untypedDir(path string, d *CachedDir) (UntypedCacheEntry, bool) {
+	entries, _, ok := d.Cached()
+	if !ok {
+		return UntypedCacheEntry{}, false
+	}
+	return UntypedCacheEntry{
+		EntryInfo:  d.entryInfo(path),
+		DirEntries: entries,
+	}, true
+}
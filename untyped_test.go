@@ -0,0 +1,61 @@
+package parsecache
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// untypedTests checks `cache`, which must have "a.json" and "dir" loaded, but not "missing.json".
+func untypedTests(t *testing.T, cache UntypedCache) {
+	file, ok := cache.UntypedGet("a.json")
+	if !ok || file.Path != "/a.json" || file.Dir || file.DirEntries != nil || file.CachedAt.IsZero() {
+		t.Errorf("file entry not returned correctly: %+v, %v", file, ok)
+	}
+	content, ok := ContentAs[testFileStructure](file)
+	if !ok || content.Number != 1 {
+		t.Errorf("file content not returned correctly: %+v, %v", content, ok)
+	}
+	if _, ok := ContentAs[string](file); ok {
+		t.Error("file content returned as the wrong type")
+	}
+
+	dir, ok := cache.UntypedGet("dir")
+	if !ok || dir.Path != "/dir" || !dir.Dir || len(dir.DirEntries) != 1 || dir.Content != nil {
+		t.Errorf("directory entry not returned correctly: %+v, %v", dir, ok)
+	}
+	if _, ok := ContentAs[testFileStructure](dir); ok {
+		t.Error("directory returned content")
+	}
+
+	if _, ok := cache.UntypedGet("missing.json"); ok {
+		t.Error("entry returned for a file which wasn't loaded")
+	}
+	if len(cache.Entries()) != 2 {
+		t.Errorf("expected 2 entries, got %d", len(cache.Entries()))
+	}
+}
+
+func TestUntypedGet(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"a.json":    &fstest.MapFile{Data: []byte(`{"Number": 1}`)},
+		"dir/b.txt": &fstest.MapFile{},
+	}
+	for _, concurrent := range []bool{false, true} {
+		var cache testInterface
+		if concurrent {
+			cache = NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute, WithCompression[testFileStructure]())
+		} else {
+			c := NewFsCache(filesystem, JsonParser[testFileStructure], time.Minute)
+			cache = &c
+		}
+		if _, err := cache.GetFile("a.json"); err != nil {
+			panic(err)
+		}
+		if _, err := cache.GetDir("dir"); err != nil {
+			panic(err)
+		}
+		cache.GetFile("missing.json")
+		untypedTests(t, cache.(UntypedCache))
+	}
+}
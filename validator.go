@@ -0,0 +1,98 @@
+package parsecache
+
+import (
+	"io/fs"
+	"time"
+)
+
+// Validator decides whether a cached file is still valid, when it's revalidated after reaching its
+// maximum age, by comparing tokens stamped from the file when it was parsed and when it was
+// revalidated.
+type Validator interface {
+	// Stamp returns a token identifying the version of the opened `file`, which `info` describes.
+	// If it reads from `file`, it must return it to the start before returning, since it's parsed
+	// next if the file has changed. If it fails, the load fails with the error.
+	Stamp(info fs.FileInfo, file fs.File) (token any, err error)
+	// Same returns true if the file is unchanged between tokens `old` and `new`, in which case it
+	// isn't parsed again. `old` may have been stamped by another validator, such as
+	// `DefaultValidator` for an entry stored by `SetFileEntry`, or be nil, in which case it should
+	// return false.
+	Same(old, new any) bool
+}
+
+// DefaultValidator is the `Validator` which the caches use unless `WithValidator` is used: a file is
+// unchanged if its size and modtime are.
+var DefaultValidator Validator = defaultValidator{}
+
+type defaultValidator struct{}
+
+// sizeModTime is the token of `DefaultValidator`.
+type sizeModTime struct {
+	size    int64
+	modTime time.Time
+}
+
+func (defaultValidator) Stamp(info fs.FileInfo, _ fs.File) (any, error) {
+	return sizeModTime{info.Size(), info.ModTime()}, nil
+}
+
+func (defaultValidator) Same(old, new any) bool {
+	o, ok := old.(sizeModTime)
+	n, _ := new.(sizeModTime)
+	return ok && o.size == n.size && o.modTime.Equal(n.modTime)
+}
+
+// identityValidator is the `Validator` used by `WithFileIdentity`: a file is unchanged if
+// `validator` says it is, and its identity is the same.
+type identityValidator struct {
+	validator Validator
+}
+
+// identityToken is the token of `identityValidator`.
+type identityToken struct {
+	token any
+	id    fileID
+}
+
+func (v identityValidator) Stamp(info fs.FileInfo, file fs.File) (any, error) {
+	token, err := v.validator.Stamp(info, file)
+	if err != nil {
+		return nil, err
+	}
+	return identityToken{token, fileIdentity(info)}, nil
+}
+
+func (v identityValidator) Same(old, new any) bool {
+	o, ok := old.(identityToken)
+	n, _ := new.(identityToken)
+	return ok && o.id == n.id && v.validator.Same(o.token, n.token)
+}
+
+// fileValidator returns the `Validator` which files are revalidated by with `config`, given whether
+// the modtime of the file is zero. That's the validator set by `WithValidator`, or
+// `DefaultValidator`, along with the identity of the file if `WithFileIdentity` is used, unless the
+// file is revalidated by the hash of its content, because of `WithContentHash` or
+// `ZeroModTimeHash`.
+func (config loadConfig) fileValidator(zeroModTime bool) Validator {
+	if config.validator == nil && (config.contentHash || (zeroModTime && config.zeroModTime == ZeroModTimeHash)) {
+		return contentHashValidator{}
+	}
+	validator := config.validator
+	if validator == nil {
+		validator = DefaultValidator
+	}
+	if config.fileIdentity {
+		return identityValidator{validator}
+	}
+	return validator
+}
+
+// WithValidator revalidates files with `validator`, rather than by their size and modtime. It
+// replaces `WithContentHash`, but `WithFileIdentity` still applies, so files are only unchanged if
+// `validator` and their identity say so. Directories are still revalidated by their size and
+// modtime.
+func WithValidator[T any](validator Validator) Option[T] {
+	return func(o *options[T]) {
+		o.load.validator = validator
+	}
+}
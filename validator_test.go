@@ -0,0 +1,149 @@
+package parsecache
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// staticValidator is a `Validator` which considers files unchanged if `same` is true, and always
+// changed otherwise.
+type staticValidator struct {
+	same bool
+}
+
+func (v staticValidator) Stamp(fs.FileInfo, fs.File) (any, error) {
+	return nil, nil
+}
+
+func (v staticValidator) Same(old, new any) bool {
+	return v.same
+}
+
+// failingValidator is a `Validator` which fails to stamp files.
+type failingValidator struct{}
+
+var errStamp = errors.New("stamp failed")
+
+func (failingValidator) Stamp(fs.FileInfo, fs.File) (any, error) {
+	return nil, errStamp
+}
+
+func (failingValidator) Same(old, new any) bool {
+	return true
+}
+
+func TestValidator(t *testing.T) {
+	maxAge := time.Second / 20
+	modTime := time.Now().Add(-time.Hour)
+	for _, concurrent := range []bool{false, true} {
+		for _, test := range []struct {
+			name      string
+			validator Validator
+			// touched and changed are the numbers expected after the content is changed without the
+			// size or modtime, and then with the modtime.
+			touched, changed uint16
+		}{
+			{"none", nil, 1, 3},
+			{"default", DefaultValidator, 1, 3},
+			{"always stale", staticValidator{false}, 2, 3},
+			{"never stale", staticValidator{true}, 1, 1},
+		} {
+			mapFS := fstest.MapFS{
+				"a.json": &fstest.MapFile{Data: []byte(`{"Number": 1}`), ModTime: modTime},
+			}
+			var opts []Option[testFileStructure]
+			if test.validator != nil {
+				opts = append(opts, WithValidator[testFileStructure](test.validator))
+			}
+			var cache testInterface
+			if concurrent {
+				cache = NewConcurrentFsCache(mapFS, JsonParser[testFileStructure], maxAge, opts...)
+			} else {
+				fsCache := NewFsCache(mapFS, JsonParser[testFileStructure], maxAge, opts...)
+				cache = &fsCache
+			}
+			if _, err := cache.GetFile("a.json"); err != nil {
+				panic(err)
+			}
+
+			// The content is changed without the size or modtime, so it's only seen if the file is
+			// parsed again.
+			mapFS["a.json"] = &fstest.MapFile{Data: []byte(`{"Number": 2}`), ModTime: modTime}
+			time.Sleep(maxAge)
+			a, err := cache.GetFile("a.json")
+			if err != nil || a.Number != test.touched {
+				t.Errorf("%s, concurrent=%v: after touch got %+v, %v", test.name, concurrent, a, err)
+			}
+			mapFS["a.json"] = &fstest.MapFile{Data: []byte(`{"Number": 3}`), ModTime: time.Now()}
+			time.Sleep(maxAge)
+			a, err = cache.GetFile("a.json")
+			if err != nil || a.Number != test.changed {
+				t.Errorf("%s, concurrent=%v: after change got %+v, %v", test.name, concurrent, a, err)
+			}
+		}
+	}
+}
+
+func TestValidatorError(t *testing.T) {
+	filesystem := fstest.MapFS{
+		"a.json": &fstest.MapFile{Data: []byte(`{"Number": 1}`)},
+	}
+	cache := NewFsCache(filesystem, JsonParser[testFileStructure], time.Minute, WithValidator[testFileStructure](failingValidator{}))
+	_, err := cache.GetFile("a.json")
+	var pathErr *fs.PathError
+	if !errors.Is(err, errStamp) || !errors.As(err, &pathErr) || pathErr.Op != "parsecache.validate" {
+		t.Errorf("stamp error not returned: %v", err)
+	}
+}
+
+func TestFileValidator(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		config      loadConfig
+		zeroModTime bool
+		expected    Validator
+	}{
+		{"default", loadConfig{}, false, DefaultValidator},
+		{"custom", loadConfig{validator: staticValidator{true}}, false, staticValidator{true}},
+		{"content hash", loadConfig{contentHash: true}, false, contentHashValidator{}},
+		{"zero modtime hash", loadConfig{zeroModTime: ZeroModTimeHash}, true, contentHashValidator{}},
+		{"nonzero modtime hash", loadConfig{zeroModTime: ZeroModTimeHash}, false, DefaultValidator},
+		{"custom over content hash", loadConfig{contentHash: true, validator: staticValidator{true}}, false, staticValidator{true}},
+		{"identity", loadConfig{fileIdentity: true}, false, identityValidator{DefaultValidator}},
+		{"identity with content hash", loadConfig{contentHash: true, fileIdentity: true}, false, contentHashValidator{}},
+	} {
+		if v := test.config.fileValidator(test.zeroModTime); v != test.expected {
+			t.Errorf("%s: got validator %#v, expected %#v", test.name, v, test.expected)
+		}
+	}
+
+	// Tokens stamped by one validator are never the same as those of another, such as when a file's
+	// modtime becomes zero with `ZeroModTimeHash`.
+	mapFS := fstest.MapFS{"a": &fstest.MapFile{Data: []byte("a")}}
+	f, err := mapFS.Open("a")
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	stats, err := f.Stat()
+	if err != nil {
+		panic(err)
+	}
+	defaultToken, _ := DefaultValidator.Stamp(stats, f)
+	hashToken, err := contentHashValidator{}.Stamp(stats, f)
+	if err != nil {
+		panic(err)
+	}
+	if (contentHashValidator{}).Same(defaultToken, hashToken) || DefaultValidator.Same(hashToken, defaultToken) {
+		t.Error("tokens of different validators are the same")
+	}
+	if !(contentHashValidator{}).Same(hashToken, hashToken) {
+		t.Error("content hash token isn't the same as itself")
+	}
+	if (identityValidator{DefaultValidator}).Same(defaultToken, defaultToken) {
+		t.Error("identity validator accepted a token it didn't stamp")
+	}
+}
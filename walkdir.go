@@ -0,0 +1,123 @@
+package parsecache
+
+import (
+	"errors"
+	"io/fs"
+	pathpkg "path"
+)
+
+// WalkDir walks the tree rooted at `root`, calling `fn` for each file and directory, like
+// `fs.WalkDir`, but reading each directory with `GetDir`, so repeated walks of a mostly unchanged
+// tree are served from the cached directories. The paths passed to `fn` are cleaned, and the
+// entries of each directory are walked in the order returned by `GetDir`.
+//
+// `fn` is called, and its result handled, as by `fs.WalkDir`, including `fs.SkipDir` and, since Go
+// 1.20, `fs.SkipAll`. A directory which fails to be read, such as one removed during the walk, is
+// passed to `fn` a second time with the error. Each path is walked at most once, even if a
+// directory returns odd entries which clean to the same path.
+func (cache *FsCache[T]) WalkDir(root string, fn fs.WalkDirFunc) error {
+	return walkDir(cache.GetDirShared, cleanPath(root), fn)
+}
+
+// WalkDir walks the tree rooted at `root`, calling `fn` for each file and directory, like
+// `fs.WalkDir`, but reading each directory with `GetDir`, so repeated walks of a mostly unchanged
+// tree are served from the cached directories. The paths passed to `fn` are cleaned, and the
+// entries of each directory are walked in the order returned by `GetDir`.
+//
+// `fn` is called, and its result handled, as by `fs.WalkDir`, including `fs.SkipDir` and, since Go
+// 1.20, `fs.SkipAll`. A directory which fails to be read, such as one removed during the walk, is
+// passed to `fn` a second time with the error. Each path is walked at most once, even if a
+// directory returns odd entries which clean to the same path.
+func (cache *ConcurrentFsCache[T]) WalkDir(root string, fn fs.WalkDirFunc) error {
+	return walkDir(cache.GetDirShared, cleanPath(root), fn)
+}
+
+// walkDir walks the tree rooted at the cleaned path `root`, reading directories with `getDir`.
+func walkDir(getDir func(string) ([]fs.DirEntry, error), root string, fn fs.WalkDirFunc) error {
+	entry, err := rootEntry(getDir, root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		w := walker{getDir: getDir, fn: fn, walked: map[string]bool{root: true}}
+		err = w.walk(root, entry)
+	}
+	if err == fs.SkipDir || isSkipAll(err) {
+		return nil
+	}
+	return err
+}
+
+// rootEntry returns the entry of the cleaned path `root`, from the entries of its parent directory,
+// so it's cached too.
+func rootEntry(getDir func(string) ([]fs.DirEntry, error), root string) (fs.DirEntry, error) {
+	if root == "/" {
+		return fs.FileInfoToDirEntry(cachedInfo{name: "/", mode: fs.ModeDir}), nil
+	}
+	dir, name := pathpkg.Split(root)
+	entries, err := getDir(dir)
+	if errors.Is(err, ErrNotADirectory) {
+		err = fs.ErrNotExist
+	}
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: root, Err: unwrapPathErr(err)}
+	}
+	for _, entry := range entries {
+		if entry.Name() == name {
+			return entry, nil
+		}
+	}
+	return nil, &fs.PathError{Op: "stat", Path: root, Err: fs.ErrNotExist}
+}
+
+// unwrapPathErr returns the underlying error of `err` if it's an `*fs.PathError`, or `err`
+// otherwise.
+func unwrapPathErr(err error) error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err
+	}
+	return err
+}
+
+// walker is the state of a walk by `walkDir`.
+type walker struct {
+	getDir func(string) ([]fs.DirEntry, error)
+	fn     fs.WalkDirFunc
+	// walked is the set of the paths which have been walked.
+	walked map[string]bool
+}
+
+// walk walks `path`, whose entry is `entry`, and the tree under it if it's a directory.
+func (w *walker) walk(path string, entry fs.DirEntry) error {
+	if err := w.fn(path, entry, nil); err != nil || !entry.IsDir() {
+		if err == fs.SkipDir && entry.IsDir() {
+			err = nil
+		}
+		return err
+	}
+
+	entries, err := w.getDir(path)
+	if err != nil {
+		err = w.fn(path, entry, err)
+		if err != nil {
+			if err == fs.SkipDir {
+				err = nil
+			}
+			return err
+		}
+	}
+	for _, child := range entries {
+		childPath := pathpkg.Join(path, child.Name())
+		if w.walked[childPath] {
+			continue
+		}
+		w.walked[childPath] = true
+		if err := w.walk(childPath, child); err != nil {
+			if err == fs.SkipDir {
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
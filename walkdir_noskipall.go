@@ -0,0 +1,8 @@
+//go:build !go1.20
+
+package parsecache
+
+// isSkipAll returns true if `err` is `fs.SkipAll`, which doesn't exist before Go 1.20.
+func isSkipAll(err error) bool {
+	return false
+}
@@ -0,0 +1,10 @@
+//go:build go1.20
+
+package parsecache
+
+import "io/fs"
+
+// isSkipAll returns true if `err` is `fs.SkipAll`.
+func isSkipAll(err error) bool {
+	return err == fs.SkipAll
+}
@@ -0,0 +1,25 @@
+//go:build go1.20
+
+package parsecache
+
+import (
+	"io/fs"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWalkDirSkipAll(t *testing.T) {
+	cache := NewConcurrentFsCache(walkDirTestFS(), JsonParser[testFileStructure], time.Minute)
+	var paths []string
+	err := cache.WalkDir("/", func(path string, entry fs.DirEntry, err error) error {
+		paths = append(paths, path)
+		if path == "/dir/b.json" {
+			return fs.SkipAll
+		}
+		return err
+	})
+	if err != nil || !reflect.DeepEqual(paths, []string{"/", "/a.json", "/dir", "/dir/b.json"}) {
+		t.Errorf("incorrect walk with SkipAll: %v, %v", paths, err)
+	}
+}
@@ -0,0 +1,157 @@
+package parsecache
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// walkDirTestFS returns the filesystem walked by the tests of `WalkDir`.
+func walkDirTestFS() fstest.MapFS {
+	return fstest.MapFS{
+		"a.json":       &fstest.MapFile{Data: []byte(`{}`)},
+		"dir/b.json":   &fstest.MapFile{Data: []byte(`{}`)},
+		"dir/c/d.json": &fstest.MapFile{Data: []byte(`{}`)},
+		"skip/e.json":  &fstest.MapFile{Data: []byte(`{}`)},
+		"z.json":       &fstest.MapFile{Data: []byte(`{}`)},
+	}
+}
+
+func TestWalkDir(t *testing.T) {
+	for _, concurrent := range []bool{false, true} {
+		filesystem := &countingFS{fs: walkDirTestFS()}
+		var walk func(string, fs.WalkDirFunc) error
+		if concurrent {
+			walk = NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], time.Minute).WalkDir
+		} else {
+			c := NewFsCache[testFileStructure](filesystem, JsonParser[testFileStructure], time.Minute)
+			walk = c.WalkDir
+		}
+		walked := func(root string, skip string) ([]string, error) {
+			var paths []string
+			err := walk(root, func(path string, entry fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				paths = append(paths, path)
+				if path == skip {
+					return fs.SkipDir
+				}
+				return nil
+			})
+			return paths, err
+		}
+
+		paths, err := walked("/", "/skip")
+		expected := []string{"/", "/a.json", "/dir", "/dir/b.json", "/dir/c", "/dir/c/d.json", "/skip", "/z.json"}
+		if err != nil || !reflect.DeepEqual(paths, expected) {
+			t.Errorf("concurrent=%v: incorrect walk: %v, %v", concurrent, paths, err)
+		}
+
+		// Repeated walks are served from the cache.
+		opens := filesystem.Opens()
+		walked("/", "/skip")
+		if filesystem.Opens() != opens {
+			t.Errorf("concurrent=%v: second walk opened %d files", concurrent, filesystem.Opens()-opens)
+		}
+
+		// SkipDir on a file skips the rest of its directory.
+		paths, err = walked("dir", "/dir/b.json")
+		if err != nil || !reflect.DeepEqual(paths, []string{"/dir", "/dir/b.json"}) {
+			t.Errorf("concurrent=%v: incorrect walk skipping a file: %v, %v", concurrent, paths, err)
+		}
+
+		// A file can be walked on its own.
+		paths, err = walked("a.json", "")
+		if err != nil || !reflect.DeepEqual(paths, []string{"/a.json"}) {
+			t.Errorf("concurrent=%v: incorrect walk of a file: %v, %v", concurrent, paths, err)
+		}
+
+		if _, err = walked("missing", ""); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("concurrent=%v: walk of a missing root didn't fail with fs.ErrNotExist: %v", concurrent, err)
+		}
+
+		// Errors from `fn` stop the walk.
+		stop := errors.New("stop")
+		err = walk("/", func(path string, entry fs.DirEntry, err error) error {
+			if path == "/dir/c" {
+				return stop
+			}
+			return err
+		})
+		if err != stop {
+			t.Errorf("concurrent=%v: walk not stopped by an error: %v", concurrent, err)
+		}
+	}
+}
+
+func TestWalkDirRemoved(t *testing.T) {
+	filesystem := walkDirTestFS()
+	cache := NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], 0)
+	var failed []string
+	err := cache.WalkDir("/", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			failed = append(failed, path)
+			return nil
+		}
+		if path == "/dir" {
+			// Remove the directory before it's read.
+			for name := range filesystem {
+				if strings.HasPrefix(name, "dir/") {
+					delete(filesystem, name)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil || !reflect.DeepEqual(failed, []string{"/dir"}) {
+		t.Errorf("removed directory not passed to fn with an error: %v, %v", failed, err)
+	}
+}
+
+// walkDirBenchmarkDir returns a directory of 10 directories of 10 files.
+func walkDirBenchmarkDir(b *testing.B) string {
+	dir := b.TempDir()
+	for i := 0; i < 10; i++ {
+		sub := filepath.Join(dir, strconv.Itoa(i))
+		if err := os.Mkdir(sub, 0o755); err != nil {
+			panic(err)
+		}
+		for j := 0; j < 10; j++ {
+			if err := os.WriteFile(filepath.Join(sub, strconv.Itoa(j)+".json"), []byte(`{}`), 0o644); err != nil {
+				panic(err)
+			}
+		}
+	}
+	return dir
+}
+
+func BenchmarkWalkDir(b *testing.B) {
+	cache := NewConcurrentFsCache(os.DirFS(walkDirBenchmarkDir(b)), JsonParser[testFileStructure], time.Minute)
+	fn := func(path string, entry fs.DirEntry, err error) error { return err }
+	cache.WalkDir("/", fn)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := cache.WalkDir("/", fn); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFsWalkDir(b *testing.B) {
+	filesystem := os.DirFS(walkDirBenchmarkDir(b))
+	fn := func(path string, entry fs.DirEntry, err error) error { return err }
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := fs.WalkDir(filesystem, ".", fn); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
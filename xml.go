@@ -0,0 +1,262 @@
+package parsecache
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// XmlParser[T] is a value of type Parser[T] which parses a file as XML.
+//
+// The file is read as it's decoded, rather than all at once, though a cache reads it all at once
+// with `ReadFile` where it can, unless it's created with `WithStreamingReads`. A UTF-8 byte order
+// mark at the start of the file is ignored. Files declaring an encoding other than UTF-8 fail to parse, use
+// `XmlParserWithCharsetReader` to support them.
+func XmlParser[T any](f io.Reader) (T, error) {
+	return XmlParserWithCharsetReader[T](nil)(f)
+}
+
+// errXmlCharset is returned by the default `CharsetReader` used by `XmlParser`.
+var errXmlCharset = errors.New("parsecache: unsupported charset, use XmlParserWithCharsetReader to convert it to UTF-8")
+
+// XmlParserWithCharsetReader returns a `Parser` which parses a file as XML, like `XmlParser`, but
+// uses `charsetReader` to convert files which declare an encoding other than UTF-8.
+//
+// `charsetReader` is used as the decoder's `CharsetReader`, so it's passed the declared charset
+// and the rest of the file, and should return a reader of the file converted to UTF-8 (for example,
+// `charset.NewReaderLabel` from golang.org/x/net/html/charset). Files in UTF-16 must also be
+// converted before the XML declaration can be read, so they need a `Parser` which wraps `f` in a
+// decoding reader before calling this one.
+func XmlParserWithCharsetReader[T any](charsetReader func(charset string, input io.Reader) (io.Reader, error)) Parser[T] {
+	if charsetReader == nil {
+		charsetReader = func(string, io.Reader) (io.Reader, error) {
+			return nil, errXmlCharset
+		}
+	}
+	return func(f io.Reader) (T, error) {
+		decoder := xml.NewDecoder(f)
+		decoder.CharsetReader = charsetReader
+		var parsed T
+		err := decoder.Decode(&parsed)
+		return parsed, err
+	}
+}
+
+// XmlParserStrict[T] is a value of type Parser[T] which parses a file as XML, like `XmlParser`, but
+// fails if an element doesn't have a matching field in the destination struct, in the way that
+// `JsonParserStrict` fails for unknown keys. Unknown attributes are allowed.
+//
+// Elements are matched to fields as `encoding/xml` matches them: by the local name, and also by the
+// namespace if the field's tag has one, including through chains of elements such as "a>b", and a
+// field tagged ",any" or ",innerxml" allows any element. The contents of elements decoded into a
+// type which isn't a struct, or which implements `xml.Unmarshaler` or `encoding.TextUnmarshaler`,
+// are not checked. Unlike `XmlParser`, the whole file is read before it's decoded.
+func XmlParserStrict[T any](f io.Reader) (T, error) {
+	var parsed T
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return parsed, err
+	}
+	parsed, err = XmlParser[T](bytes.NewReader(content))
+	if err != nil {
+		return parsed, err
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.CharsetReader = func(string, io.Reader) (io.Reader, error) {
+		return nil, errXmlCharset
+	}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return parsed, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			checker := xmlChecker{decoder, make(map[reflect.Type]*xmlFields)}
+			return parsed, checker.check(reflect.TypeOf(&parsed).Elem(), start.Name.Local)
+		}
+	}
+}
+
+// xmlFields are the elements which can be decoded into a struct, or into an element of a chain of
+// elements of a struct's field.
+type xmlFields struct {
+	// elements are the fields, keyed by the local name of their element.
+	elements map[string]xmlField
+	// any is true if the struct has a field tagged ",any" or ",innerxml", so it allows any element.
+	any bool
+	// anyType is the type of the field tagged ",any", which any element missing from `elements` is
+	// decoded into, or nil if every element is allowed, as by ",innerxml".
+	anyType reflect.Type
+}
+
+// xmlField is an element which can be decoded into a struct.
+type xmlField struct {
+	// space is the namespace of the element, or empty if it may be in any namespace.
+	space string
+	// typ is the type the element is decoded into, or nil if the element is part of a chain.
+	typ reflect.Type
+	// chain are the elements inside the element, if it's part of a chain.
+	chain *xmlFields
+}
+
+// xmlChecker checks that every element of a document has a matching field.
+type xmlChecker struct {
+	decoder *xml.Decoder
+	// fields are the fields of each struct type which has been checked.
+	fields map[reflect.Type]*xmlFields
+}
+
+var (
+	xmlUnmarshalerType = reflect.TypeOf((*xml.Unmarshaler)(nil)).Elem()
+	xmlNameType        = reflect.TypeOf(xml.Name{})
+)
+
+// errXmlUnexpectedEOF is returned by `XmlParserStrict` if the file ends inside an element.
+var errXmlUnexpectedEOF = errors.New("parsecache: xml: unexpected EOF")
+
+// unknownXmlElementError returns the error from `XmlParserStrict` for the element at `path`, which
+// doesn't have a matching field.
+func unknownXmlElementError(path string) error {
+	return fmt.Errorf("parsecache: xml: unknown element <%s>", path)
+}
+
+// decodedType returns the type an element decoded into a value of type `t` is decoded into: the
+// element type of slices, other than `[]byte`, without any pointers.
+func decodedType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 {
+		t = t.Elem()
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+	}
+	return t
+}
+
+// check checks the contents of the element at `path`, whose start has just been read, decoded into
+// a value of type `t`, and reads up to the end of the element.
+func (c xmlChecker) check(t reflect.Type, path string) error {
+	t = decodedType(t)
+	pointer := reflect.PointerTo(t)
+	if t.Kind() != reflect.Struct || pointer.Implements(xmlUnmarshalerType) || pointer.Implements(textUnmarshalerType) {
+		return c.decoder.Skip()
+	}
+	fields, ok := c.fields[t]
+	if !ok {
+		fields = &xmlFields{elements: make(map[string]xmlField)}
+		fields.add(t)
+		c.fields[t] = fields
+	}
+	return c.checkFields(fields, path)
+}
+
+// checkFields checks the contents of the element at `path`, whose start has just been read, against
+// `fields`, and reads up to the end of the element.
+func (c xmlChecker) checkFields(fields *xmlFields, path string) error {
+	for {
+		token, err := c.decoder.Token()
+		if err == io.EOF {
+			return errXmlUnexpectedEOF
+		} else if err != nil {
+			return err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			childPath := path + ">" + token.Name.Local
+			field, ok := fields.elements[token.Name.Local]
+			switch {
+			case ok && (field.space == "" || field.space == token.Name.Space):
+				if field.chain != nil {
+					err = c.checkFields(field.chain, childPath)
+				} else {
+					err = c.check(field.typ, childPath)
+				}
+			case fields.any && fields.anyType != nil:
+				err = c.check(fields.anyType, childPath)
+			case fields.any:
+				err = c.decoder.Skip()
+			default:
+				return unknownXmlElementError(childPath)
+			}
+			if err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// add adds the fields of the struct type `t` to `fields`, as `encoding/xml` decodes them.
+func (fields *xmlFields) add(t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("xml")
+		if tag == "-" || field.Name == "XMLName" || (field.PkgPath != "" && !field.Anonymous) {
+			continue
+		}
+		name, flags, _ := strings.Cut(tag, ",")
+		switch {
+		case hasXmlFlag(flags, "attr") || hasXmlFlag(flags, "chardata") || hasXmlFlag(flags, "cdata") || hasXmlFlag(flags, "comment"):
+			continue
+		case hasXmlFlag(flags, "innerxml"):
+			fields.any, fields.anyType = true, nil
+			continue
+		case hasXmlFlag(flags, "any"):
+			if !fields.any {
+				fields.any, fields.anyType = true, field.Type
+			}
+			continue
+		}
+
+		typ := decodedType(field.Type)
+		if field.Anonymous && name == "" && typ.Kind() == reflect.Struct {
+			fields.add(typ)
+			continue
+		}
+		space := ""
+		if name == "" {
+			name = field.Name
+			if typ.Kind() == reflect.Struct {
+				// The element of a field without a name is named by its type's XMLName, if it has one.
+				if xmlName, ok := typ.FieldByName("XMLName"); ok && xmlName.Type == xmlNameType {
+					if typeName, _, _ := strings.Cut(xmlName.Tag.Get("xml"), ","); typeName != "" {
+						name = typeName
+					}
+				}
+			}
+		}
+		if i := strings.IndexByte(name, ' '); i >= 0 {
+			space, name = name[:i], name[i+1:]
+		}
+
+		chain := strings.Split(name, ">")
+		parent := fields
+		for _, element := range chain[:len(chain)-1] {
+			existing, ok := parent.elements[element]
+			if !ok || existing.chain == nil {
+				existing = xmlField{space: space, chain: &xmlFields{elements: make(map[string]xmlField)}}
+				parent.elements[element] = existing
+			}
+			parent = existing.chain
+		}
+		parent.elements[chain[len(chain)-1]] = xmlField{space: space, typ: field.Type}
+	}
+}
+
+// hasXmlFlag returns true if the comma separated `flags` of an XML tag include `flag`.
+func hasXmlFlag(flags, flag string) bool {
+	for _, f := range strings.Split(flags, ",") {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package parsecache
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+type testXmlItem struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:",chardata"`
+}
+
+type testXmlStructure struct {
+	Version string        `xml:"version,attr"`
+	Title   string        `xml:"meta>title"`
+	Items   []testXmlItem `xml:"items>item"`
+}
+
+func TestXmlParser(t *testing.T) {
+	document := `<?xml version="1.0"?>
+<config version="2">
+	<meta><title>Example</title></meta>
+	<items>
+		<item name="a">first</item>
+		<item name="b">second</item>
+	</items>
+</config>`
+	filesystem := fstest.MapFS{
+		"a.xml":       &fstest.MapFile{Data: []byte(document)},
+		"bom.xml":     &fstest.MapFile{Data: []byte("\uFEFF" + document)},
+		"bad.xml":     &fstest.MapFile{Data: []byte(`<config version="2"><meta></config>`)},
+		"latin-1.xml": &fstest.MapFile{Data: []byte(`<?xml version="1.0" encoding="ISO-8859-1"?><config version="1"/>`)},
+	}
+	cache := NewConcurrentFsCache(filesystem, XmlParser[testXmlStructure], 0)
+
+	for _, file := range []string{"a.xml", "bom.xml"} {
+		parsed, err := cache.GetFile(file)
+		if err != nil {
+			t.Errorf("%s failed to parse: %v", file, err)
+			continue
+		}
+		if parsed.Version != "2" || parsed.Title != "Example" {
+			t.Errorf("%s attributes or nested elements not parsed correctly: %+v", file, parsed)
+		}
+		if len(parsed.Items) != 2 || parsed.Items[1].Name != "b" || parsed.Items[1].Value != "second" {
+			t.Errorf("%s items not parsed correctly: %+v", file, parsed.Items)
+		}
+	}
+
+	_, err := cache.GetFile("bad.xml")
+	if err == nil {
+		t.Error("bad.xml parsed without an error")
+	}
+
+	_, err = cache.GetFile("latin-1.xml")
+	if err == nil || !strings.Contains(err.Error(), "XmlParserWithCharsetReader") {
+		t.Errorf("latin-1.xml didn't fail with the charset error: %v", err)
+	}
+
+	charsets := []string{}
+	charsetCache := NewConcurrentFsCache(filesystem, XmlParserWithCharsetReader[testXmlStructure](func(charset string, input io.Reader) (io.Reader, error) {
+		// The test file is only ASCII, so it doesn't need converting.
+		charsets = append(charsets, charset)
+		return input, nil
+	}), 0)
+	parsed, err := charsetCache.GetFile("latin-1.xml")
+	if err != nil {
+		t.Errorf("latin-1.xml failed to parse with a CharsetReader: %v", err)
+	}
+	if parsed.Version != "1" || len(charsets) != 1 || charsets[0] != "ISO-8859-1" {
+		t.Error("CharsetReader not used for latin-1.xml")
+	}
+}
+
+type testXmlStrictStructure struct {
+	XMLName xml.Name      `xml:"urn:example config"`
+	Version string        `xml:"version,attr"`
+	Title   string        `xml:"meta>title"`
+	Items   []testXmlItem `xml:"items>item"`
+	Owner   *struct {
+		Name string `xml:"name"`
+	} `xml:"urn:example owner"`
+	Updated time.Time `xml:"updated"`
+}
+
+func TestXmlParserStrict(t *testing.T) {
+	valid := `<config xmlns="urn:example" xmlns:x="urn:other" version="2" x:extra="allowed">
+	<meta><title>Example</title></meta>
+	<items><item name="a">first</item><item name="b">second</item></items>
+	<owner><name>Someone</name></owner>
+	<updated>2024-01-02T03:04:05Z</updated>
+</config>`
+	filesystem := fstest.MapFS{
+		"valid.xml":              &fstest.MapFile{Data: []byte(valid)},
+		"unknown.xml":            &fstest.MapFile{Data: []byte(`<config xmlns="urn:example"><unknown/></config>`)},
+		"unknown-nested.xml":     &fstest.MapFile{Data: []byte(`<config xmlns="urn:example"><owner><age>1</age></owner></config>`)},
+		"unknown-chain.xml":      &fstest.MapFile{Data: []byte(`<config xmlns="urn:example"><meta><subtitle/></meta></config>`)},
+		"wrong-namespace.xml":    &fstest.MapFile{Data: []byte(`<config xmlns="urn:example"><owner xmlns="urn:other"/></config>`)},
+		"wrong-root.xml":         &fstest.MapFile{Data: []byte(`<other xmlns="urn:example"/>`)},
+		"unknown-item-child.xml": &fstest.MapFile{Data: []byte(`<config xmlns="urn:example"><items><item><x/></item></items></config>`)},
+		"string-child.xml":       &fstest.MapFile{Data: []byte(`<config xmlns="urn:example"><meta><title>a<b/></title></meta></config>`)},
+	}
+	cache := NewConcurrentFsCache(filesystem, XmlParserStrict[testXmlStrictStructure], 0)
+
+	parsed, err := cache.GetFile("valid.xml")
+	if err != nil {
+		t.Errorf("valid.xml failed to parse: %v", err)
+	}
+	if parsed.Title != "Example" || len(parsed.Items) != 2 || parsed.Owner == nil || parsed.Owner.Name != "Someone" || parsed.Updated.Year() != 2024 {
+		t.Errorf("valid.xml not parsed correctly: %+v", parsed)
+	}
+
+	for file, element := range map[string]string{
+		"unknown.xml":            "config>unknown",
+		"unknown-nested.xml":     "config>owner>age",
+		"unknown-chain.xml":      "config>meta>subtitle",
+		"wrong-namespace.xml":    "config>owner",
+		"unknown-item-child.xml": "config>items>item>x",
+	} {
+		if _, err := cache.GetFile(file); err == nil || !strings.Contains(err.Error(), "<"+element+">") {
+			t.Errorf("%s didn't fail with an unknown element error for %s: %v", file, element, err)
+		}
+	}
+	if _, err := cache.GetFile("wrong-root.xml"); err == nil {
+		t.Error("wrong-root.xml parsed without an error")
+	}
+	// The content of elements which aren't decoded into structs isn't checked.
+	if _, err := cache.GetFile("string-child.xml"); err != nil {
+		t.Errorf("string-child.xml failed to parse: %v", err)
+	}
+
+	type anyStructure struct {
+		Known string   `xml:"known"`
+		Other []string `xml:",any"`
+	}
+	anyCache := NewConcurrentFsCache(fstest.MapFS{
+		"any.xml": &fstest.MapFile{Data: []byte(`<config><known>a</known><other>b</other></config>`)},
+	}, XmlParserStrict[anyStructure], 0)
+	if parsed, err := anyCache.GetFile("any.xml"); err != nil || len(parsed.Other) != 1 {
+		t.Errorf("any.xml not parsed with an any field: %+v, %v", parsed, err)
+	}
+}
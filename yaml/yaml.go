@@ -0,0 +1,61 @@
+// Package yaml provides parsers for using YAML files with parsecache.
+//
+// It's a separate module so that the main parsecache module doesn't depend on gopkg.in/yaml.v3.
+package yaml
+
+import (
+	"errors"
+	"io"
+
+	"github.com/JOT85/parsecache"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Options configures the parsers returned by `ParserWith` and `MultiParser`.
+type Options struct {
+	// Strict makes it an error for a document to contain keys without a matching field in the
+	// destination struct.
+	Strict bool
+}
+
+// newDecoder returns a decoder for `f` configured by `opts`.
+func newDecoder(f io.Reader, opts Options) *yamlv3.Decoder {
+	decoder := yamlv3.NewDecoder(f)
+	decoder.KnownFields(opts.Strict)
+	return decoder
+}
+
+// Parser is a `parsecache.Parser` which parses the first document in a YAML file.
+func Parser[T any](f io.Reader) (T, error) {
+	return ParserWith[T](Options{})(f)
+}
+
+// ParserWith returns a `parsecache.Parser` which parses the first document in a YAML file, with the
+// given options.
+func ParserWith[T any](opts Options) parsecache.Parser[T] {
+	return func(f io.Reader) (T, error) {
+		var parsed T
+		err := newDecoder(f, opts).Decode(&parsed)
+		return parsed, err
+	}
+}
+
+// MultiParser returns a `parsecache.Parser` which parses every document in a YAML file, with the
+// given options.
+func MultiParser[T any](opts Options) parsecache.Parser[[]T] {
+	return func(f io.Reader) ([]T, error) {
+		decoder := newDecoder(f, opts)
+		var documents []T
+		for {
+			var parsed T
+			err := decoder.Decode(&parsed)
+			if errors.Is(err, io.EOF) {
+				return documents, nil
+			}
+			if err != nil {
+				return documents, err
+			}
+			documents = append(documents, parsed)
+		}
+	}
+}
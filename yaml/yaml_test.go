@@ -0,0 +1,83 @@
+package yaml
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/JOT85/parsecache"
+)
+
+type testFileStructure struct {
+	Hello  string  `yaml:"hello"`
+	Number uint16  `yaml:"number"`
+	Float  float32 `yaml:"float"`
+}
+
+func TestParser(t *testing.T) {
+	maxAge := time.Second / 10
+	dir, err := os.MkdirTemp("", "parsecache-test-yaml-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	os.WriteFile(filepath.Join(dir, "a.yaml"), []byte("hello: world!\nnumber: 12\nfloat: -0.3\n"), 0660)
+	os.WriteFile(filepath.Join(dir, "bad.yaml"), []byte("hello: [unterminated\n"), 0660)
+	os.WriteFile(filepath.Join(dir, "unknown.yaml"), []byte("hello: world!\ntypo: 1\n"), 0660)
+	os.WriteFile(filepath.Join(dir, "multi.yaml"), []byte("number: 1\n---\nnumber: 2\n---\nnumber: 3\n"), 0660)
+
+	cache := parsecache.NewConcurrentFsCache(os.DirFS(dir), Parser[testFileStructure], maxAge)
+	a, err := cache.GetFile("a.yaml")
+	if err != nil {
+		panic(err)
+	}
+	if a.Hello != "world!" || a.Number != 12 || a.Float != -0.3 {
+		t.Error("a.yaml not parsed correctly")
+	}
+	a, err = cache.GetFile("a.yaml")
+	if err != nil {
+		panic(err)
+	}
+	if a.Hello != "world!" || a.Number != 12 || a.Float != -0.3 {
+		t.Error("a.yaml not cached correctly")
+	}
+
+	os.WriteFile(filepath.Join(dir, "a.yaml"), []byte("hello: change\nnumber: 12\nfloat: -0.3\n"), 0660)
+	time.Sleep(maxAge + time.Second/10)
+	a, err = cache.GetFile("a.yaml")
+	if err != nil {
+		panic(err)
+	}
+	if a.Hello != "change" {
+		t.Error("a.yaml not invalidated correctly")
+	}
+
+	_, err = cache.GetFile("bad.yaml")
+	if err == nil {
+		t.Error("bad.yaml parsed without an error")
+	}
+
+	_, err = cache.GetFile("unknown.yaml")
+	if err != nil {
+		t.Error("unknown keys are an error without Strict")
+	}
+	strict := parsecache.NewConcurrentFsCache(os.DirFS(dir), ParserWith[testFileStructure](Options{Strict: true}), maxAge)
+	_, err = strict.GetFile("unknown.yaml")
+	if err == nil {
+		t.Error("unknown keys aren't an error with Strict")
+	}
+
+	multi := parsecache.NewConcurrentFsCache(os.DirFS(dir), MultiParser[testFileStructure](Options{}), maxAge)
+	documents, err := multi.GetFile("multi.yaml")
+	if err != nil {
+		panic(err)
+	}
+	if len(documents) != 3 || documents[0].Number != 1 || documents[2].Number != 3 {
+		t.Error("multi.yaml not parsed correctly")
+	}
+	_, err = multi.GetFile("bad.yaml")
+	if err == nil {
+		t.Error("bad.yaml parsed without an error")
+	}
+}
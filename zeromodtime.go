@@ -0,0 +1,37 @@
+package parsecache
+
+// ZeroModTimePolicy is how files with a zero modtime, such as those of an `embed.FS` or of an
+// `fstest.MapFS` without a `ModTime`, are revalidated, see `WithZeroModTimePolicy`.
+type ZeroModTimePolicy int
+
+const (
+	// ZeroModTimeDefault revalidates files with a zero modtime like any other file, by their size and
+	// modtime, so they're only parsed again if their size changes.
+	ZeroModTimeDefault ZeroModTimePolicy = iota
+	// ZeroModTimeImmutable treats files with a zero modtime as never changing, so once parsed they're
+	// served from memory for the lifetime of the cache, without being opened again. This suits an
+	// `embed.FS`, whose content can't change.
+	ZeroModTimeImmutable
+	// ZeroModTimeHash revalidates files with a zero modtime by a hash of their content, like
+	// `WithContentHash`.
+	ZeroModTimeHash
+	// ZeroModTimeAlwaysReparse parses files with a zero modtime again whenever they're revalidated.
+	ZeroModTimeAlwaysReparse
+)
+
+// WithZeroModTimePolicy sets how files with a zero modtime are revalidated, since their modtime
+// can't show whether they have changed. Files whose modtime isn't zero, and directories, are
+// unaffected. It has no effect with `WithContentHash` or `WithValidator`, which don't use the
+// modtime.
+func WithZeroModTimePolicy[T any](policy ZeroModTimePolicy) Option[T] {
+	return func(o *options[T]) {
+		o.load.zeroModTime = policy
+	}
+}
+
+// immutable returns true if the loaded entry `f` is never revalidated, because of
+// `ZeroModTimeImmutable`.
+func (f *CachedFile[T]) immutable(config loadConfig) bool {
+	return config.zeroModTime == ZeroModTimeImmutable && config.validator == nil && !config.contentHash &&
+		!f.lastLoadTime.IsZero() && f.lastModTime.IsZero()
+}
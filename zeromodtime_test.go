@@ -0,0 +1,98 @@
+package parsecache
+
+import (
+	"embed"
+	"io"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+//go:embed testdata/zeromodtime
+var testZeroModTimeFS embed.FS
+
+func TestZeroModTimePolicy(t *testing.T) {
+	maxAge := time.Second / 20
+	for _, test := range []struct {
+		policy ZeroModTimePolicy
+		// number is the number expected after the content is changed without the size, and opens
+		// is the number of times the file is expected to be opened after the first load.
+		number uint16
+		opens  int
+	}{
+		{ZeroModTimeDefault, 1, 2},
+		{ZeroModTimeImmutable, 1, 0},
+		{ZeroModTimeHash, 2, 2},
+		{ZeroModTimeAlwaysReparse, 2, 2},
+	} {
+		for _, concurrent := range []bool{false, true} {
+			mapFS := fstest.MapFS{
+				"a.json": &fstest.MapFile{Data: []byte(`{"Number": 1}`)},
+			}
+			filesystem := &countingFS{fs: mapFS}
+			opt := WithZeroModTimePolicy[testFileStructure](test.policy)
+			var cache testInterface
+			if concurrent {
+				cache = NewConcurrentFsCache(filesystem, JsonParser[testFileStructure], maxAge, opt)
+			} else {
+				fsCache := NewFsCache(filesystem, JsonParser[testFileStructure], maxAge, opt)
+				cache = &fsCache
+			}
+			if _, err := cache.GetFile("a.json"); err != nil {
+				panic(err)
+			}
+			opens := filesystem.Opens()
+
+			// The file is revalidated once unchanged, and once after its content changes without its
+			// size.
+			time.Sleep(maxAge)
+			if _, err := cache.GetFile("a.json"); err != nil {
+				panic(err)
+			}
+			mapFS["a.json"] = &fstest.MapFile{Data: []byte(`{"Number": 2}`)}
+			time.Sleep(maxAge)
+			a, err := cache.GetFile("a.json")
+			if err != nil || a.Number != test.number {
+				t.Errorf("policy %d, concurrent=%v: got %+v, %v", test.policy, concurrent, a, err)
+			}
+			if filesystem.Opens()-opens != test.opens {
+				t.Errorf("policy %d, concurrent=%v: opened %d times", test.policy, concurrent, filesystem.Opens()-opens)
+			}
+		}
+	}
+}
+
+func TestZeroModTimePolicyEmbed(t *testing.T) {
+	maxAge := time.Second / 20
+	for _, test := range []struct {
+		policy ZeroModTimePolicy
+		// parses and opens are the number of times the file is expected to be parsed and opened by
+		// three gets.
+		parses, opens int
+	}{
+		{ZeroModTimeDefault, 1, 3},
+		{ZeroModTimeImmutable, 1, 1},
+		{ZeroModTimeHash, 1, 3},
+		{ZeroModTimeAlwaysReparse, 3, 3},
+	} {
+		filesystem := &countingFS{fs: testZeroModTimeFS}
+		parses := 0
+		parser := func(f io.Reader) (testFileStructure, error) {
+			parses++
+			return JsonParser[testFileStructure](f)
+		}
+		cache := NewConcurrentFsCache(filesystem, parser, maxAge, WithZeroModTimePolicy[testFileStructure](test.policy))
+		for i := 0; i < 3; i++ {
+			if i > 0 {
+				time.Sleep(maxAge)
+			}
+			a, err := cache.GetFile("testdata/zeromodtime/a.json")
+			if err != nil || a.Number != 1 {
+				t.Errorf("policy %d: got %+v, %v", test.policy, a, err)
+			}
+		}
+		if parses != test.parses || filesystem.Opens() != test.opens {
+			t.Errorf("policy %d: parsed %d and opened %d times", test.policy, parses, filesystem.Opens())
+		}
+	}
+}